@@ -4,9 +4,12 @@ import (
 	"log"
 	"time"
 
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
 	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/config"
 	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/handler"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/middleware"
 	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/reference"
 	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
 	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/router"
 	"github.com/gin-gonic/gin"
@@ -21,7 +24,7 @@ func main() {
 	var db *gorm.DB
 	var err error
 	for i := 1; i <= 10; i++ {
-		db, err = gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{})
+		db, err = gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{TranslateError: true})
 		if err == nil {
 			break
 		}
@@ -35,8 +38,34 @@ func main() {
 	// Auto-migrate models
 	if err := db.AutoMigrate(
 		&model.Inscription{},
+		&model.SequenceDossier{},
 		&model.Decision{},
 		&model.InscriptionHistorique{},
+		&model.Recours{},
+		&model.RecoursDocument{},
+		&model.MotifRefus{},
+		&model.Formulaire{},
+		&model.RegleEligibilite{},
+		&model.ParametresFormation{},
+		&model.CreneauEntretien{},
+		&model.ReservationEntretien{},
+		&model.CleIdempotence{},
+		&model.Groupe{},
+		&model.MembreGroupe{},
+		&model.Seance{},
+		&model.Presence{},
+		&model.AlerteAssiduite{},
+		&model.ModuleFormation{},
+		&model.Note{},
+		&model.Attestation{},
+		&model.Affectation{},
+		&model.DoublonSuspect{},
+		&model.DepassementSla{},
+		&model.PriseEnCharge{},
+		&model.SessionJury{},
+		&model.PublicationResultats{},
+		&model.MessageGroupe{},
+		&model.EnvoiMessage{},
 	); err != nil {
 		log.Fatalf("failed to migrate database: %v", err)
 	}
@@ -45,13 +74,98 @@ func main() {
 
 	// Repository
 	inscriptionRepo := repository.NewInscriptionRepository(db)
+	recoursRepo := repository.NewRecoursRepository(db)
+	motifRepo := repository.NewMotifRefusRepository(db)
+	formRepo := repository.NewFormulaireRepository(db)
+	regleRepo := repository.NewRegleEligibiliteRepository(db)
+	paramRepo := repository.NewParametresFormationRepository(db)
+	entretienRepo := repository.NewEntretienRepository(db)
+	idempotenceRepo := repository.NewIdempotenceRepository(db)
+	groupeRepo := repository.NewGroupeRepository(db)
+	seanceRepo := repository.NewSeanceRepository(db)
+	resultatRepo := repository.NewResultatRepository(db)
+	affectationRepo := repository.NewAffectationRepository(db)
+	doublonRepo := repository.NewDoublonRepository(db)
+	slaRepo := repository.NewSlaRepository(db)
+	fileRepo := repository.NewFileRepository(db)
+	juryRepo := repository.NewJuryRepository(db)
+	publicationRepo := repository.NewPublicationRepository(db)
+	messageRepo := repository.NewMessageRepository(db)
+
+	if err := motifRepo.SeedDefaults(model.DefaultMotifsRefus); err != nil {
+		log.Fatalf("failed to seed refusal reasons: %v", err)
+	}
+
+	// Clients for other services
+	programClient := client.NewProgramClient(cfg.ProgramServiceURL)
+	notificationClient := client.NewNotificationClient(cfg.NotificationServiceURL)
+	documentClient := client.NewDocumentClient(cfg.DocumentServiceURL, cfg.JWTSecret)
 
 	// Handler
-	inscriptionHandler := handler.NewInscriptionHandler(inscriptionRepo)
+	inscriptionHandler := handler.NewInscriptionHandler(
+		inscriptionRepo, motifRepo, formRepo, regleRepo, paramRepo, doublonRepo, juryRepo, programClient,
+		notificationClient, cfg.OfferConfirmationDays, reference.ParseCodes(cfg.InstitutionCodes),
+	)
+	recoursHandler := handler.NewRecoursHandler(recoursRepo, inscriptionRepo, documentClient, cfg.AppealWindowDays)
+	motifHandler := handler.NewMotifRefusHandler(motifRepo)
+	formHandler := handler.NewFormulaireHandler(formRepo, programClient)
+	paramHandler := handler.NewParametresFormationHandler(paramRepo, regleRepo, programClient)
+	entretienHandler := handler.NewEntretienHandler(entretienRepo, inscriptionRepo, programClient, notificationClient, cfg.InterviewOrganizer)
+	idempotenceHandler := handler.NewIdempotenceHandler(idempotenceRepo)
+	groupeHandler := handler.NewGroupeHandler(groupeRepo, inscriptionRepo, programClient)
+	seanceHandler := handler.NewSeanceHandler(
+		seanceRepo, inscriptionRepo, groupeRepo, paramRepo,
+		programClient, notificationClient, cfg.AttendanceThreshold,
+	)
+	resultatHandler := handler.NewResultatHandler(
+		resultatRepo, inscriptionRepo, seanceRepo, paramRepo, programClient, cfg.AttendanceThreshold,
+	)
+	affectationHandler := handler.NewAffectationHandler(
+		affectationRepo, inscriptionRepo, paramRepo, motifRepo, juryRepo, programClient, cfg.OfferConfirmationDays,
+	)
+	doublonHandler := handler.NewDoublonHandler(doublonRepo)
+	slaHandler := handler.NewSlaHandler(
+		slaRepo, paramRepo, notificationClient, cfg.DecisionSLAHours, cfg.SLAEscalationHours,
+	)
+	fileHandler := handler.NewFileHandler(
+		fileRepo, inscriptionRepo, paramRepo, programClient, cfg.DecisionSLAHours, cfg.QueueClaimHours,
+	)
+	juryHandler := handler.NewJuryHandler(juryRepo, programClient)
+	publicationHandler := handler.NewPublicationHandler(publicationRepo, programClient)
+	messageHandler := handler.NewMessageHandler(messageRepo, inscriptionRepo, programClient, notificationClient)
+	corbeilleHandler := handler.NewCorbeilleHandler(inscriptionRepo, documentClient, cfg.TrashRetentionDays)
+	espaceCandidatHandler := handler.NewEspaceCandidatHandler(
+		inscriptionRepo, recoursRepo, entretienRepo, programClient, documentClient,
+		cfg.RequiredDocuments, cfg.AppealWindowDays,
+	)
+	offreHandler := handler.NewOffreHandler(inscriptionRepo, notificationClient, cfg.OfferReminderHours)
+
+	idempotency := middleware.Idempotency(idempotenceRepo, time.Duration(cfg.IdempotencyTTLHours)*time.Hour)
 
 	// Router
 	r := gin.Default()
-	router.Setup(r, inscriptionHandler, cfg.JWTSecret)
+	router.Setup(r, router.Handlers{
+		Inscription:         inscriptionHandler,
+		Recours:             recoursHandler,
+		MotifRefus:          motifHandler,
+		Formulaire:          formHandler,
+		ParametresFormation: paramHandler,
+		Entretien:           entretienHandler,
+		Idempotence:         idempotenceHandler,
+		Groupe:              groupeHandler,
+		Seance:              seanceHandler,
+		Resultat:            resultatHandler,
+		Affectation:         affectationHandler,
+		Offre:               offreHandler,
+		Doublon:             doublonHandler,
+		Sla:                 slaHandler,
+		File:                fileHandler,
+		Jury:                juryHandler,
+		Publication:         publicationHandler,
+		Message:             messageHandler,
+		Corbeille:           corbeilleHandler,
+		EspaceCandidat:      espaceCandidatHandler,
+	}, idempotency, cfg.JWTSecret)
 
 	// Start server
 	log.Printf("application-service starting on port %s", cfg.Port)
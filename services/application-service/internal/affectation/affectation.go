@@ -0,0 +1,196 @@
+// Package affectation allocates candidates to formations from their ranked choices
+// (voeux) and the jury rankings, with the candidate-proposing deferred acceptance
+// algorithm (Gale–Shapley). The allocation is stable: no candidate and formation
+// would both rather be matched together than with their current match, and no
+// candidate could obtain a better choice without displacing a better-ranked one.
+package affectation
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+)
+
+// Voeu is one choice of a candidate: an inscription in a formation, with the
+// jury's score for it.
+type Voeu struct {
+	InscriptionID uint
+	FormationID   uint
+	Score         float64
+}
+
+// Candidat is a candidate with their choices, most wanted first.
+type Candidat struct {
+	CandidatID string
+	NomComplet string
+	Voeux      []Voeu
+}
+
+// Formation is a formation taking part in the allocation. Places is the number of
+// seats left; a negative value means unlimited.
+type Formation struct {
+	FormationID  uint
+	Capacite     *int
+	PlacesPrises int
+	Places       int
+}
+
+// prefere reports whether the jury ranks a before b: higher score first, then the
+// earlier application.
+func prefere(a, b Voeu) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	return a.InscriptionID < b.InscriptionID
+}
+
+// proposition is a candidate's choice held by a formation.
+type proposition struct {
+	candidat int
+	voeu     Voeu
+}
+
+// Allouer computes the allocation and explains it. Candidates are processed in the
+// given order, which makes the result deterministic.
+func Allouer(candidats []Candidat, formations []Formation) model.RapportAffectation {
+	places := make(map[uint]int, len(formations))
+	for _, f := range formations {
+		places[f.FormationID] = f.Places
+	}
+
+	// Deferred acceptance: every free candidate proposes to their next choice; a full
+	// formation keeps its best-ranked proposals and rejects the others.
+	suivant := make([]int, len(candidats))
+	retenues := make(map[uint][]proposition, len(formations))
+	libres := make([]int, 0, len(candidats))
+	for i := range candidats {
+		libres = append(libres, i)
+	}
+	for len(libres) > 0 {
+		c := libres[0]
+		libres = libres[1:]
+		if suivant[c] >= len(candidats[c].Voeux) {
+			continue
+		}
+		v := candidats[c].Voeux[suivant[c]]
+		suivant[c]++
+
+		n, ok := places[v.FormationID]
+		if !ok || n == 0 {
+			libres = append(libres, c)
+			continue
+		}
+		r := append(retenues[v.FormationID], proposition{candidat: c, voeu: v})
+		sort.SliceStable(r, func(i, j int) bool { return prefere(r[i].voeu, r[j].voeu) })
+		if n > 0 && len(r) > n {
+			libres = append(libres, r[n].candidat)
+			r = r[:n]
+		}
+		retenues[v.FormationID] = r
+	}
+
+	affecte := make(map[int]uint, len(candidats))
+	for _, r := range retenues {
+		for _, p := range r {
+			affecte[p.candidat] = p.voeu.InscriptionID
+		}
+	}
+
+	// Waiting lists: every choice better than the one obtained, in jury order
+	attente := make(map[uint][]Voeu)
+	for i, cand := range candidats {
+		for _, v := range cand.Voeux {
+			if v.InscriptionID == affecte[i] {
+				break
+			}
+			attente[v.FormationID] = append(attente[v.FormationID], v)
+		}
+	}
+	position := make(map[uint]int)
+	for _, l := range attente {
+		sort.SliceStable(l, func(i, j int) bool { return prefere(l[i], l[j]) })
+		for i, v := range l {
+			position[v.InscriptionID] = i + 1
+		}
+	}
+
+	rapport := model.RapportAffectation{
+		Formations: make([]model.BilanFormation, 0, len(formations)),
+		Candidats:  make([]model.AffectationCandidat, 0, len(candidats)),
+	}
+	for _, f := range formations {
+		b := model.BilanFormation{
+			FormationID:  f.FormationID,
+			Capacite:     f.Capacite,
+			PlacesPrises: f.PlacesPrises,
+			Admis:        len(retenues[f.FormationID]),
+			ListeAttente: len(attente[f.FormationID]),
+		}
+		if f.Places >= 0 {
+			offertes := f.Places
+			b.PlacesOffertes = &offertes
+		}
+		if r := retenues[f.FormationID]; len(r) > 0 {
+			barre := r[len(r)-1].voeu.Score
+			b.Barre = &barre
+		}
+		rapport.Formations = append(rapport.Formations, b)
+	}
+
+	for i, cand := range candidats {
+		ac := model.AffectationCandidat{
+			CandidatID: cand.CandidatID,
+			NomComplet: cand.NomComplet,
+			Voeux:      make([]model.ResultatVoeu, 0, len(cand.Voeux)),
+		}
+		obtenu := 0
+		for rang, v := range cand.Voeux {
+			rv := model.ResultatVoeu{
+				Rang:          rang + 1,
+				InscriptionID: v.InscriptionID,
+				FormationID:   v.FormationID,
+				Score:         v.Score,
+			}
+			switch {
+			case v.InscriptionID == affecte[i]:
+				obtenu = rang + 1
+				id, fid := v.InscriptionID, v.FormationID
+				ac.InscriptionID, ac.FormationID, ac.RangObtenu = &id, &fid, &obtenu
+				rv.Resultat = model.EtatAccepte
+				rv.Explication = fmt.Sprintf("admis avec %.2f (%d/%d admis)",
+					v.Score, rangAdmis(retenues[v.FormationID], v.InscriptionID), len(retenues[v.FormationID]))
+			case obtenu > 0:
+				rv.Resultat = model.EtatRefuse
+				rv.Explication = fmt.Sprintf("voeu n°%d obtenu", obtenu)
+			default:
+				rv.Resultat = model.EtatListeAttente
+				p := position[v.InscriptionID]
+				rv.PositionAttente = &p
+				rv.Explication = refus(places[v.FormationID], retenues[v.FormationID])
+			}
+			ac.Voeux = append(ac.Voeux, rv)
+		}
+		rapport.Candidats = append(rapport.Candidats, ac)
+	}
+	return rapport
+}
+
+// rangAdmis returns the position of an inscription among a formation's admitted candidates.
+func rangAdmis(r []proposition, inscriptionID uint) int {
+	for i, p := range r {
+		if p.voeu.InscriptionID == inscriptionID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// refus explains why a choice was not obtained. Stability guarantees it is because
+// the formation filled up with better-ranked candidates.
+func refus(places int, r []proposition) string {
+	if places == 0 || len(r) == 0 {
+		return "aucune place disponible"
+	}
+	return fmt.Sprintf("formation complète (%d place(s)), dernier admis avec %.2f", places, r[len(r)-1].voeu.Score)
+}
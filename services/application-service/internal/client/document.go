@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Document is the subset of document-service's Document used by this service.
+type Document struct {
+	ID            uint      `json:"id"`
+	InscriptionID uint      `json:"inscription_id"`
+	TypeDocument  string    `json:"type_document"`
+	NomFichier    string    `json:"nom_fichier"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// DocumentClient calls the internal endpoints of the Document Service. They need a
+// SYSTEM token, which the client signs itself with the JWT secret shared by the services.
+type DocumentClient struct {
+	baseURL   string
+	jwtSecret string
+	http      *http.Client
+}
+
+// NewDocumentClient creates a new DocumentClient.
+func NewDocumentClient(baseURL, jwtSecret string) *DocumentClient {
+	return &DocumentClient{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		jwtSecret: jwtSecret,
+		// Purging deletes every stored object of the inscription, one by one
+		http: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ListInscription returns the documents uploaded for an inscription.
+func (d *DocumentClient) ListInscription(ctx context.Context, inscriptionID uint) ([]Document, error) {
+	var body struct {
+		Data []Document `json:"data"`
+	}
+	path := fmt.Sprintf("/internal/inscriptions/%d/documents", inscriptionID)
+	if err := d.do(ctx, http.MethodGet, path, &body); err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+// PurgeInscription permanently deletes the documents of an inscription. It succeeds
+// again once they are gone.
+func (d *DocumentClient) PurgeInscription(ctx context.Context, inscriptionID uint) error {
+	return d.do(ctx, http.MethodDelete, fmt.Sprintf("/internal/inscriptions/%d/documents", inscriptionID), nil)
+}
+
+func (d *DocumentClient) do(ctx context.Context, method, path string, out interface{}) error {
+	token, err := d.serviceToken()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("document-service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("document-service returned %d for %s", resp.StatusCode, path)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// serviceToken signs a short-lived SYSTEM token for a call made on the service's own behalf.
+func (d *DocumentClient) serviceToken() (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": "application-service",
+		"role":    "SYSTEM",
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(d.jwtSecret))
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Attachment is a file sent along with a notification.
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Content     string `json:"content"` // base64
+}
+
+// NewAttachment base64-encodes raw content into an Attachment.
+func NewAttachment(filename, contentType string, content []byte) Attachment {
+	return Attachment{
+		Filename:    filename,
+		ContentType: contentType,
+		Content:     base64.StdEncoding.EncodeToString(content),
+	}
+}
+
+// Notification is the body of notification-service's POST /notifications.
+type Notification struct {
+	TemplateKey string                 `json:"templateKey"`
+	Language    string                 `json:"language,omitempty"`
+	Recipient   string                 `json:"recipient"`
+	Payload     map[string]interface{} `json:"payload"`
+	Attachments []Attachment           `json:"attachments,omitempty"`
+}
+
+// NotificationClient calls the Notification Service REST API.
+type NotificationClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewNotificationClient creates a new NotificationClient.
+func NewNotificationClient(baseURL string) *NotificationClient {
+	return &NotificationClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Receipt is notification-service's answer to a queued notification.
+type Receipt struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// Delivery statuses reported by notification-service. SENT and DEAD are final.
+const (
+	StatusPending  = "PENDING"
+	StatusSent     = "SENT"
+	StatusRetrying = "RETRYING"
+	StatusDead     = "DEAD"
+)
+
+// Send queues a notification. notification-service deduplicates on idempotencyKey,
+// so retrying with the same key never sends the e-mail twice.
+func (n *NotificationClient) Send(ctx context.Context, idempotencyKey string, notif Notification) error {
+	_, err := n.Queue(ctx, idempotencyKey, notif)
+	return err
+}
+
+// Queue is Send returning the receipt, whose ID tracks the delivery. Retrying with
+// the same key returns the receipt of the notification already queued.
+func (n *NotificationClient) Queue(ctx context.Context, idempotencyKey string, notif Notification) (*Receipt, error) {
+	body, err := json.Marshal(notif)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.baseURL+"/notifications", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	resp, err := n.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("notification-service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("notification-service returned %d", resp.StatusCode)
+	}
+	var receipt Receipt
+	if err := json.NewDecoder(resp.Body).Decode(&receipt); err != nil {
+		return nil, fmt.Errorf("notification-service: %w", err)
+	}
+	if receipt.ID == "" {
+		return nil, fmt.Errorf("notification-service did not queue the notification")
+	}
+	return &receipt, nil
+}
+
+// Status returns the delivery status of a queued notification.
+func (n *NotificationClient) Status(ctx context.Context, id string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.baseURL+"/notifications/"+url.PathEscape(id), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := n.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("notification-service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("notification-service returned %d", resp.StatusCode)
+	}
+	var body struct {
+		Notification *struct {
+			Status string `json:"status"`
+		} `json:"notification"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("notification-service: %w", err)
+	}
+	if body.Notification == nil {
+		return "", ErrNotFound
+	}
+	return body.Notification.Status, nil
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when the upstream service answers 404.
+var ErrNotFound = errors.New("ressource introuvable")
+
+// Formation is the subset of program-service's Formation used by this service.
+type Formation struct {
+	ID                   uint       `json:"id"`
+	EtablissementID      string     `json:"etablissement_id"`
+	CoordinateurID       string     `json:"coordinateur_id"`
+	Titre                string     `json:"titre"`
+	Etat                 string     `json:"etat"`
+	DateOuverture        *time.Time `json:"date_ouverture"`
+	DateFermeture        *time.Time `json:"date_fermeture"`
+	InscriptionsOuvertes bool       `json:"inscriptions_ouvertes"`
+}
+
+// ProgramClient calls the Program Service REST API.
+type ProgramClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewProgramClient creates a new ProgramClient.
+func NewProgramClient(baseURL string) *ProgramClient {
+	return &ProgramClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// GetFormation fetches a formation by ID.
+// authorization is forwarded as-is so program-service applies the caller's JWT.
+func (p *ProgramClient) GetFormation(ctx context.Context, id uint, authorization string) (*Formation, error) {
+	var body struct {
+		Data Formation `json:"data"`
+	}
+	if err := p.get(ctx, fmt.Sprintf("/formations/%d", id), authorization, &body); err != nil {
+		return nil, err
+	}
+	return &body.Data, nil
+}
+
+// ListFormations fetches the formations of an institution.
+func (p *ProgramClient) ListFormations(ctx context.Context, etablissementID, authorization string) ([]Formation, error) {
+	var body struct {
+		Data []Formation `json:"data"`
+	}
+	path := "/formations?etablissement_id=" + url.QueryEscape(etablissementID)
+	if err := p.get(ctx, path, authorization, &body); err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+func (p *ProgramClient) get(ctx context.Context, path, authorization string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("program-service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("program-service returned %d for %s", resp.StatusCode, path)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -3,31 +3,72 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 )
 
 // Config holds all configuration for the service.
 type Config struct {
-	Port      string
-	DBHost    string
-	DBPort    string
-	DBUser    string
-	DBPass    string
-	DBName    string
-	DBSSLMode string
-	JWTSecret string
+	Port                   string
+	DBHost                 string
+	DBPort                 string
+	DBUser                 string
+	DBPass                 string
+	DBName                 string
+	DBSSLMode              string
+	JWTSecret              string
+	AppealWindowDays       int
+	ProgramServiceURL      string
+	NotificationServiceURL string
+	DocumentServiceURL     string
+	InterviewOrganizer     string
+	IdempotencyTTLHours    int
+	AttendanceThreshold    int
+	OfferConfirmationDays  int
+	OfferReminderHours     int
+	InstitutionCodes       string
+	DecisionSLAHours       map[string]int
+	SLAEscalationHours     int
+	QueueClaimHours        int
+	TrashRetentionDays     int
+	RequiredDocuments      []string
 }
 
 // Load reads configuration from environment variables.
 func Load() *Config {
+	appealWindow, _ := strconv.Atoi(getEnv("APPEAL_WINDOW_DAYS", "15"))
+	idempotencyTTL, _ := strconv.Atoi(getEnv("IDEMPOTENCY_TTL_HOURS", "24"))
+	attendanceThreshold, _ := strconv.Atoi(getEnv("ATTENDANCE_THRESHOLD_PERCENT", "80"))
+	offerConfirmation, _ := strconv.Atoi(getEnv("OFFER_CONFIRMATION_DAYS", "7"))
+	offerReminder, _ := strconv.Atoi(getEnv("OFFER_REMINDER_HOURS", "48"))
+	slaEscalation, _ := strconv.Atoi(getEnv("SLA_ESCALATION_HOURS", "48"))
+	queueClaim, _ := strconv.Atoi(getEnv("QUEUE_CLAIM_HOURS", "4"))
+	trashRetention, _ := strconv.Atoi(getEnv("TRASH_RETENTION_DAYS", "30"))
+
 	return &Config{
-		Port:      getEnv("PORT", "3005"),
-		DBHost:    getEnv("DB_HOST", "localhost"),
-		DBPort:    getEnv("DB_PORT", "5435"),
-		DBUser:    getEnv("DB_USER", "application_user"),
-		DBPass:    getEnv("DB_PASSWORD", "application_pass"),
-		DBName:    getEnv("DB_NAME", "application_db"),
-		DBSSLMode: getEnv("DB_SSLMODE", "disable"),
-		JWTSecret: getEnv("JWT_SECRET", "changeme-super-secret-key"),
+		Port:                   getEnv("PORT", "3005"),
+		DBHost:                 getEnv("DB_HOST", "localhost"),
+		DBPort:                 getEnv("DB_PORT", "5435"),
+		DBUser:                 getEnv("DB_USER", "application_user"),
+		DBPass:                 getEnv("DB_PASSWORD", "application_pass"),
+		DBName:                 getEnv("DB_NAME", "application_db"),
+		DBSSLMode:              getEnv("DB_SSLMODE", "disable"),
+		JWTSecret:              getEnv("JWT_SECRET", "changeme-super-secret-key"),
+		AppealWindowDays:       appealWindow,
+		ProgramServiceURL:      getEnv("PROGRAM_SERVICE_URL", "http://localhost:3004"),
+		NotificationServiceURL: getEnv("NOTIFICATION_SERVICE_URL", "http://localhost:3007"),
+		DocumentServiceURL:     getEnv("DOCUMENT_SERVICE_URL", "http://localhost:3006"),
+		InterviewOrganizer:     getEnv("INTERVIEW_ORGANIZER_EMAIL", "no-reply@fst-cfc.local"),
+		IdempotencyTTLHours:    idempotencyTTL,
+		AttendanceThreshold:    attendanceThreshold,
+		OfferConfirmationDays:  offerConfirmation,
+		OfferReminderHours:     offerReminder,
+		InstitutionCodes:       getEnv("INSTITUTION_CODES", ""),
+		DecisionSLAHours:       parseHours(getEnv("DECISION_SLA_HOURS", "DOSSIER_SOUMIS=72,EN_VALIDATION=336")),
+		SLAEscalationHours:     slaEscalation,
+		QueueClaimHours:        queueClaim,
+		TrashRetentionDays:     trashRetention,
+		RequiredDocuments:      parseList(getEnv("REQUIRED_DOCUMENTS", "cv,diplom,photo")),
 	}
 }
 
@@ -39,6 +80,32 @@ func (c *Config) DSN() string {
 	)
 }
 
+// parseHours reads a list of durations such as "DOSSIER_SOUMIS=72,EN_VALIDATION=336".
+// Malformed or non-positive entries are ignored.
+func parseHours(s string) map[string]int {
+	hours := map[string]int{}
+	for _, entry := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(entry, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || n <= 0 {
+			continue
+		}
+		hours[strings.TrimSpace(key)] = n
+	}
+	return hours
+}
+
+// parseList reads a comma-separated list such as "cv,diplom,photo", dropping empty entries.
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -0,0 +1,72 @@
+// Package doublon scores how likely two inscriptions filed under different candidate
+// accounts belong to the same person.
+package doublon
+
+import (
+	"math"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+)
+
+// SimilariteNomMin is the trigram similarity from which two names count as a match.
+// Below SimilariteNomSeule, a name match alone does not raise a suspicion: common
+// names would flood the review queue.
+const (
+	SimilariteNomMin   = 0.6
+	SimilariteNomSeule = 0.9
+)
+
+// Poids of each criterion in the score.
+var poids = map[string]float64{
+	model.CritereCIN:       0.99,
+	model.CritereCNE:       0.99,
+	model.CritereEmail:     0.9,
+	model.CritereTelephone: 0.7,
+	model.CritereNom:       0.6,
+}
+
+// Correspondance describes what two inscriptions have in common. SimilariteNom is
+// the pg_trgm similarity of their accent-free, lower-cased names.
+type Correspondance struct {
+	CINEgal       bool
+	CNEEgal       bool
+	EmailEgal     bool
+	TelephoneEgal bool
+	SimilariteNom float64
+}
+
+// Evaluer returns the criteria met by a pair and its score between 0 and 1, or
+// false when the pair is not suspicious. Criteria combine as independent evidence:
+// score = 1 − Π(1 − poids × force).
+func Evaluer(c Correspondance) (float64, []string, bool) {
+	var criteres []string
+	reste := 1.0
+	ajouter := func(critere string, force float64) {
+		criteres = append(criteres, critere)
+		reste *= 1 - poids[critere]*force
+	}
+
+	if c.CINEgal {
+		ajouter(model.CritereCIN, 1)
+	}
+	if c.CNEEgal {
+		ajouter(model.CritereCNE, 1)
+	}
+	if c.EmailEgal {
+		ajouter(model.CritereEmail, 1)
+	}
+	if c.TelephoneEgal {
+		ajouter(model.CritereTelephone, 1)
+	}
+	if c.SimilariteNom >= SimilariteNomMin {
+		ajouter(model.CritereNom, c.SimilariteNom)
+	}
+
+	if len(criteres) == 0 {
+		return 0, nil, false
+	}
+	if len(criteres) == 1 && criteres[0] == model.CritereNom && c.SimilariteNom < SimilariteNomSeule {
+		return 0, nil, false
+	}
+	return math.Round((1-reste)*1000) / 1000, criteres, true
+}
@@ -0,0 +1,212 @@
+// Package eligibility evaluates a formation's entry requirements over the
+// application form answers of a candidate.
+package eligibility
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+)
+
+var operateurs = map[string]bool{
+	model.OperateurEgal: true, model.OperateurDifferent: true,
+	model.OperateurSuperieur: true, model.OperateurSupOuEgal: true,
+	model.OperateurInferieur: true, model.OperateurInfOuEgal: true,
+	model.OperateurParmi: true, model.OperateurHorsDe: true,
+	model.OperateurContient: true, model.OperateurRenseigne: true,
+	model.OperateurCorrespond: true,
+}
+
+// Check reports whether a rule is well-formed.
+func Check(r model.RegleEligibilite) error {
+	if r.Code == "" || r.Champ == "" {
+		return fmt.Errorf("règle %q: code et champ sont obligatoires", r.Code)
+	}
+	if !operateurs[r.Operateur] {
+		return fmt.Errorf("règle %q: opérateur %q non supporté", r.Code, r.Operateur)
+	}
+	if r.Operateur == model.OperateurRenseigne {
+		return nil
+	}
+	v, err := decode(r.Valeur)
+	if err != nil || v == nil {
+		return fmt.Errorf("règle %q: valeur manquante ou invalide", r.Code)
+	}
+	switch r.Operateur {
+	case model.OperateurParmi, model.OperateurHorsDe:
+		if _, ok := v.([]interface{}); !ok {
+			return fmt.Errorf("règle %q: l'opérateur %s attend une liste", r.Code, r.Operateur)
+		}
+	case model.OperateurSuperieur, model.OperateurSupOuEgal, model.OperateurInferieur, model.OperateurInfOuEgal:
+		if _, ok := v.(json.Number); !ok {
+			return fmt.Errorf("règle %q: l'opérateur %s attend un nombre", r.Code, r.Operateur)
+		}
+	case model.OperateurCorrespond:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("règle %q: l'opérateur regex attend une chaîne", r.Code)
+		}
+		if _, err := regexp.Compile(s); err != nil {
+			return fmt.Errorf("règle %q: expression régulière invalide", r.Code)
+		}
+	}
+	return nil
+}
+
+// Evaluate runs every rule against the answers and returns one result per rule,
+// in rule order. The second value is true when all rules are satisfied.
+func Evaluate(regles []model.RegleEligibilite, reponses []byte) ([]model.ResultatRegle, bool) {
+	answers := map[string]interface{}{}
+	if len(reponses) > 0 {
+		if v, err := decode(reponses); err == nil {
+			if m, ok := v.(map[string]interface{}); ok {
+				answers = m
+			}
+		}
+	}
+
+	eligible := true
+	resultats := make([]model.ResultatRegle, 0, len(regles))
+	for _, r := range regles {
+		ok, detail := evaluate(r, lookup(answers, r.Champ))
+		if !ok {
+			eligible = false
+		}
+		resultats = append(resultats, model.ResultatRegle{
+			Code:       r.Code,
+			Libelle:    r.Libelle,
+			Satisfaite: ok,
+			Detail:     detail,
+		})
+	}
+	return resultats, eligible
+}
+
+func evaluate(r model.RegleEligibilite, got interface{}) (bool, string) {
+	if r.Operateur == model.OperateurRenseigne {
+		if got == nil || got == "" {
+			return false, fmt.Sprintf("%s non renseigné", r.Champ)
+		}
+		return true, ""
+	}
+	if got == nil {
+		return false, fmt.Sprintf("%s non renseigné", r.Champ)
+	}
+
+	want, _ := decode(r.Valeur)
+
+	var ok bool
+	switch r.Operateur {
+	case model.OperateurEgal:
+		ok = equal(got, want)
+	case model.OperateurDifferent:
+		ok = !equal(got, want)
+	case model.OperateurSuperieur, model.OperateurSupOuEgal, model.OperateurInferieur, model.OperateurInfOuEgal:
+		g, gok := number(got)
+		w, wok := number(want)
+		if !gok || !wok {
+			return false, fmt.Sprintf("%s n'est pas un nombre", r.Champ)
+		}
+		switch r.Operateur {
+		case model.OperateurSuperieur:
+			ok = g > w
+		case model.OperateurSupOuEgal:
+			ok = g >= w
+		case model.OperateurInferieur:
+			ok = g < w
+		case model.OperateurInfOuEgal:
+			ok = g <= w
+		}
+	case model.OperateurParmi, model.OperateurHorsDe:
+		list, _ := want.([]interface{})
+		found := false
+		for _, w := range list {
+			if equal(got, w) {
+				found = true
+				break
+			}
+		}
+		ok = found == (r.Operateur == model.OperateurParmi)
+	case model.OperateurContient:
+		switch g := got.(type) {
+		case string:
+			w, _ := want.(string)
+			ok = strings.Contains(fold(g), fold(w))
+		case []interface{}:
+			for _, x := range g {
+				if equal(x, want) {
+					ok = true
+					break
+				}
+			}
+		}
+	case model.OperateurCorrespond:
+		g, gok := got.(string)
+		w, _ := want.(string)
+		re, err := regexp.Compile(w)
+		ok = gok && err == nil && re.MatchString(g)
+	}
+
+	if ok {
+		return true, ""
+	}
+	return false, fmt.Sprintf("%s = %s ne satisfait pas %s %s", r.Champ, render(got), r.Operateur, render(want))
+}
+
+// lookup resolves a dotted path ("diplome.niveau") in the answers.
+func lookup(answers map[string]interface{}, path string) interface{} {
+	var cur interface{} = answers
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+func decode(raw []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
+
+// equal compares numbers numerically and strings case- and space-insensitively.
+func equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return x == y
+		}
+	}
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			return fold(x) == fold(y)
+		}
+	}
+	return render(a) == render(b)
+}
+
+func number(v interface{}) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+func fold(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func render(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,272 @@
+// Package formschema validates application form answers against the subset of
+// JSON Schema that coordinators use to describe per-formation forms.
+//
+// Supported keywords: type, title, description, properties, required,
+// additionalProperties (boolean), enum, minLength, maxLength, pattern,
+// format (email, date, date-time), minimum, maximum, items, minItems, maxItems.
+package formschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Schema is one node of a form schema.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// FieldError describes why one answer does not satisfy the schema.
+type FieldError struct {
+	Champ   string `json:"champ"`
+	Message string `json:"message"`
+}
+
+var knownTypes = map[string]bool{
+	"object": true, "string": true, "number": true, "integer": true, "boolean": true, "array": true,
+}
+
+// Parse decodes and checks a form schema. The root must be an object schema.
+func Parse(raw []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("schéma JSON invalide: %w", err)
+	}
+	if s.Type != "object" {
+		return nil, fmt.Errorf(`le schéma racine doit être de type "object"`)
+	}
+	if err := s.compile("$"); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) compile(path string) error {
+	if s.Type != "" && !knownTypes[s.Type] {
+		return fmt.Errorf("%s: type %q non supporté", path, s.Type)
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: pattern invalide: %w", path, err)
+		}
+		s.pattern = re
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			return fmt.Errorf("%s: champ requis %q absent des propriétés", path, name)
+		}
+	}
+	for name, prop := range s.Properties {
+		if prop == nil {
+			return fmt.Errorf("%s.%s: propriété vide", path, name)
+		}
+		if err := prop.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+// Keys returns the top-level property names in a stable order.
+func (s *Schema) Keys() []string {
+	keys := make([]string, 0, len(s.Properties))
+	for k := range s.Properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Validate checks a JSON document of answers against the schema.
+// It returns nil when the answers are valid.
+func (s *Schema) Validate(raw []byte) []FieldError {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		raw = []byte("{}")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return []FieldError{{Champ: "$", Message: "réponses JSON invalides"}}
+	}
+
+	var errs []FieldError
+	s.validate("", v, &errs)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Champ < errs[j].Champ })
+	return errs
+}
+
+func (s *Schema) validate(path string, v interface{}, errs *[]FieldError) {
+	add := func(format string, args ...interface{}) {
+		champ := path
+		if champ == "" {
+			champ = "$"
+		}
+		*errs = append(*errs, FieldError{Champ: champ, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !s.checkType(v) {
+		add("type attendu: %s", s.Type)
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(v, s.Enum) {
+		add("valeur non autorisée")
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if x, ok := val[name]; !ok || x == nil || x == "" {
+				*errs = append(*errs, FieldError{Champ: join(path, name), Message: "champ obligatoire"})
+			}
+		}
+		for name, x := range val {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					*errs = append(*errs, FieldError{Champ: join(path, name), Message: "champ inconnu"})
+				}
+				continue
+			}
+			if x == nil {
+				continue
+			}
+			prop.validate(join(path, name), x, errs)
+		}
+
+	case string:
+		n := len([]rune(val))
+		if s.MinLength != nil && n < *s.MinLength {
+			add("au moins %d caractères", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			add("au plus %d caractères", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			add("format invalide")
+		}
+		switch s.Format {
+		case "email":
+			if _, err := mail.ParseAddress(val); err != nil {
+				add("adresse e-mail invalide")
+			}
+		case "date":
+			if _, err := time.Parse("2006-01-02", val); err != nil {
+				add("date attendue au format AAAA-MM-JJ")
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, val); err != nil {
+				add("date-heure attendue au format RFC3339")
+			}
+		}
+
+	case json.Number:
+		f, _ := val.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			add("valeur minimale: %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			add("valeur maximale: %v", *s.Maximum)
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			add("au moins %d éléments", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			add("au plus %d éléments", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, x := range val {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), x, errs)
+			}
+		}
+	}
+}
+
+func (s *Schema) checkType(v interface{}) bool {
+	switch s.Type {
+	case "":
+		return true
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		// JSON Schema counts 3.0 and 1e2 as integers
+		f, err := n.Float64()
+		return err == nil && math.Trunc(f) == f
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	}
+	return false
+}
+
+func inEnum(v interface{}, enum []interface{}) bool {
+	got, _ := json.Marshal(v)
+	for _, e := range enum {
+		want, _ := json.Marshal(e)
+		if bytes.Equal(got, want) {
+			return true
+		}
+	}
+	// json.Number marshals as its literal, enum numbers as float64: compare numerically too
+	if n, ok := v.(json.Number); ok {
+		f, _ := n.Float64()
+		for _, e := range enum {
+			if ef, ok := e.(float64); ok && ef == f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,443 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/affectation"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// errMotifAffectation reports that the reason code given to the choices refused by a
+// run is missing or deactivated.
+var errMotifAffectation = errors.New("le motif de refus VOEU_PRIORITAIRE_OBTENU est absent ou désactivé : réactivez-le avant de lancer l'affectation")
+
+// AffectationHandler handles HTTP requests for ranked choices (voeux), jury
+// rankings and allocation runs.
+type AffectationHandler struct {
+	repo            *repository.AffectationRepository
+	inscriptionRepo *repository.InscriptionRepository
+	paramRepo       *repository.ParametresFormationRepository
+	motifRepo       *repository.MotifRefusRepository
+	juryRepo        *repository.JuryRepository
+	programs        *client.ProgramClient
+	delaiOffre      int
+}
+
+// NewAffectationHandler creates a new AffectationHandler. delaiOffre is the number
+// of days candidates have to confirm an offer, for formations that set none.
+func NewAffectationHandler(
+	repo *repository.AffectationRepository,
+	inscriptionRepo *repository.InscriptionRepository,
+	paramRepo *repository.ParametresFormationRepository,
+	motifRepo *repository.MotifRefusRepository,
+	juryRepo *repository.JuryRepository,
+	programs *client.ProgramClient,
+	delaiOffre int,
+) *AffectationHandler {
+	return &AffectationHandler{
+		repo:            repo,
+		inscriptionRepo: inscriptionRepo,
+		paramRepo:       paramRepo,
+		motifRepo:       motifRepo,
+		juryRepo:        juryRepo,
+		programs:        programs,
+		delaiOffre:      delaiOffre,
+	}
+}
+
+// GetVoeux returns the caller's ranked applications, most wanted first.
+func (h *AffectationHandler) GetVoeux(c *gin.Context) {
+	voeux, err := h.repo.FindVoeux(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": voeux})
+}
+
+// PutVoeux ranks the caller's open applications across formations, most wanted
+// first. Applications left out of the list lose their rank.
+func (h *AffectationHandler) PutVoeux(c *gin.Context) {
+	var input struct {
+		Inscriptions []uint `json:"inscriptions" binding:"required,min=1"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	candidatID := c.GetString("user_id")
+	formations := make(map[uint]bool, len(input.Inscriptions))
+	for _, id := range input.Inscriptions {
+		ins, err := h.inscriptionRepo.FindByID(id)
+		if err != nil || ins.CandidatID != candidatID {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("inscription %d introuvable", id)})
+			return
+		}
+		switch ins.Etat {
+		case model.EtatPreinscription, model.EtatDossierSoumis, model.EtatEnValidation:
+		default:
+			c.JSON(http.StatusConflict, gin.H{
+				"error": fmt.Sprintf("l'inscription %d n'est plus classable (%s)", id, ins.Etat),
+			})
+			return
+		}
+		if formations[ins.FormationID] {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "chaque voeu doit porter sur une formation différente"})
+			return
+		}
+		formations[ins.FormationID] = true
+	}
+
+	if err := h.repo.SetVoeux(candidatID, input.Inscriptions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	voeux, err := h.repo.FindVoeux(candidatID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": voeux})
+}
+
+// GetClassement returns the jury ranking of a formation: the inscriptions still in
+// the running, best score first, unscored ones last.
+func (h *AffectationHandler) GetClassement(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	classement, err := h.repo.FindClassement(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": classement})
+}
+
+// PutClassement records the jury scores of inscriptions of a formation.
+func (h *AffectationHandler) PutClassement(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Scores []struct {
+			InscriptionID uint     `json:"inscription_id" binding:"required"`
+			Score         *float64 `json:"score" binding:"required"`
+		} `json:"scores" binding:"required,min=1,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	enLice, err := h.repo.FindClassement(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	classables := make(map[uint]bool, len(enLice))
+	for _, ins := range enLice {
+		classables[ins.ID] = true
+	}
+
+	var inconnues []uint
+	scores := make(map[uint]float64, len(input.Scores))
+	for _, s := range input.Scores {
+		if !classables[s.InscriptionID] {
+			inconnues = append(inconnues, s.InscriptionID)
+			continue
+		}
+		scores[s.InscriptionID] = *s.Score
+	}
+	if len(inconnues) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":        "inscriptions non classables pour cette formation (EN_VALIDATION ou LISTE_ATTENTE uniquement)",
+			"inscriptions": inconnues,
+		})
+		return
+	}
+
+	if err := h.repo.SetScores(uint(id), scores); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	classement, err := h.repo.FindClassement(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": classement})
+}
+
+// Lancer runs the allocation over a set of formations. Each candidate's scored
+// applications in those formations are taken in the order of their choices, and
+// every candidate obtains at most one seat. The seat obtained becomes ACCEPTE,
+// better choices go to LISTE_ATTENTE and lower ones are refused.
+// ?simulation=true returns the report without applying it.
+func (h *AffectationHandler) Lancer(c *gin.Context) {
+	var input struct {
+		FormationIDs []uint `json:"formation_ids" binding:"required,min=1"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	etablissementID := ""
+	formations := make([]affectation.Formation, 0, len(input.FormationIDs))
+	index := make(map[uint]int, len(input.FormationIDs))
+	for i, id := range input.FormationIDs {
+		if _, ok := index[id]; ok {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "formation en double"})
+			return
+		}
+		formation := formationInScope(c, h.programs, id)
+		if formation == nil {
+			return
+		}
+		if i == 0 {
+			etablissementID = formation.EtablissementID
+		} else if formation.EtablissementID != etablissementID {
+			etablissementID = ""
+		}
+
+		params, err := h.paramRepo.Get(id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		index[id] = len(formations)
+		formations = append(formations, affectation.Formation{FormationID: id, Capacite: params.Capacite})
+	}
+
+	enLice, err := h.repo.FindEnLice(input.FormationIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Seats already taken in the formations, and the candidates who hold one anywhere
+	candidatIDs := make([]string, 0, len(enLice))
+	for _, ins := range enLice {
+		candidatIDs = append(candidatIDs, ins.CandidatID)
+	}
+	places, err := h.repo.FindPlaces(input.FormationIDs, candidatIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	dejaAffectes := make(map[string]bool)
+	for _, ins := range places {
+		if i, ok := index[ins.FormationID]; ok {
+			formations[i].PlacesPrises++
+		}
+		dejaAffectes[ins.CandidatID] = true
+	}
+	for i, f := range formations {
+		formations[i].Places = -1
+		if f.Capacite != nil {
+			formations[i].Places = max(*f.Capacite-f.PlacesPrises, 0)
+		}
+	}
+
+	var candidats []affectation.Candidat
+	var nonClasses []uint
+	ignores := make(map[string]bool)
+	byID := make(map[uint]*model.Inscription, len(enLice))
+	for i := range enLice {
+		ins := &enLice[i]
+		if dejaAffectes[ins.CandidatID] {
+			ignores[ins.CandidatID] = true
+			continue
+		}
+		if ins.ScoreJury == nil {
+			nonClasses = append(nonClasses, ins.ID)
+			continue
+		}
+		byID[ins.ID] = ins
+		// FindEnLice returns each candidate's inscriptions together, in preference order
+		if n := len(candidats); n == 0 || candidats[n-1].CandidatID != ins.CandidatID {
+			candidats = append(candidats, affectation.Candidat{CandidatID: ins.CandidatID, NomComplet: ins.NomComplet})
+		}
+		cand := &candidats[len(candidats)-1]
+		cand.Voeux = append(cand.Voeux, affectation.Voeu{
+			InscriptionID: ins.ID,
+			FormationID:   ins.FormationID,
+			Score:         *ins.ScoreJury,
+		})
+	}
+
+	rapport := affectation.Allouer(candidats, formations)
+	rapport.NonClasses = nonClasses
+	for candidatID := range ignores {
+		rapport.DejaAffectes = append(rapport.DejaAffectes, candidatID)
+	}
+	sort.Strings(rapport.DejaAffectes)
+
+	if c.Query("simulation") == "true" {
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"rapport": rapport}, "simulation": true})
+		return
+	}
+
+	changements, err := h.changements(rapport, byID)
+	if errors.Is(err, errMotifAffectation) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Decisions go to the formations' open jury sessions; closed ones lock their own
+	decides := make([]*model.Inscription, len(changements))
+	for i, ch := range changements {
+		decides[i] = ch.Inscription
+	}
+	sessions, verrouillees, err := sessionsJury(h.juryRepo, decides)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(verrouillees) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        "décisions arrêtées par une session de jury close : ouvrez une nouvelle session pour les réviser",
+			"inscriptions": verrouillees,
+		})
+		return
+	}
+	for i := range changements {
+		changements[i].SessionJuryID = sessions[changements[i].Inscription.FormationID]
+	}
+
+	formationIDs, _ := json.Marshal(input.FormationIDs)
+	rapportJSON, err := json.Marshal(rapport)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	run := model.Affectation{
+		EtablissementID: etablissementID,
+		FormationIDs:    model.JSON(formationIDs),
+		Rapport:         model.JSON(rapportJSON),
+		LancePar:        c.GetString("user_id"),
+	}
+
+	capacites := make(map[uint]*int, len(formations))
+	for _, f := range formations {
+		capacites[f.FormationID] = f.Capacite
+	}
+	prise, err := h.repo.Appliquer(&run, changements, capacites)
+	if errors.Is(err, repository.ErrDejaPrisEnCharge) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "prise_en_charge": prise})
+		return
+	}
+	if errors.Is(err, repository.ErrAffectationPerimee) || errors.Is(err, repository.ErrCapaciteAtteinte) ||
+		errors.Is(err, repository.ErrSessionJuryClose) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": run, "simulation": false})
+}
+
+// List returns the applied allocation runs, without their report.
+func (h *AffectationHandler) List(c *gin.Context) {
+	runs, err := h.repo.FindAll(etablissementScope(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": runs})
+}
+
+// Get returns an applied allocation run with its report.
+func (h *AffectationHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	run, err := h.repo.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "affectation not found"})
+		return
+	}
+	if scope := etablissementScope(c); scope != "" && run.EtablissementID != scope {
+		c.JSON(http.StatusNotFound, gin.H{"error": "affectation not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": run})
+}
+
+// changements turns a report into the state changes to apply. Choices already in
+// the decided state are left alone. It returns errMotifAffectation when refusals
+// cannot be given their reason code.
+func (h *AffectationHandler) changements(rapport model.RapportAffectation, byID map[uint]*model.Inscription) ([]repository.ChangementEtat, error) {
+	var changements []repository.ChangementEtat
+	for _, ac := range rapport.Candidats {
+		for _, v := range ac.Voeux {
+			ins := byID[v.InscriptionID]
+			if ins == nil || ins.Etat == v.Resultat {
+				continue
+			}
+			ch := repository.ChangementEtat{
+				Inscription: ins,
+				Etat:        v.Resultat,
+				Commentaire: fmt.Sprintf("Affectation, voeu n°%d : %s", v.Rang, v.Explication),
+			}
+			if v.Resultat == model.EtatAccepte {
+				echeance, err := echeanceOffre(h.paramRepo, v.FormationID, h.delaiOffre)
+				if err != nil {
+					return nil, err
+				}
+				ch.OffreExpireLe = &echeance
+			}
+			if v.Resultat == model.EtatRefuse {
+				motifs, err := h.motifRepo.FindActiveByCodes(ins.EtablissementID, []string{"VOEU_PRIORITAIRE_OBTENU"})
+				if err != nil {
+					return nil, err
+				}
+				if len(motifs) == 0 {
+					return nil, errMotifAffectation
+				}
+				ch.Motifs = motifs
+			}
+			changements = append(changements, ch)
+		}
+	}
+	return changements, nil
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Anonymiser erases the personal data of a closed dossier, e.g. once its retention
+// period is over or at the candidate's request. Open dossiers are refused: they
+// still need the candidate's contact details.
+func (h *InscriptionHandler) Anonymiser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Motif string `json:"motif" binding:"required,max=1000"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err := h.repo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+	if ins.AnonymiseLe != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "les données de ce dossier ont déjà été anonymisées"})
+		return
+	}
+	switch ins.Etat {
+	case model.EtatRefuse, model.EtatValide, model.EtatNonValide, model.EtatAbandon, model.EtatDesiste:
+	default:
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "seul un dossier clôturé peut être anonymisé",
+			"etat_actuel": ins.Etat,
+		})
+		return
+	}
+
+	if err := h.repo.Anonymiser(ins, c.GetString("user_id"), input.Motif); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err = h.repo.FindByID(ins.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ins})
+}
@@ -1,52 +1,73 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/formschema"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/identite"
 	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/reference"
 	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
 	"github.com/gin-gonic/gin"
 )
 
 // InscriptionHandler handles HTTP requests for inscriptions.
 type InscriptionHandler struct {
-	repo *repository.InscriptionRepository
+	repo          *repository.InscriptionRepository
+	motifRepo     *repository.MotifRefusRepository
+	formRepo      *repository.FormulaireRepository
+	regleRepo     *repository.RegleEligibiliteRepository
+	paramRepo     *repository.ParametresFormationRepository
+	doublons      *repository.DoublonRepository
+	jury          *repository.JuryRepository
+	programs      *client.ProgramClient
+	notifications *client.NotificationClient
+	delaiOffre    int
+	codes         reference.Codes
 }
 
-// NewInscriptionHandler creates a new InscriptionHandler.
-func NewInscriptionHandler(repo *repository.InscriptionRepository) *InscriptionHandler {
-	return &InscriptionHandler{repo: repo}
+// NewInscriptionHandler creates a new InscriptionHandler. delaiOffre is the number of
+// days accepted candidates have to confirm their offer, for formations that set none;
+// codes gives the institution codes used in dossier numbers.
+func NewInscriptionHandler(
+	repo *repository.InscriptionRepository,
+	motifRepo *repository.MotifRefusRepository,
+	formRepo *repository.FormulaireRepository,
+	regleRepo *repository.RegleEligibiliteRepository,
+	paramRepo *repository.ParametresFormationRepository,
+	doublons *repository.DoublonRepository,
+	jury *repository.JuryRepository,
+	programs *client.ProgramClient,
+	notifications *client.NotificationClient,
+	delaiOffre int,
+	codes reference.Codes,
+) *InscriptionHandler {
+	return &InscriptionHandler{
+		repo:          repo,
+		motifRepo:     motifRepo,
+		formRepo:      formRepo,
+		regleRepo:     regleRepo,
+		paramRepo:     paramRepo,
+		doublons:      doublons,
+		jury:          jury,
+		programs:      programs,
+		notifications: notifications,
+		delaiOffre:    delaiOffre,
+		codes:         codes,
+	}
 }
 
-// List returns all inscriptions, optionally filtered by candidat_id or formation_id.
+// List returns all inscriptions, optionally filtered by candidat_id, formation_id,
+// dossier number (?reference=), CIN, CNE and form answers (?reponses[employeur]=OCP).
+// Staff only see the inscriptions of their own institution.
 func (h *InscriptionHandler) List(c *gin.Context) {
-	candidatID := c.Query("candidat_id")
-	if candidatID != "" {
-		inscriptions, err := h.repo.FindByCandidatID(candidatID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"data": inscriptions})
-		return
-	}
-
-	formationIDStr := c.Query("formation_id")
-	if formationIDStr != "" {
-		formationID, err := strconv.ParseUint(formationIDStr, 10, 32)
-		if err == nil {
-			inscriptions, err := h.repo.FindByFormationID(uint(formationID))
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-			c.JSON(http.StatusOK, gin.H{"data": inscriptions})
-			return
-		}
-	}
-
-	inscriptions, err := h.repo.FindAll()
+	inscriptions, err := h.repo.FindAll(listFilter(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -63,7 +84,7 @@ func (h *InscriptionHandler) Get(c *gin.Context) {
 	}
 
 	ins, err := h.repo.FindByID(uint(id))
-	if err != nil {
+	if err != nil || !inScope(c, ins) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
 		return
 	}
@@ -74,12 +95,13 @@ func (h *InscriptionHandler) Get(c *gin.Context) {
 // Maps to: Sequence Diagram B — candidate pre-registers.
 func (h *InscriptionHandler) Create(c *gin.Context) {
 	var input struct {
-		CandidatID  string `json:"candidat_id" binding:"required"`
-		FormationID uint   `json:"formation_id" binding:"required"`
-		NomComplet  string `json:"nom_complet" binding:"required"`
-		Email       string `json:"email" binding:"required,email"`
-		Telephone   string `json:"telephone"`
-		Notes       string `json:"notes"`
+		CandidatID  string     `json:"candidat_id" binding:"required"`
+		FormationID uint       `json:"formation_id" binding:"required"`
+		NomComplet  string     `json:"nom_complet" binding:"required"`
+		Email       string     `json:"email" binding:"required,email"`
+		Notes       string     `json:"notes"`
+		Reponses    model.JSON `json:"reponses"`
+		identite.Saisie
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -87,24 +109,138 @@ func (h *InscriptionHandler) Create(c *gin.Context) {
 		return
 	}
 
+	ident, erreurs := identite.Valider(input.Saisie, time.Now())
+	if len(erreurs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "informations d'identité invalides",
+			"erreurs": erreurs,
+		})
+		return
+	}
+
+	// Resolve the owning institution so the inscription can be scoped to its staff
+	formation, err := h.programs.GetFormation(c.Request.Context(), input.FormationID, c.GetHeader("Authorization"))
+	if errors.Is(err, client.ErrNotFound) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "formation introuvable"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
 	ins := model.Inscription{
-		CandidatID:  input.CandidatID,
-		FormationID: input.FormationID,
-		Etat:        model.EtatPreinscription,
-		NomComplet:  input.NomComplet,
-		Email:       input.Email,
-		Telephone:   input.Telephone,
-		Notes:       input.Notes,
+		CandidatID:      input.CandidatID,
+		FormationID:     input.FormationID,
+		EtablissementID: formation.EtablissementID,
+		Etat:            model.EtatPreinscription,
+		NomComplet:      input.NomComplet,
+		Email:           input.Email,
+		Notes:           input.Notes,
+	}
+	ident.Appliquer(&ins)
+
+	// Validate the answers against the formation's application form, if any
+	form, err := h.formRepo.FindByFormationID(input.FormationID)
+	if err == nil {
+		schema, err := formschema.Parse(form.Schema)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if erreurs := schema.Validate(input.Reponses); len(erreurs) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "réponses au formulaire invalides",
+				"erreurs": erreurs,
+			})
+			return
+		}
+		ins.Reponses = input.Reponses
+		ins.FormulaireVersion = form.Version
+	} else if len(input.Reponses) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "aucun formulaire défini pour cette formation"})
+		return
+	}
+
+	// Check the formation's prerequisites against the answers
+	rejeter, err := h.evaluerEligibilite(&ins)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if rejeter {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":       "prérequis d'accès non satisfaits",
+			"eligibilite": ins.Eligibilite,
+		})
+		return
 	}
 
-	if err := h.repo.Create(&ins); err != nil {
+	if err := h.repo.Create(&ins, h.codes.Code(ins.EtablissementID)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Queue likely duplicates for staff review; the candidate is not told
+	if _, err := h.doublons.Detecter(&ins); err != nil {
+		log.Printf("inscription %d: duplicate detection failed: %v", ins.ID, err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"data": ins})
 }
 
+// UpdateIdentite completes or corrects the identity fields of an inscription: phone,
+// CIN, CNE or Massar code, date of birth and last diploma. Fields left empty keep
+// their value. Candidates can only update their own inscriptions.
+func (h *InscriptionHandler) UpdateIdentite(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input identite.Saisie
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err := h.repo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+	if c.GetString("role") == "CANDIDAT" && ins.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "accès refusé"})
+		return
+	}
+	if ins.AnonymiseLe != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": repository.ErrAnonymise.Error()})
+		return
+	}
+
+	ident, erreurs := identite.Valider(input, time.Now())
+	if len(erreurs) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "informations d'identité invalides",
+			"erreurs": erreurs,
+		})
+		return
+	}
+
+	err = h.repo.UpdateIdentite(ins.ID, ident.Colonnes())
+	if errors.Is(err, repository.ErrAnonymise) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ident.Appliquer(ins)
+	c.JSON(http.StatusOK, gin.H{"data": ins})
+}
+
 // Transition changes the inscription status according to the state machine rules.
 // Maps to: State Diagram — Application Lifecycle & Sequence Diagram C.
 func (h *InscriptionHandler) Transition(c *gin.Context) {
@@ -115,9 +251,10 @@ func (h *InscriptionHandler) Transition(c *gin.Context) {
 	}
 
 	var input struct {
-		Etat        string `json:"etat" binding:"required"`
-		ModifiePar  string `json:"modifie_par" binding:"required"`
-		Commentaire string `json:"commentaire"`
+		Etat        string   `json:"etat" binding:"required"`
+		ModifiePar  string   `json:"modifie_par" binding:"required"`
+		Commentaire string   `json:"commentaire"`
+		Motifs      []string `json:"motifs"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -126,7 +263,7 @@ func (h *InscriptionHandler) Transition(c *gin.Context) {
 	}
 
 	ins, err := h.repo.FindByID(uint(id))
-	if err != nil {
+	if err != nil || !inScope(c, ins) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
 		return
 	}
@@ -143,34 +280,245 @@ func (h *InscriptionHandler) Transition(c *gin.Context) {
 		return
 	}
 
-	ancienEtat := ins.Etat
-	ins.Etat = targetEtat
-
-	if err := h.repo.Update(ins); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Final results follow from grades and attendance, not from a manual decision
+	if targetEtat == model.EtatValide || targetEtat == model.EtatNonValide {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "les résultats finaux sont établis par délibération (POST /formations/:id/deliberation)",
+		})
 		return
 	}
 
+	// A refusal must be justified by at least one active reason code
+	var motifs []model.MotifRefus
+	if targetEtat == model.EtatRefuse {
+		if len(input.Motifs) == 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "au moins un motif de refus est requis"})
+			return
+		}
+		motifs, err = h.motifRepo.FindActiveByCodes(ins.EtablissementID, input.Motifs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if inconnus := unknownCodes(input.Motifs, motifs); len(inconnus) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":    "motifs de refus inconnus ou inactifs",
+				"inconnus": inconnus,
+			})
+			return
+		}
+	}
+
+	// Admission decisions belong to the open jury session; a closed one locks its own
+	var sessionJuryID *uint
+	if model.EstDecisionJury(targetEtat) {
+		sessions, verrouillees, err := sessionsJury(h.jury, []*model.Inscription{ins})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(verrouillees) > 0 {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "décision arrêtée par une session de jury close : ouvrez une nouvelle session pour la réviser",
+			})
+			return
+		}
+		sessionJuryID = sessions[ins.FormationID]
+	}
+
+	// Prerequisites are evaluated again when the dossier is submitted; as at creation,
+	// a formation that rejects ineligible candidates refuses the submission
+	if targetEtat == model.EtatDossierSoumis {
+		rejeter, err := h.evaluerEligibilite(ins)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if rejeter {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":       "prérequis d'accès non satisfaits",
+				"eligibilite": ins.Eligibilite,
+			})
+			return
+		}
+	}
+
+	colonnes := map[string]interface{}{"etat": targetEtat}
+	if targetEtat == model.EtatDossierSoumis {
+		colonnes["eligibilite_statut"] = ins.EligibiliteStatut
+		colonnes["eligibilite"] = ins.Eligibilite
+	}
+
+	// An offer must be confirmed by the candidate before its deadline
+	var echeance time.Time
+	if targetEtat == model.EtatAccepte {
+		echeance, err = echeanceOffre(h.paramRepo, ins.FormationID, h.delaiOffre)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		colonnes["offre_expire_le"] = echeance
+		colonnes["offre_confirmee_le"] = nil
+		colonnes["offre_rappel_le"] = nil
+	}
+
 	// Record decision (for review/acceptance/rejection states)
+	var decision *model.Decision
 	if targetEtat == model.EtatAccepte || targetEtat == model.EtatRefuse ||
-		targetEtat == model.EtatEnValidation || targetEtat == model.EtatInscrit {
-		decision := model.Decision{
+		targetEtat == model.EtatEnValidation || targetEtat == model.EtatInscrit ||
+		targetEtat == model.EtatListeAttente {
+		decision = &model.Decision{
 			InscriptionID: ins.ID,
 			DecidePar:     input.ModifiePar,
 			Etat:          targetEtat,
 			Commentaire:   input.Commentaire,
+			Motifs:        motifs,
+			SessionJuryID: sessionJuryID,
 		}
-		_ = h.repo.CreateDecision(&decision)
 	}
 
 	// Record history (audit trail)
 	historique := model.InscriptionHistorique{
 		InscriptionID: ins.ID,
-		AncienEtat:    ancienEtat,
+		AncienEtat:    ins.Etat,
 		NouvelEtat:    targetEtat,
 		ModifiePar:    input.ModifiePar,
 	}
-	_ = h.repo.CreateHistory(&historique)
+
+	// The state change, decision, reason codes and history are written together, unless
+	// a colleague has claimed the dossier
+	prise, err := h.repo.ChangerEtatPar(c.GetString("user_id"), ins, colonnes, decision, &historique)
+	if errors.Is(err, repository.ErrDejaPrisEnCharge) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "prise_en_charge": prise})
+		return
+	}
+	if errors.Is(err, repository.ErrEtatModifie) || errors.Is(err, repository.ErrSessionJuryClose) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins.Etat = targetEtat
+	if targetEtat == model.EtatAccepte {
+		ins.OffreExpireLe = &echeance
+		ins.OffreConfirmeeLe = nil
+		ins.OffreRappelLe = nil
+	}
+	if targetEtat == model.EtatRefuse {
+		if err := h.notifierRefus(c, ins, decision); err != nil {
+			log.Printf("inscription %d: refusal notice not sent: %v", ins.ID, err)
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{"data": ins})
 }
+
+// notifierRefus e-mails the candidate the refusal of their dossier with the candidate
+// message of each reason code, or its label when it has none.
+func (h *InscriptionHandler) notifierRefus(c *gin.Context, ins *model.Inscription, decision *model.Decision) error {
+	if ins.Email == "" {
+		return errors.New("aucune adresse e-mail sur l'inscription")
+	}
+
+	messages := make([]string, 0, len(decision.Motifs))
+	for _, m := range decision.Motifs {
+		if m.MessageCandidat != "" {
+			messages = append(messages, m.MessageCandidat)
+		} else {
+			messages = append(messages, m.Libelle)
+		}
+	}
+	return h.notifications.Send(c.Request.Context(), fmt.Sprintf("refus-%d", decision.ID), client.Notification{
+		TemplateKey: "inscription-refusee",
+		Recipient:   ins.Email,
+		Payload: map[string]interface{}{
+			"nomComplet":    ins.NomComplet,
+			"inscriptionId": ins.ID,
+			"reference":     ins.NumeroDossier(),
+			"formationId":   ins.FormationID,
+			"motifs":        messages,
+			"commentaire":   decision.Commentaire,
+		},
+	})
+}
+
+// ResolveEtablissements fills in the institution of inscriptions created before it was recorded.
+// Internal endpoint for the scheduler — the SYSTEM token is forwarded to program-service.
+func (h *InscriptionHandler) ResolveEtablissements(c *gin.Context) {
+	formationIDs, err := h.repo.FindFormationsWithoutEtablissement()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var updated int64
+	var failed []uint
+	for _, formationID := range formationIDs {
+		formation, err := h.programs.GetFormation(c.Request.Context(), formationID, c.GetHeader("Authorization"))
+		if err != nil || formation.EtablissementID == "" {
+			failed = append(failed, formationID)
+			continue
+		}
+		n, err := h.repo.SetEtablissement(formationID, formation.EtablissementID)
+		if err != nil {
+			failed = append(failed, formationID)
+			continue
+		}
+		updated += n
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "etablissements resolved",
+		"updated":             updated,
+		"formations_en_echec": failed,
+	})
+}
+
+// AttribuerReferences numbers the inscriptions created before dossier numbers existed,
+// in the academic year of their creation. Internal endpoint for the scheduler; it
+// works in batches and can be called again until "restants" is false.
+func (h *InscriptionHandler) AttribuerReferences(c *gin.Context) {
+	const lot = 500
+
+	inscriptions, err := h.repo.FindSansReference(lot)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var numerotees int
+	var failed []uint
+	for i := range inscriptions {
+		ins := &inscriptions[i]
+		if err := h.repo.AttribuerReference(ins, h.codes.Code(ins.EtablissementID)); err != nil {
+			failed = append(failed, ins.ID)
+			continue
+		}
+		numerotees++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "references assigned",
+		"numerotees":            numerotees,
+		"inscriptions_en_echec": failed,
+		"restants":              len(inscriptions) == lot,
+	})
+}
+
+// unknownCodes returns the requested codes that did not resolve to a reason.
+func unknownCodes(codes []string, motifs []model.MotifRefus) []string {
+	found := make(map[string]bool, len(motifs))
+	for _, m := range motifs {
+		found[m.Code] = true
+	}
+	var inconnus []string
+	for _, code := range codes {
+		if !found[code] {
+			inconnus = append(inconnus, code)
+		}
+	}
+	return inconnus
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// Coherence replays the history of the inscriptions selected by the listing filters
+// and reports those whose history has gaps or does not end in their current state.
+func (h *InscriptionHandler) Coherence(c *gin.Context) {
+	rapports, verifiees, err := h.verifierCoherence(listFilter(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":         rapports,
+		"verifiees":    verifiees,
+		"incoherentes": len(rapports),
+	})
+}
+
+// ReparerCoherence fills the gaps found by Coherence with synthetic history entries
+// (RECONSTITUTION), dated at the decision of the missing change when there is one.
+// The current state is taken as right: it is never changed.
+func (h *InscriptionHandler) ReparerCoherence(c *gin.Context) {
+	rapports, verifiees, err := h.verifierCoherence(listFilter(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var entrees int
+	var failed []uint
+	for _, rapport := range rapports {
+		reconstitutions := make([]model.InscriptionHistorique, len(rapport.Anomalies))
+		for i, a := range rapport.Anomalies {
+			reconstitutions[i] = a.Reconstitution(rapport.InscriptionID, c.GetString("user_id"))
+		}
+		if err := h.repo.Reconstituer(reconstitutions); err != nil {
+			failed = append(failed, rapport.InscriptionID)
+			continue
+		}
+		entrees += len(reconstitutions)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "history repaired",
+		"data":                  rapports,
+		"verifiees":             verifiees,
+		"reparees":              len(rapports) - len(failed),
+		"entrees_creees":        entrees,
+		"inscriptions_en_echec": failed,
+	})
+}
+
+// verifierCoherence checks the inscriptions matching the filter, 500 at a time, and
+// returns the reports of those with anomalies and how many were checked.
+func (h *InscriptionHandler) verifierCoherence(f repository.InscriptionFilter) ([]model.RapportCoherence, int, error) {
+	const lot = 500
+
+	rapports := []model.RapportCoherence{}
+	var verifiees int
+	var apresID uint
+	for {
+		inscriptions, err := h.repo.FindAVerifier(f, apresID, lot)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := range inscriptions {
+			ins := &inscriptions[i]
+			if anomalies := ins.VerifierHistorique(); len(anomalies) > 0 {
+				rapports = append(rapports, model.RapportCoherence{
+					InscriptionID: ins.ID,
+					Reference:     ins.NumeroDossier(),
+					Etat:          ins.Etat,
+					Anomalies:     anomalies,
+				})
+			}
+			apresID = ins.ID
+		}
+		verifiees += len(inscriptions)
+		if len(inscriptions) < lot {
+			return rapports, verifiees, nil
+		}
+	}
+}
+
+// EtatAu answers what state an inscription was in at ?date=, a time in RFC 3339 or a
+// day (YYYY-MM-DD, meaning its end, UTC), by reading its history. historique_complet
+// is false while the checker finds gaps in it: the answer may then be wrong.
+// Candidates can only query their own inscriptions.
+func (h *InscriptionHandler) EtatAu(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	date, err := time.Parse(time.RFC3339, c.Query("date"))
+	if err != nil {
+		jour, errJour := time.Parse("2006-01-02", c.Query("date"))
+		if errJour != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be RFC 3339 or YYYY-MM-DD"})
+			return
+		}
+		date = jour.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	ins, err := h.repo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+	if c.GetString("role") == "CANDIDAT" && ins.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "accès refusé"})
+		return
+	}
+
+	etat, entree, ok := ins.EtatAu(date)
+	if !ok {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":         "l'inscription n'existait pas encore à cette date",
+			"date_creation": ins.DateCreation,
+		})
+		return
+	}
+
+	resultat := gin.H{
+		"date":               date,
+		"etat":               etat,
+		"depuis":             ins.DateCreation,
+		"historique_id":      nil,
+		"historique_complet": len(ins.VerifierHistorique()) == 0,
+	}
+	if entree != nil {
+		resultat["depuis"] = entree.CreatedAt
+		resultat["historique_id"] = entree.ID
+	}
+	c.JSON(http.StatusOK, gin.H{"data": resultat})
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CorbeilleHandler handles the deletion of inscriptions: the trash, restoration and
+// the purge of inscriptions deleted longer than the retention period.
+type CorbeilleHandler struct {
+	repo      *repository.InscriptionRepository
+	documents *client.DocumentClient
+	retention time.Duration
+}
+
+// NewCorbeilleHandler creates a new CorbeilleHandler. retentionDays is how long a
+// deleted inscription can be restored before it is purged.
+func NewCorbeilleHandler(repo *repository.InscriptionRepository, documents *client.DocumentClient, retentionDays int) *CorbeilleHandler {
+	return &CorbeilleHandler{
+		repo:      repo,
+		documents: documents,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
+// Supprimer moves an inscription to the trash, e.g. a test or spam dossier. Enrolled
+// learners are refused: their course and results must be kept.
+func (h *CorbeilleHandler) Supprimer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Motif string `json:"motif" binding:"required,max=1000"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err := h.repo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+	switch ins.Etat {
+	case model.EtatInscrit, model.EtatValide, model.EtatNonValide, model.EtatAbandon:
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "le dossier d'un apprenant inscrit ne peut pas être supprimé",
+			"etat_actuel": ins.Etat,
+		})
+		return
+	}
+
+	err = h.repo.Supprimer(ins, c.GetString("user_id"), input.Motif)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "inscription supprimée",
+		"purgee_le": time.Now().Add(h.retention),
+	})
+}
+
+// List returns the deleted inscriptions of the caller's institution, most recently
+// deleted first, with the date each one will be purged.
+func (h *CorbeilleHandler) List(c *gin.Context) {
+	inscriptions, err := h.repo.FindCorbeille(etablissementScope(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	elements := make([]model.ElementCorbeille, len(inscriptions))
+	for i, ins := range inscriptions {
+		elements[i] = model.ElementCorbeille{
+			Inscription: ins,
+			SupprimeeLe: ins.DeletedAt.Time,
+			PurgeeLe:    ins.DeletedAt.Time.Add(h.retention),
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": elements})
+}
+
+// Restaurer takes an inscription out of the trash, in the state it was deleted in.
+// Like the deletion, it needs a reason for the history.
+func (h *CorbeilleHandler) Restaurer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Motif string `json:"motif" binding:"required,max=1000"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err := h.repo.FindSupprimee(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found in trash"})
+		return
+	}
+
+	err = h.repo.Restaurer(ins, c.GetString("user_id"), input.Motif)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found in trash"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err = h.repo.FindByID(ins.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ins})
+}
+
+// Purger permanently deletes the inscriptions deleted longer than the retention period.
+// Internal endpoint for the scheduler. document-service deletes the inscription's files
+// once its rows are deleted, before they are committed; if either fails, the inscription
+// is left in the trash, with its files, for the next run. It works in batches and can be
+// called again until "restants" is false.
+func (h *CorbeilleHandler) Purger(c *gin.Context) {
+	const lot = 100
+
+	inscriptions, err := h.repo.FindAPurger(time.Now().Add(-h.retention), lot)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var purgees int
+	var failed []uint
+	for _, ins := range inscriptions {
+		inscriptionID := ins.ID
+		err := h.repo.Purger(inscriptionID, func() error {
+			return h.documents.PurgeInscription(c.Request.Context(), inscriptionID)
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Restored meanwhile
+			continue
+		}
+		if err != nil {
+			failed = append(failed, ins.ID)
+			continue
+		}
+		purgees++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "trash purged",
+		"purgees":               purgees,
+		"inscriptions_en_echec": failed,
+		"restants":              len(inscriptions) == lot,
+	})
+}
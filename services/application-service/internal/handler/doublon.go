@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// DoublonHandler handles the review queue of suspected duplicate candidates.
+type DoublonHandler struct {
+	repo *repository.DoublonRepository
+}
+
+// NewDoublonHandler creates a new DoublonHandler.
+func NewDoublonHandler(repo *repository.DoublonRepository) *DoublonHandler {
+	return &DoublonHandler{repo: repo}
+}
+
+// List returns the suspected duplicates of the caller's institution, most likely
+// first, optionally filtered by ?statut= and ?inscription_id=.
+func (h *DoublonHandler) List(c *gin.Context) {
+	f := repository.DoublonFilter{
+		EtablissementID: etablissementScope(c),
+		Statut:          c.Query("statut"),
+	}
+	if id, err := strconv.ParseUint(c.Query("inscription_id"), 10, 32); err == nil {
+		f.InscriptionID = uint(id)
+	}
+
+	doublons, err := h.repo.FindAll(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": doublons})
+}
+
+// Get returns a suspected duplicate with both inscriptions.
+func (h *DoublonHandler) Get(c *gin.Context) {
+	d := h.doublonInScope(c)
+	if d == nil {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": d})
+}
+
+// Fusionner merges the two candidate accounts of a suspected duplicate into the
+// account of the inscription to keep. When both accounts have a dossier in the same
+// formation and the one to delete is past PREINSCRIPTION, nothing is merged: the
+// conflicts are answered with 409 so that staff delete the unwanted dossier first.
+func (h *DoublonHandler) Fusionner(c *gin.Context) {
+	var input struct {
+		ConserveeID uint   `json:"conservee_id" binding:"required"`
+		Commentaire string `json:"commentaire" binding:"required,max=1000"`
+	}
+
+	d := h.doublonInScope(c)
+	if d == nil {
+		return
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.ConserveeID != d.InscriptionID && input.ConserveeID != d.AutreInscriptionID {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":     "l'inscription conservée doit être l'une des deux inscriptions du doublon",
+			"autorises": []uint{d.InscriptionID, d.AutreInscriptionID},
+		})
+		return
+	}
+
+	conflits, fusion, err := h.repo.Fusionner(d, input.ConserveeID, c.GetString("user_id"), input.Commentaire)
+	if errors.Is(err, repository.ErrDoublonResolu) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "statut": d.Statut})
+		return
+	}
+	if errors.Is(err, repository.ErrFusionConflit) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflits": conflits})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": fusion})
+}
+
+// Ecarter dismisses a suspected duplicate: the two accounts are different people.
+func (h *DoublonHandler) Ecarter(c *gin.Context) {
+	var input struct {
+		Commentaire string `json:"commentaire" binding:"required,max=1000"`
+	}
+
+	d := h.doublonInScope(c)
+	if d == nil {
+		return
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.repo.Ecarter(d, c.GetString("user_id"), input.Commentaire)
+	if errors.Is(err, repository.ErrDoublonResolu) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "statut": d.Statut})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": d})
+}
+
+// Detecter compares every inscription with those of other candidates of its
+// institution and queues the new suspected duplicates. Internal endpoint for the
+// scheduler; pairs already raised are left as they are.
+func (h *DoublonHandler) Detecter(c *gin.Context) {
+	const lot = 500
+
+	var examinees, ajoutes int
+	var failed []uint
+	var apres uint
+	for {
+		inscriptions, err := h.repo.FindInscriptionsApres(apres, lot)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for i := range inscriptions {
+			nouveaux, err := h.repo.Detecter(&inscriptions[i])
+			if err != nil {
+				failed = append(failed, inscriptions[i].ID)
+				continue
+			}
+			ajoutes += len(nouveaux)
+		}
+		examinees += len(inscriptions)
+		if len(inscriptions) < lot {
+			break
+		}
+		apres = inscriptions[len(inscriptions)-1].ID
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "duplicates detected",
+		"examinees":             examinees,
+		"nouveaux_doublons":     ajoutes,
+		"inscriptions_en_echec": failed,
+	})
+}
+
+// doublonInScope loads the suspected duplicate of the :id parameter and checks that
+// staff callers belong to its institution. On failure it writes the response and
+// returns nil.
+func (h *DoublonHandler) doublonInScope(c *gin.Context) *model.DoublonSuspect {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil
+	}
+
+	d, err := h.repo.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "doublon not found"})
+		return nil
+	}
+	if scope := etablissementScope(c); scope != "" && d.EtablissementID != scope {
+		c.JSON(http.StatusNotFound, gin.H{"error": "doublon not found"})
+		return nil
+	}
+	return d
+}
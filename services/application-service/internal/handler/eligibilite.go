@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/eligibility"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// evaluerEligibilite runs the formation's rules over the inscription's answers and
+// records the outcome on it. It returns true when the formation is configured to
+// reject applications that fail their prerequisites and this one does.
+func (h *InscriptionHandler) evaluerEligibilite(ins *model.Inscription) (bool, error) {
+	regles, err := h.regleRepo.FindByFormationID(ins.FormationID)
+	if err != nil {
+		return false, err
+	}
+	if len(regles) == 0 {
+		ins.EligibiliteStatut = ""
+		ins.Eligibilite = nil
+		return false, nil
+	}
+
+	resultats, eligible := eligibility.Evaluate(regles, ins.Reponses)
+	raw, err := json.Marshal(resultats)
+	if err != nil {
+		return false, err
+	}
+	ins.Eligibilite = raw
+	if eligible {
+		ins.EligibiliteStatut = model.EligibiliteEligible
+		return false, nil
+	}
+	ins.EligibiliteStatut = model.EligibiliteNonEligible
+
+	params, err := h.paramRepo.Get(ins.FormationID)
+	if err != nil {
+		return false, err
+	}
+	return params.ModeEligibilite == model.ModeEligibiliteRejeter, nil
+}
+
+// Eligibilite re-evaluates the formation's rules against the inscription's answers
+// and returns which rules pass or fail. Nothing is stored.
+func (h *InscriptionHandler) Eligibilite(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ins, err := h.repo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	if c.GetString("role") == "CANDIDAT" && ins.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "accès refusé"})
+		return
+	}
+
+	regles, err := h.regleRepo.FindByFormationID(ins.FormationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resultats, eligible := eligibility.Evaluate(regles, ins.Reponses)
+	statut := model.EligibiliteEligible
+	if !eligible {
+		statut = model.EligibiliteNonEligible
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"statut":            statut,
+		"resultats":         resultats,
+		"statut_enregistre": ins.EligibiliteStatut,
+	})
+}
@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/ics"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// EntretienHandler handles HTTP requests for interview slots and bookings.
+type EntretienHandler struct {
+	repo            *repository.EntretienRepository
+	inscriptionRepo *repository.InscriptionRepository
+	programs        *client.ProgramClient
+	notifications   *client.NotificationClient
+	organisateur    string
+}
+
+// NewEntretienHandler creates a new EntretienHandler.
+func NewEntretienHandler(
+	repo *repository.EntretienRepository,
+	inscriptionRepo *repository.InscriptionRepository,
+	programs *client.ProgramClient,
+	notifications *client.NotificationClient,
+	organisateur string,
+) *EntretienHandler {
+	return &EntretienHandler{
+		repo:            repo,
+		inscriptionRepo: inscriptionRepo,
+		programs:        programs,
+		notifications:   notifications,
+		organisateur:    organisateur,
+	}
+}
+
+// ListCreneaux returns the interview slots of a formation. Candidates only see upcoming slots.
+func (h *EntretienHandler) ListCreneaux(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	aVenir := c.GetString("role") == "CANDIDAT" || c.Query("a_venir") == "true"
+	creneaux, err := h.repo.FindCreneaux(uint(id), aVenir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	scope := etablissementScope(c)
+	visibles := make([]model.CreneauEntretien, 0, len(creneaux))
+	for _, cr := range creneaux {
+		if scope == "" || cr.EtablissementID == scope {
+			visibles = append(visibles, cr)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": visibles})
+}
+
+// CreateCreneau publishes an interview slot for a formation.
+func (h *EntretienHandler) CreateCreneau(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Debut     time.Time `json:"debut" binding:"required"`
+		Fin       time.Time `json:"fin" binding:"required"`
+		Capacite  int       `json:"capacite" binding:"required,min=1"`
+		Lieu      string    `json:"lieu"`
+		LienVisio string    `json:"lien_visio" binding:"omitempty,url"`
+		Jury      string    `json:"jury" binding:"required,max=100"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !input.Fin.After(input.Debut) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "la fin du créneau doit suivre son début"})
+		return
+	}
+	if !input.Debut.After(time.Now()) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "le créneau doit être dans le futur"})
+		return
+	}
+	if input.Lieu == "" && input.LienVisio == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "un lieu ou un lien de visioconférence est requis"})
+		return
+	}
+
+	formation := formationInScope(c, h.programs, uint(id))
+	if formation == nil {
+		return
+	}
+
+	creneau := model.CreneauEntretien{
+		FormationID:     uint(id),
+		EtablissementID: formation.EtablissementID,
+		Debut:           input.Debut,
+		Fin:             input.Fin,
+		Capacite:        input.Capacite,
+		Lieu:            input.Lieu,
+		LienVisio:       input.LienVisio,
+		Jury:            input.Jury,
+		CreePar:         c.GetString("user_id"),
+	}
+
+	if err := h.repo.CreateCreneau(&creneau); err != nil {
+		if errors.Is(err, repository.ErrChevauchement) {
+			c.JSON(http.StatusConflict, gin.H{"error": "ce jury a déjà un créneau sur cette plage horaire"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": creneau})
+}
+
+// DeleteCreneau withdraws a slot that nobody has booked yet.
+func (h *EntretienHandler) DeleteCreneau(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	creneau, err := h.repo.FindCreneauByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "creneau not found"})
+		return
+	}
+	if scope := etablissementScope(c); scope != "" && creneau.EtablissementID != scope {
+		c.JSON(http.StatusNotFound, gin.H{"error": "creneau not found"})
+		return
+	}
+
+	if err := h.repo.DeleteCreneau(creneau.ID); err != nil {
+		if errors.Is(err, repository.ErrCreneauComplet) {
+			c.JSON(http.StatusConflict, gin.H{"error": "des candidats ont déjà réservé ce créneau"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "créneau supprimé"})
+}
+
+// GetReservation returns the interview booked for an inscription.
+func (h *EntretienHandler) GetReservation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	if c.GetString("role") == "CANDIDAT" && ins.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "accès refusé"})
+		return
+	}
+
+	res, err := h.repo.FindReservation(ins.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "aucun entretien réservé"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": res})
+}
+
+// Reserver books an interview slot for an inscription, or reschedules the existing
+// booking, and e-mails the candidate an iCalendar invitation.
+func (h *EntretienHandler) Reserver(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		CreneauID uint `json:"creneau_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	if ins.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "seul le candidat concerné peut réserver un entretien"})
+		return
+	}
+
+	if ins.Etat != model.EtatEnValidation {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "un entretien ne peut être réservé que pendant la validation du dossier",
+			"etat_actuel": ins.Etat,
+		})
+		return
+	}
+
+	creneau, err := h.repo.FindCreneauByID(input.CreneauID)
+	if err != nil || creneau.FormationID != ins.FormationID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "creneau not found"})
+		return
+	}
+	if !creneau.Debut.After(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "ce créneau est déjà passé"})
+		return
+	}
+
+	// An interview that has already started cannot be moved
+	if actuelle, err := h.repo.FindReservation(ins.ID); err == nil && actuelle.Creneau != nil &&
+		actuelle.CreneauID != creneau.ID && !actuelle.Creneau.Debut.After(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "l'entretien actuel a déjà eu lieu"})
+		return
+	}
+
+	res, err := h.repo.Reserver(ins.ID, ins.CandidatID, creneau.ID)
+	switch {
+	case errors.Is(err, repository.ErrCreneauComplet):
+		c.JSON(http.StatusConflict, gin.H{"error": "ce créneau est complet"})
+		return
+	case errors.Is(err, repository.ErrChevauchement):
+		c.JSON(http.StatusConflict, gin.H{"error": "vous avez déjà un entretien sur cette plage horaire"})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "creneau not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.envoyerConvocation(c, ins, res); err != nil {
+		log.Printf("interview booking %d: invitation not sent: %v", res.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": res})
+}
+
+// envoyerConvocation e-mails the booking confirmation with an .ics invitation.
+// The event UID is stable per booking and the sequence grows on each reschedule,
+// so calendars update the existing event instead of adding a new one.
+func (h *EntretienHandler) envoyerConvocation(c *gin.Context, ins *model.Inscription, res *model.ReservationEntretien) error {
+	if ins.Email == "" {
+		return errors.New("aucune adresse e-mail sur l'inscription")
+	}
+
+	titre := fmt.Sprintf("Formation #%d", ins.FormationID)
+	if f, err := h.programs.GetFormation(c.Request.Context(), ins.FormationID, c.GetHeader("Authorization")); err == nil && f.Titre != "" {
+		titre = f.Titre
+	}
+
+	cr := res.Creneau
+	invitation := ics.Request(ics.Event{
+		UID:          fmt.Sprintf("entretien-%d@application-service.fst-cfc", res.ID),
+		Sequence:     res.Sequence,
+		Debut:        cr.Debut,
+		Fin:          cr.Fin,
+		Titre:        "Entretien — " + titre,
+		Description:  "Entretien d'admission — dossier " + ins.NumeroDossier(),
+		Lieu:         cr.Lieu,
+		URL:          cr.LienVisio,
+		Organisateur: h.organisateur,
+		Participant:  ins.Email,
+	}, time.Now())
+
+	return h.notifications.Send(c.Request.Context(), fmt.Sprintf("entretien-%d-%d", res.ID, res.Sequence), client.Notification{
+		TemplateKey: "entretien-confirmation",
+		Recipient:   ins.Email,
+		Payload: map[string]interface{}{
+			"nomComplet":    ins.NomComplet,
+			"formation":     titre,
+			"inscriptionId": ins.ID,
+			"reference":     ins.NumeroDossier(),
+			"debut":         cr.Debut.Format(time.RFC3339),
+			"fin":           cr.Fin.Format(time.RFC3339),
+			"lieu":          cr.Lieu,
+			"lienVisio":     cr.LienVisio,
+			"reprogramme":   res.Sequence > 0,
+		},
+		Attachments: []client.Attachment{
+			client.NewAttachment("entretien.ics", "text/calendar; charset=utf-8; method=REQUEST", invitation),
+		},
+	})
+}
@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// delaiAppelAmont bounds each call to another service while building the candidate's
+// home page, so that a slow service only leaves its part empty.
+const delaiAppelAmont = 2 * time.Second
+
+// EspaceCandidatHandler builds the candidate's home page in one call.
+type EspaceCandidatHandler struct {
+	repo           *repository.InscriptionRepository
+	recoursRepo    *repository.RecoursRepository
+	entretienRepo  *repository.EntretienRepository
+	programs       *client.ProgramClient
+	documents      *client.DocumentClient
+	piecesRequises []string
+	delaiRecours   int
+}
+
+// NewEspaceCandidatHandler creates a new EspaceCandidatHandler. piecesRequises are the
+// document types every dossier needs; delaiRecours is the appeal window in days.
+func NewEspaceCandidatHandler(
+	repo *repository.InscriptionRepository,
+	recoursRepo *repository.RecoursRepository,
+	entretienRepo *repository.EntretienRepository,
+	programs *client.ProgramClient,
+	documents *client.DocumentClient,
+	piecesRequises []string,
+	delaiRecours int,
+) *EspaceCandidatHandler {
+	return &EspaceCandidatHandler{
+		repo:           repo,
+		recoursRepo:    recoursRepo,
+		entretienRepo:  entretienRepo,
+		programs:       programs,
+		documents:      documents,
+		piecesRequises: piecesRequises,
+		delaiRecours:   delaiRecours,
+	}
+}
+
+// MesInscriptions returns the caller's inscriptions with their formation from
+// program-service, their document checklist from document-service, the next expected
+// action and the upcoming deadlines. The other services are called in parallel, each
+// call bounded by delaiAppelAmont; a service that fails is listed in
+// "services_indisponibles" and its part is left null.
+func (h *EspaceCandidatHandler) MesInscriptions(c *gin.Context) {
+	inscriptions, err := h.repo.FindAll(repository.InscriptionFilter{CandidatID: c.GetString("user_id")})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var (
+		wg            sync.WaitGroup
+		mu            sync.Mutex
+		formations    = map[uint]*client.Formation{}
+		documents     = map[uint][]client.Document{}
+		indisponibles = map[string]bool{}
+	)
+	appeler := func(service string, appel func(ctx context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(c.Request.Context(), delaiAppelAmont)
+			defer cancel()
+			if err := appel(ctx); err != nil {
+				mu.Lock()
+				indisponibles[service] = true
+				mu.Unlock()
+			}
+		}()
+	}
+
+	authorization := c.GetHeader("Authorization")
+	demandees := map[uint]bool{}
+	for _, ins := range inscriptions {
+		formationID := ins.FormationID
+		if !demandees[formationID] {
+			demandees[formationID] = true
+			appeler("program-service", func(ctx context.Context) error {
+				formation, err := h.programs.GetFormation(ctx, formationID, authorization)
+				if errors.Is(err, client.ErrNotFound) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				mu.Lock()
+				formations[formationID] = formation
+				mu.Unlock()
+				return nil
+			})
+		}
+
+		inscriptionID := ins.ID
+		appeler("document-service", func(ctx context.Context) error {
+			docs, err := h.documents.ListInscription(ctx, inscriptionID)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			documents[inscriptionID] = docs
+			mu.Unlock()
+			return nil
+		})
+	}
+	wg.Wait()
+
+	dossiers := make([]model.DossierCandidat, len(inscriptions))
+	for i := range inscriptions {
+		ins := &inscriptions[i]
+		dossier := model.DossierCandidat{Inscription: *ins}
+
+		formation := formations[ins.FormationID]
+		if formation != nil {
+			dossier.Formation = &model.FormationCandidat{
+				ID:                   formation.ID,
+				Titre:                formation.Titre,
+				DateOuverture:        formation.DateOuverture,
+				DateFermeture:        formation.DateFermeture,
+				InscriptionsOuvertes: formation.InscriptionsOuvertes,
+			}
+		}
+		if docs, ok := documents[ins.ID]; ok {
+			dossier.Documents = checklist(h.piecesRequises, docs)
+		}
+
+		action, echeance, err := h.suivi(ins, dossier.Formation, dossier.Documents)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		dossier.ProchaineAction = action
+		dossier.Echeances = []model.Echeance{}
+		if echeance != nil {
+			dossier.Echeances = append(dossier.Echeances, *echeance)
+		}
+		dossiers[i] = dossier
+	}
+
+	services := []string{}
+	for service := range indisponibles {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":                   dossiers,
+		"services_indisponibles": services,
+	})
+}
+
+// checklist matches the uploaded documents with the required types. When a type was
+// uploaded several times, the latest upload counts.
+func checklist(requises []string, docs []client.Document) *model.ChecklistDocuments {
+	derniers := map[string]client.Document{}
+	autres := 0
+	for _, doc := range docs {
+		if d, ok := derniers[doc.TypeDocument]; !ok || doc.CreatedAt.After(d.CreatedAt) {
+			derniers[doc.TypeDocument] = doc
+		}
+	}
+	requis := map[string]bool{}
+	for _, t := range requises {
+		requis[t] = true
+	}
+	for _, doc := range docs {
+		if !requis[doc.TypeDocument] {
+			autres++
+		}
+	}
+
+	resultat := &model.ChecklistDocuments{Complet: true, Pieces: []model.PieceDossier{}, Autres: autres}
+	for _, t := range requises {
+		piece := model.PieceDossier{Type: t}
+		if doc, ok := derniers[t]; ok {
+			id, depose := doc.ID, doc.CreatedAt
+			piece.Fournie = true
+			piece.DocumentID = &id
+			piece.NomFichier = doc.NomFichier
+			piece.DeposeLe = &depose
+		} else {
+			resultat.Complet = false
+		}
+		resultat.Pieces = append(resultat.Pieces, piece)
+	}
+	return resultat
+}
+
+// suivi works out the next expected action of an inscription and the deadline that
+// goes with it, if any. formation and documents may be nil when their service did
+// not answer.
+func (h *EspaceCandidatHandler) suivi(ins *model.Inscription, formation *model.FormationCandidat, documents *model.ChecklistDocuments) (*model.ActionAttendue, *model.Echeance, error) {
+	switch ins.Etat {
+	case model.EtatPreinscription:
+		var cloture *model.Echeance
+		if formation != nil && formation.DateFermeture != nil {
+			cloture = &model.Echeance{
+				Type:    model.EcheanceClotureInscriptions,
+				Date:    *formation.DateFermeture,
+				Libelle: "Clôture des inscriptions",
+			}
+		}
+		if documents != nil && !documents.Complet {
+			return attendue(model.ActionCompleterDossier, "Déposez les pièces manquantes de votre dossier", cloture)
+		}
+		return attendue(model.ActionSoumettreDossier, "Soumettez votre dossier", cloture)
+
+	case model.EtatDossierSoumis:
+		return attendue(model.ActionAttendreExamen, "Votre dossier est en cours d'examen", nil)
+
+	case model.EtatEnValidation:
+		reservation, err := h.entretienRepo.FindReservation(ins.ID)
+		if err == nil && reservation.Creneau != nil {
+			if reservation.Creneau.Debut.After(time.Now()) {
+				return attendue(model.ActionPasserEntretien, "Présentez-vous à votre entretien", &model.Echeance{
+					Type:    model.EcheanceEntretien,
+					Date:    reservation.Creneau.Debut,
+					Libelle: "Entretien",
+				})
+			}
+			return attendue(model.ActionAttendreDecision, "Le jury étudie votre candidature", nil)
+		}
+		creneaux, err := h.entretienRepo.FindCreneaux(ins.FormationID, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(creneaux) > 0 {
+			return attendue(model.ActionReserverEntretien, "Réservez un créneau d'entretien", nil)
+		}
+		return attendue(model.ActionAttendreDecision, "Le jury étudie votre candidature", nil)
+
+	case model.EtatListeAttente:
+		return attendue(model.ActionAttendreDecision, "Vous êtes sur liste d'attente", nil)
+
+	case model.EtatAccepte:
+		if ins.OffreConfirmeeLe != nil {
+			return attendue(model.ActionAttendreInscription, "Votre inscription va être finalisée", nil)
+		}
+		var limite *model.Echeance
+		if ins.OffreExpireLe != nil {
+			limite = &model.Echeance{
+				Type:    model.EcheanceConfirmationOffre,
+				Date:    *ins.OffreExpireLe,
+				Libelle: "Confirmation de votre place",
+			}
+		}
+		return attendue(model.ActionConfirmerOffre, "Confirmez ou déclinez votre place", limite)
+
+	case model.EtatRefuse:
+		refus, err := h.repo.FindLastDecision(ins.ID, model.EtatRefuse)
+		if err != nil {
+			return nil, nil, nil
+		}
+		n, err := h.recoursRepo.CountSince(ins.ID, refus)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n > 0 {
+			return attendue(model.ActionAttendreRecours, "Votre recours est en cours d'examen", nil)
+		}
+		limite := refus.CreatedAt.AddDate(0, 0, h.delaiRecours)
+		if !limite.After(time.Now()) {
+			return nil, nil, nil
+		}
+		return attendue(model.ActionDeposerRecours, "Vous pouvez contester ce refus", &model.Echeance{
+			Type:    model.EcheanceRecours,
+			Date:    limite,
+			Libelle: "Dépôt d'un recours",
+		})
+
+	case model.EtatInscrit:
+		return attendue(model.ActionSuivreFormation, "Suivez votre formation", nil)
+
+	case model.EtatValide:
+		return attendue(model.ActionTelechargerAttestation, "Téléchargez votre attestation de réussite", nil)
+	}
+	return nil, nil, nil
+}
+
+// attendue builds an action with its deadline, dropped once past.
+func attendue(code, libelle string, echeance *model.Echeance) (*model.ActionAttendue, *model.Echeance, error) {
+	if echeance != nil && !echeance.Date.After(time.Now()) {
+		echeance = nil
+	}
+	action := &model.ActionAttendue{Code: code, Libelle: libelle}
+	if echeance != nil {
+		action.Echeance = &echeance.Date
+	}
+	return action, echeance, nil
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/formschema"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/identite"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// listFilter builds the inscription filter shared by List, Export and bulk messages
+// from the query string. ?etat= accepts a comma-separated list of states. Candidates
+// only get their own inscriptions and cannot filter on identity fields or answers.
+func listFilter(c *gin.Context) repository.InscriptionFilter {
+	f := repository.InscriptionFilter{
+		EtablissementID: etablissementScope(c),
+		CandidatID:      c.Query("candidat_id"),
+		Reference:       c.Query("reference"),
+		Reponses:        c.QueryMap("reponses"),
+	}
+	if id, err := strconv.ParseUint(c.Query("formation_id"), 10, 32); err == nil {
+		f.FormationID = uint(id)
+	}
+	for _, etat := range strings.Split(c.Query("etat"), ",") {
+		if etat = strings.TrimSpace(etat); etat != "" {
+			f.Etats = append(f.Etats, model.EtatInscription(strings.ToUpper(etat)))
+		}
+	}
+	// Identifiers are matched in their normalised form; an invalid one matches nothing
+	if cin := c.Query("cin"); cin != "" {
+		f.CIN, _ = identite.NormaliserCIN(cin)
+	}
+	if cne := c.Query("cne"); cne != "" {
+		f.CNE, _ = identite.NormaliserCNE(cne)
+	}
+	if c.GetString("role") == "CANDIDAT" {
+		f.CandidatID = c.GetString("user_id")
+		f.CIN, f.CNE, f.Reponses = "", "", nil
+	}
+	return f
+}
+
+// Export streams the filtered inscriptions as CSV, one column per form answer.
+// When ?formation_id= is given, answer columns follow that formation's form schema;
+// otherwise they are the union of the keys found in the answers.
+func (h *InscriptionHandler) Export(c *gin.Context) {
+	filter := listFilter(c)
+
+	inscriptions, err := h.repo.FindAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	answers := make([]map[string]interface{}, len(inscriptions))
+	for i, ins := range inscriptions {
+		if len(ins.Reponses) > 0 {
+			_ = json.Unmarshal(ins.Reponses, &answers[i])
+		}
+	}
+
+	keys := h.answerColumns(filter.FormationID, answers)
+
+	header := []string{
+		"id", "reference", "candidat_id", "formation_id", "etablissement_id", "etat",
+		"nom_complet", "email", "telephone", "cin", "cne", "date_naissance",
+		"dernier_diplome", "annee_diplome", "date_creation",
+	}
+	for _, k := range keys {
+		header = append(header, "reponses."+k)
+	}
+
+	filename := fmt.Sprintf("inscriptions-%s.csv", time.Now().Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(header)
+	for i, ins := range inscriptions {
+		row := []string{
+			strconv.FormatUint(uint64(ins.ID), 10),
+			ins.NumeroDossier(),
+			ins.CandidatID,
+			strconv.FormatUint(uint64(ins.FormationID), 10),
+			ins.EtablissementID,
+			string(ins.Etat),
+			ins.NomComplet,
+			ins.Email,
+			ins.Telephone,
+			valeurOuVide(ins.CIN),
+			valeurOuVide(ins.CNE),
+			dateOuVide(ins.DateNaissance),
+			ins.DernierDiplome,
+			entierOuVide(ins.AnneeDiplome),
+			ins.DateCreation.Format(time.RFC3339),
+		}
+		for _, k := range keys {
+			row = append(row, answerCell(answers[i][k]))
+		}
+		_ = w.Write(row)
+	}
+	w.Flush()
+}
+
+// answerColumns returns the form answer keys to export.
+func (h *InscriptionHandler) answerColumns(formationID uint, answers []map[string]interface{}) []string {
+	if formationID != 0 {
+		if form, err := h.formRepo.FindByFormationID(formationID); err == nil {
+			if schema, err := formschema.Parse(form.Schema); err == nil {
+				return schema.Keys()
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	var keys []string
+	for _, a := range answers {
+		for k := range a {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// valeurOuVide renders an optional string as a CSV cell.
+func valeurOuVide(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// dateOuVide renders an optional date as a CSV cell.
+func dateOuVide(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// entierOuVide renders an optional integer as a CSV cell.
+func entierOuVide(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+// answerCell renders one answer as a CSV cell; arrays and objects are kept as JSON.
+func answerCell(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// etatsATraiter are the states in which a dossier waits for its coordinator.
+var etatsATraiter = []model.EtatInscription{model.EtatDossierSoumis, model.EtatEnValidation}
+
+// FileHandler serves the work queue of coordinators: the dossiers waiting for them
+// across their formations, and the claims that keep two colleagues off the same one.
+type FileHandler struct {
+	repo            *repository.FileRepository
+	inscriptionRepo *repository.InscriptionRepository
+	paramRepo       *repository.ParametresFormationRepository
+	programs        *client.ProgramClient
+	defauts         model.DelaisSla
+	dureePrise      time.Duration
+}
+
+// NewFileHandler creates a new FileHandler. defauts holds the SLA, in hours per state,
+// of formations that set none; dureePriseHeures is how long a claim holds unless its
+// holder renews it.
+func NewFileHandler(
+	repo *repository.FileRepository,
+	inscriptionRepo *repository.InscriptionRepository,
+	paramRepo *repository.ParametresFormationRepository,
+	programs *client.ProgramClient,
+	defauts map[string]int,
+	dureePriseHeures int,
+) *FileHandler {
+	return &FileHandler{
+		repo:            repo,
+		inscriptionRepo: inscriptionRepo,
+		paramRepo:       paramRepo,
+		programs:        programs,
+		defauts:         model.DelaisSlaDe(defauts),
+		dureePrise:      time.Duration(dureePriseHeures) * time.Hour,
+	}
+}
+
+// List returns the caller's work queue: the dossiers in actionable states of the
+// formations they coordinate (all formations of the institution for its
+// administrator), closest to or furthest past their SLA first, then oldest first.
+// ?formation_id= and ?etat= narrow it, ?limit= caps it (default 100); the counts per
+// formation cover the whole queue.
+func (h *FileHandler) List(c *gin.Context) {
+	etats := etatsATraiter
+	if e := c.Query("etat"); e != "" {
+		etat := model.EtatInscription(e)
+		if etat != model.EtatDossierSoumis && etat != model.EtatEnValidation {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "etat must be DOSSIER_SOUMIS or EN_VALIDATION"})
+			return
+		}
+		etats = []model.EtatInscription{etat}
+	}
+	limit := 100
+	if n, err := strconv.Atoi(c.Query("limit")); err == nil && n > 0 {
+		limit = min(n, 500)
+	}
+
+	formations, err := h.programs.ListFormations(c.Request.Context(), c.GetString("institution_id"), c.GetHeader("Authorization"))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	filtre, _ := strconv.ParseUint(c.Query("formation_id"), 10, 32)
+	titres := map[uint]string{}
+	var formationIDs []uint
+	for _, f := range formations {
+		if c.GetString("role") == "COORDINATEUR" && f.CoordinateurID != c.GetString("user_id") {
+			continue
+		}
+		if filtre != 0 && f.ID != uint(filtre) {
+			continue
+		}
+		titres[f.ID] = f.Titre
+		formationIDs = append(formationIDs, f.ID)
+	}
+	if len(formationIDs) == 0 {
+		c.JSON(http.StatusOK, gin.H{"data": []model.ElementFile{}, "formations": []model.CompteFile{}, "total": 0})
+		return
+	}
+
+	inscriptions, err := h.repo.FindEnAttente(etats, formationIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ids := make([]uint, len(inscriptions))
+	for i, ins := range inscriptions {
+		ids[i] = ins.ID
+	}
+	prises, err := h.repo.FindPrises(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	maintenant := time.Now()
+	delais := map[uint]model.DelaisSla{}
+	comptes := map[uint]*model.CompteFile{}
+	for _, id := range formationIDs {
+		p, err := h.paramRepo.Get(id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		delais[id] = p.Sla(h.defauts)
+		comptes[id] = &model.CompteFile{FormationID: id, Titre: titres[id]}
+	}
+
+	elements := make([]model.ElementFile, len(inscriptions))
+	for i, ins := range inscriptions {
+		e := model.ElementFile{
+			Inscription: ins.Inscription,
+			EntreeEtat:  ins.EntreeEtat,
+			AgeHeures:   int(maintenant.Sub(ins.EntreeEtat).Hours()),
+		}
+		if heures, ok := delais[ins.FormationID][ins.Etat]; ok && heures > 0 {
+			echeance := ins.EntreeEtat.Add(time.Duration(heures) * time.Hour)
+			risque := maintenant.Sub(ins.EntreeEtat).Hours() / float64(heures)
+			e.DelaiSla, e.Echeance, e.RisqueSla = &heures, &echeance, &risque
+		}
+		if p, ok := prises[ins.ID]; ok && p.Active(ins.Etat, maintenant) {
+			e.PriseEnCharge = &p
+			e.ParMoi = p.Par == c.GetString("user_id")
+		}
+		elements[i] = e
+
+		compte := comptes[ins.FormationID]
+		compte.Total++
+		if e.RisqueSla != nil && *e.RisqueSla >= 1 {
+			compte.EnRetard++
+		}
+		if e.PriseEnCharge != nil {
+			compte.PrisEnCharge++
+		}
+	}
+
+	// Dossiers without SLA come after those with one, oldest first among equals
+	sort.SliceStable(elements, func(i, j int) bool {
+		ri, rj := elements[i].RisqueSla, elements[j].RisqueSla
+		if (ri == nil) != (rj == nil) {
+			return ri != nil
+		}
+		if ri != nil && *ri != *rj {
+			return *ri > *rj
+		}
+		return elements[i].EntreeEtat.Before(elements[j].EntreeEtat)
+	})
+
+	parFormation := make([]model.CompteFile, 0, len(formationIDs))
+	for _, id := range formationIDs {
+		parFormation = append(parFormation, *comptes[id])
+	}
+	total := len(elements)
+	if len(elements) > limit {
+		elements = elements[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{"data": elements, "formations": parFormation, "total": total})
+}
+
+// Prendre claims a dossier of the queue for the caller, or renews their claim. A
+// colleague's active claim is answered with 409 and its holder.
+func (h *FileHandler) Prendre(c *gin.Context) {
+	ins := h.dossierATraiter(c)
+	if ins == nil {
+		return
+	}
+	if ins.Etat != model.EtatDossierSoumis && ins.Etat != model.EtatEnValidation {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "seuls les dossiers soumis ou en validation peuvent être pris en charge",
+			"etat":  ins.Etat,
+		})
+		return
+	}
+
+	maintenant := time.Now()
+	p := &model.PriseEnCharge{
+		InscriptionID: ins.ID,
+		Etat:          ins.Etat,
+		Par:           c.GetString("user_id"),
+		Le:            maintenant,
+		ExpireLe:      maintenant.Add(h.dureePrise),
+	}
+	err := h.repo.Prendre(p)
+	if errors.Is(err, repository.ErrDejaPrisEnCharge) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "prise_en_charge": p})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": p})
+}
+
+// Liberer releases the caller's claim on a dossier. The institution administrator
+// may release anyone's.
+func (h *FileHandler) Liberer(c *gin.Context) {
+	ins := h.dossierATraiter(c)
+	if ins == nil {
+		return
+	}
+	par := c.GetString("user_id")
+	if c.GetString("role") == "ADMIN_ETABLISSEMENT" {
+		par = ""
+	}
+	libere, err := h.repo.Liberer(ins.ID, par)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !libere {
+		c.JSON(http.StatusNotFound, gin.H{"error": "aucune prise en charge à libérer"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "prise en charge libérée"})
+}
+
+// dossierATraiter loads the inscription of the :id parameter and checks that it
+// belongs to the caller's queue: their institution and, for a coordinator, one of
+// their formations. On failure it writes the response and returns nil.
+func (h *FileHandler) dossierATraiter(c *gin.Context) *model.Inscription {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil
+	}
+	ins, err := h.inscriptionRepo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return nil
+	}
+	if c.GetString("role") == "COORDINATEUR" {
+		formation := formationInScope(c, h.programs, ins.FormationID)
+		if formation == nil {
+			return nil
+		}
+		if formation.CoordinateurID != c.GetString("user_id") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "accès refusé"})
+			return nil
+		}
+	}
+	return ins
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/formschema"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// FormulaireHandler handles HTTP requests for per-formation application forms.
+type FormulaireHandler struct {
+	repo     *repository.FormulaireRepository
+	programs *client.ProgramClient
+}
+
+// NewFormulaireHandler creates a new FormulaireHandler.
+func NewFormulaireHandler(repo *repository.FormulaireRepository, programs *client.ProgramClient) *FormulaireHandler {
+	return &FormulaireHandler{repo: repo, programs: programs}
+}
+
+// Get returns the form schema of a formation (candidates need it to fill in their application).
+func (h *FormulaireHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	f, err := h.repo.FindByFormationID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "aucun formulaire défini pour cette formation"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": f})
+}
+
+// Put defines or replaces the form schema of a formation. Each change bumps the version;
+// answers already stored keep the version they were validated against.
+func (h *FormulaireHandler) Put(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Schema json.RawMessage `json:"schema" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := formschema.Parse(input.Schema); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	f := &model.Formulaire{
+		FormationID: uint(id),
+		Schema:      model.JSON(input.Schema),
+		Version:     1,
+		ModifiePar:  c.GetString("user_id"),
+	}
+	if err := h.repo.Save(f); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": f})
+}
@@ -0,0 +1,359 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GroupeHandler handles HTTP requests for cohorts of enrolled learners.
+type GroupeHandler struct {
+	repo            *repository.GroupeRepository
+	inscriptionRepo *repository.InscriptionRepository
+	programs        *client.ProgramClient
+}
+
+// NewGroupeHandler creates a new GroupeHandler.
+func NewGroupeHandler(
+	repo *repository.GroupeRepository,
+	inscriptionRepo *repository.InscriptionRepository,
+	programs *client.ProgramClient,
+) *GroupeHandler {
+	return &GroupeHandler{repo: repo, inscriptionRepo: inscriptionRepo, programs: programs}
+}
+
+// List returns the groups of a formation with their headcount.
+func (h *GroupeHandler) List(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	groupes, err := h.repo.FindByFormationID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	scope := etablissementScope(c)
+	visibles := make([]model.Groupe, 0, len(groupes))
+	for _, g := range groupes {
+		if scope == "" || g.EtablissementID == scope {
+			visibles = append(visibles, g)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": visibles})
+}
+
+// Create adds a group to a formation.
+func (h *GroupeHandler) Create(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Nom         string `json:"nom" binding:"required,max=100"`
+		Description string `json:"description"`
+		Capacite    int    `json:"capacite" binding:"required,min=1"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	formation := formationInScope(c, h.programs, uint(id))
+	if formation == nil {
+		return
+	}
+
+	g := model.Groupe{
+		FormationID:     uint(id),
+		EtablissementID: formation.EtablissementID,
+		Nom:             input.Nom,
+		Description:     input.Description,
+		Capacite:        input.Capacite,
+		CreePar:         c.GetString("user_id"),
+	}
+
+	if err := h.repo.Create(&g); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "un groupe porte déjà ce nom pour cette formation"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": g})
+}
+
+// Update renames a group or changes its description or capacity.
+func (h *GroupeHandler) Update(c *gin.Context) {
+	g := h.groupeInScope(c)
+	if g == nil {
+		return
+	}
+
+	var input struct {
+		Nom         *string `json:"nom" binding:"omitempty,min=1,max=100"`
+		Description *string `json:"description"`
+		Capacite    *int    `json:"capacite" binding:"omitempty,min=1"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Nom != nil {
+		g.Nom = *input.Nom
+	}
+	if input.Description != nil {
+		g.Description = *input.Description
+	}
+	if input.Capacite != nil {
+		g.Capacite = *input.Capacite
+	}
+
+	if err := h.repo.Update(g); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrGroupeNonVide):
+			c.JSON(http.StatusConflict, gin.H{"error": "la capacité ne peut pas être inférieure à l'effectif du groupe"})
+		case errors.Is(err, gorm.ErrDuplicatedKey):
+			c.JSON(http.StatusConflict, gin.H{"error": "un groupe porte déjà ce nom pour cette formation"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": g})
+}
+
+// Delete removes an empty group.
+func (h *GroupeHandler) Delete(c *gin.Context) {
+	g := h.groupeInScope(c)
+	if g == nil {
+		return
+	}
+
+	if err := h.repo.Delete(g.ID); err != nil {
+		if errors.Is(err, repository.ErrGroupeNonVide) {
+			c.JSON(http.StatusConflict, gin.H{"error": "retirez d'abord les membres du groupe"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "groupe supprimé"})
+}
+
+// Membres returns the roster of a group.
+func (h *GroupeHandler) Membres(c *gin.Context) {
+	g := h.groupeInScope(c)
+	if g == nil {
+		return
+	}
+
+	membres, err := h.repo.FindMembres(g.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": membres, "groupe": g})
+}
+
+// ExportMembres streams the roster of a group as CSV, for the trainers.
+func (h *GroupeHandler) ExportMembres(c *gin.Context) {
+	g := h.groupeInScope(c)
+	if g == nil {
+		return
+	}
+
+	membres, err := h.repo.FindMembres(g.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("groupe-%d-%s.csv", g.ID, time.Now().Format("20060102-150405"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{
+		"groupe", "inscription_id", "reference", "candidat_id", "nom_complet", "email", "telephone", "date_affectation",
+	})
+	for _, m := range membres {
+		if m.Inscription == nil {
+			continue
+		}
+		_ = w.Write([]string{
+			celluleCSV(g.Nom),
+			strconv.FormatUint(uint64(m.InscriptionID), 10),
+			m.Inscription.NumeroDossier(),
+			celluleCSV(m.Inscription.CandidatID),
+			celluleCSV(m.Inscription.NomComplet),
+			celluleCSV(m.Inscription.Email),
+			celluleCSV(m.Inscription.Telephone),
+			m.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+}
+
+// celluleCSV keeps a value typed by a user from being read as a formula by
+// spreadsheets: one starting with =, +, -, @, a tab or a carriage return is prefixed
+// with a quote.
+func celluleCSV(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// Repartir distributes the formation's enrolled learners without a group over its
+// groups, balancing their fill rate.
+func (h *GroupeHandler) Repartir(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	affectes, restants, err := h.repo.Repartir(uint(id), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	groupes, err := h.repo.FindByFormationID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"affectes":    affectes,
+		"sans_groupe": restants,
+		"data":        groupes,
+	})
+}
+
+// Affecter assigns an enrolled inscription to a group, or moves it to another one.
+func (h *GroupeHandler) Affecter(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		GroupeID uint `json:"groupe_id" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	if ins.Etat != model.EtatInscrit {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "seuls les candidats inscrits peuvent être affectés à un groupe",
+			"etat_actuel": ins.Etat,
+		})
+		return
+	}
+
+	g, err := h.repo.FindByID(input.GroupeID)
+	if err != nil || g.FormationID != ins.FormationID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "groupe not found"})
+		return
+	}
+
+	m, err := h.repo.Affecter(ins.ID, g.ID, c.GetString("user_id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrGroupeComplet) {
+			c.JSON(http.StatusConflict, gin.H{"error": "ce groupe est complet"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": m})
+}
+
+// Retirer removes an inscription from its group.
+func (h *GroupeHandler) Retirer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	n, err := h.repo.Retirer(ins.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cette inscription n'est affectée à aucun groupe"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "retiré du groupe"})
+}
+
+// groupeInScope loads the group named by the :id parameter and checks that staff
+// callers belong to its institution. On failure it writes the response and returns nil.
+func (h *GroupeHandler) groupeInScope(c *gin.Context) *model.Groupe {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil
+	}
+
+	g, err := h.repo.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "groupe not found"})
+		return nil
+	}
+	if scope := etablissementScope(c); scope != "" && g.EtablissementID != scope {
+		c.JSON(http.StatusNotFound, gin.H{"error": "groupe not found"})
+		return nil
+	}
+	return g
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotenceHandler exposes maintenance of stored idempotency keys.
+type IdempotenceHandler struct {
+	repo *repository.IdempotenceRepository
+}
+
+// NewIdempotenceHandler creates a new IdempotenceHandler.
+func NewIdempotenceHandler(repo *repository.IdempotenceRepository) *IdempotenceHandler {
+	return &IdempotenceHandler{repo: repo}
+}
+
+// PurgeExpired deletes expired idempotency keys (scheduler job).
+func (h *IdempotenceHandler) PurgeExpired(c *gin.Context) {
+	n, err := h.repo.PurgeExpired()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "expired idempotency keys purged",
+		"deleted": n,
+	})
+}
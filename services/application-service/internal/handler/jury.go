@@ -0,0 +1,375 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/pdf"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// JuryHandler handles the deliberation sessions of admission juries and their
+// minutes (procès-verbal).
+type JuryHandler struct {
+	repo     *repository.JuryRepository
+	programs *client.ProgramClient
+}
+
+// NewJuryHandler creates a new JuryHandler.
+func NewJuryHandler(repo *repository.JuryRepository, programs *client.ProgramClient) *JuryHandler {
+	return &JuryHandler{repo: repo, programs: programs}
+}
+
+// sessionInput is the body of session creation and update.
+type sessionInput struct {
+	Intitule  string             `json:"intitule" binding:"required,max=200"`
+	TenueLe   time.Time          `json:"tenue_le" binding:"required"`
+	Lieu      string             `json:"lieu" binding:"max=200"`
+	President string             `json:"president" binding:"required,max=200"`
+	Membres   []model.MembreJury `json:"membres" binding:"required,min=1,dive"`
+}
+
+// appliquer copies the input onto the session.
+func (in *sessionInput) appliquer(s *model.SessionJury) error {
+	membres, err := json.Marshal(in.Membres)
+	if err != nil {
+		return err
+	}
+	s.Intitule = in.Intitule
+	s.TenueLe = in.TenueLe
+	s.Lieu = in.Lieu
+	s.President = in.President
+	s.Membres = model.JSON(membres)
+	return nil
+}
+
+// List returns the jury sessions of a formation, newest first.
+func (h *JuryHandler) List(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	sessions, err := h.repo.FindByFormation(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+// Create opens a jury session for a formation, with its chair and attending members.
+// Admission decisions on the formation's dossiers are attached to it until it closes.
+func (h *JuryHandler) Create(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input sessionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	formation := formationInScope(c, h.programs, uint(id))
+	if formation == nil {
+		return
+	}
+
+	s := &model.SessionJury{
+		FormationID:     uint(id),
+		EtablissementID: formation.EtablissementID,
+		Statut:          model.SessionJuryOuverte,
+		OuvertePar:      c.GetString("user_id"),
+	}
+	if err := input.appliquer(s); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.repo.Create(s); err != nil {
+		if errors.Is(err, repository.ErrSessionJuryOuverte) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": s})
+}
+
+// Get returns a jury session with the decisions attached to it.
+func (h *JuryHandler) Get(c *gin.Context) {
+	s := h.sessionInScope(c)
+	if s == nil {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": s})
+}
+
+// Update corrects the details and attendance of an open session.
+func (h *JuryHandler) Update(c *gin.Context) {
+	var input sessionInput
+	s := h.sessionInScope(c)
+	if s == nil {
+		return
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := input.appliquer(s); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.repo.Update(s); err != nil {
+		if errors.Is(err, repository.ErrSessionJuryClose) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": s})
+}
+
+// Cloturer closes a session: its decisions are locked and its minutes frozen. The
+// dossiers it decided can only be decided again by a later session.
+func (h *JuryHandler) Cloturer(c *gin.Context) {
+	s := h.sessionInScope(c)
+	if s == nil {
+		return
+	}
+
+	err := h.repo.Cloturer(s, c.GetString("user_id"), time.Now())
+	switch {
+	case errors.Is(err, repository.ErrSessionJuryClose):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, repository.ErrSessionJuryVide):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": s})
+}
+
+// Proces returns the minutes of a closed session as a PDF to be signed.
+func (h *JuryHandler) Proces(c *gin.Context) {
+	s := h.sessionInScope(c)
+	if s == nil {
+		return
+	}
+	if s.Statut != model.SessionJuryClose {
+		c.JSON(http.StatusConflict, gin.H{"error": "le procès-verbal est établi à la clôture de la session"})
+		return
+	}
+
+	var lignes []model.LigneProces
+	var membres []model.MembreJury
+	if err := json.Unmarshal(s.Proces, &lignes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := json.Unmarshal(s.Membres, &membres); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	titre := fmt.Sprintf("Formation n°%d", s.FormationID)
+	if f, err := h.programs.GetFormation(c.Request.Context(), s.FormationID, c.GetHeader("Authorization")); err == nil && f.Titre != "" {
+		titre = f.Titre
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="pv-jury-%d.pdf"`, s.ID))
+	c.Data(http.StatusOK, "application/pdf", procesPDF(s, titre, membres, lignes))
+}
+
+// sessionInScope loads the session of the :id parameter and checks that staff
+// callers belong to its institution. On failure it writes the response and returns nil.
+func (h *JuryHandler) sessionInScope(c *gin.Context) *model.SessionJury {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil
+	}
+
+	s, err := h.repo.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return nil
+	}
+	if scope := etablissementScope(c); scope != "" && s.EtablissementID != scope {
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return nil
+	}
+	return s
+}
+
+// sessionsJury returns, for the formations of the dossiers about to receive an
+// admission decision, the open session the decisions are attached to (nil when there
+// is none), and the dossiers that cannot be decided: their current state was decided
+// by a closed session and no session of their formation is open.
+func sessionsJury(jury *repository.JuryRepository, inscriptions []*model.Inscription) (map[uint]*uint, []uint, error) {
+	sessions := map[uint]*uint{}
+	var sansSession []uint
+	for _, ins := range inscriptions {
+		if _, ok := sessions[ins.FormationID]; !ok {
+			s, err := jury.FindOuverte(ins.FormationID)
+			if err != nil {
+				return nil, nil, err
+			}
+			sessions[ins.FormationID] = nil
+			if s != nil {
+				sessions[ins.FormationID] = &s.ID
+			}
+		}
+		if sessions[ins.FormationID] == nil {
+			sansSession = append(sansSession, ins.ID)
+		}
+	}
+
+	verrous, err := jury.FindVerrous(sansSession)
+	if err != nil {
+		return nil, nil, err
+	}
+	var verrouillees []uint
+	for _, id := range sansSession {
+		if _, ok := verrous[id]; ok {
+			verrouillees = append(verrouillees, id)
+		}
+	}
+	return sessions, verrouillees, nil
+}
+
+// procesPDF renders the minutes of a closed jury session: attendance, then the
+// admitted, waitlisted and refused candidates, best score first, and the signatures.
+func procesPDF(s *model.SessionJury, formation string, membres []model.MembreJury, lignes []model.LigneProces) []byte {
+	doc := pdf.New(fmt.Sprintf("Procès-verbal du jury n°%d", s.ID))
+	p := doc.AddPage()
+	const marge, bas = 50.0, pdf.PageHeight - 70
+	largeur := pdf.PageWidth - 2*marge
+	y := 0.0
+	place := func(hauteur float64) {
+		if y+hauteur > bas {
+			p = doc.AddPage()
+			y = 60
+		}
+	}
+
+	p.TextCentered(60, 12, true, "Faculté des Sciences et Techniques")
+	p.TextCentered(76, 11, false, "Centre de Formation Continue")
+	p.TextCentered(120, 18, true, "PROCÈS-VERBAL DE DÉLIBÉRATION DU JURY")
+	p.Line(marge, 132, pdf.PageWidth-marge, 132, 1)
+
+	y = 160
+	y = p.Paragraph(marge, y, largeur, 12, true, formation)
+	y = p.Paragraph(marge, y, largeur, 11, false, s.Intitule)
+	lieu := ""
+	if s.Lieu != "" {
+		lieu = ", " + s.Lieu
+	}
+	y = p.Paragraph(marge, y+4, largeur, 10, false,
+		fmt.Sprintf("Séance tenue le %s%s, sous la présidence de %s.", s.TenueLe.Format("02/01/2006 à 15h04"), lieu, s.President))
+
+	y += 10
+	p.Text(marge, y, 11, true, "Membres présents")
+	y += 16
+	for _, m := range membres {
+		ligne := m.Nom
+		if m.Qualite != "" {
+			ligne += " — " + m.Qualite
+		}
+		place(14)
+		y = p.Paragraph(marge+10, y, largeur-10, 10, false, ligne)
+	}
+
+	sections := []struct {
+		etat  model.EtatInscription
+		titre string
+	}{
+		{model.EtatAccepte, "Candidats admis"},
+		{model.EtatListeAttente, "Candidats en liste d'attente"},
+		{model.EtatRefuse, "Candidats non retenus"},
+	}
+	for _, sec := range sections {
+		var liste []model.LigneProces
+		for _, l := range lignes {
+			if l.Etat == sec.etat {
+				liste = append(liste, l)
+			}
+		}
+		sort.SliceStable(liste, func(i, j int) bool {
+			si, sj := liste[i].Score, liste[j].Score
+			if (si == nil) != (sj == nil) {
+				return si != nil
+			}
+			return si != nil && *si > *sj
+		})
+
+		y += 16
+		place(60)
+		p.Text(marge, y, 12, true, fmt.Sprintf("%s (%d)", sec.titre, len(liste)))
+		y += 18
+		if len(liste) == 0 {
+			p.Text(marge+10, y, 10, false, "Néant.")
+			y += 14
+			continue
+		}
+		p.Text(marge, y, 9, true, "N°")
+		p.Text(marge+30, y, 9, true, "Dossier")
+		p.Text(marge+170, y, 9, true, "Nom et prénom")
+		p.TextRight(pdf.PageWidth-marge, y, 9, true, "Score")
+		y += 5
+		p.Line(marge, y, pdf.PageWidth-marge, y, 0.5)
+		y += 13
+		for i, l := range liste {
+			place(14)
+			score := "—"
+			if l.Score != nil {
+				score = fmt.Sprintf("%.2f", *l.Score)
+			}
+			p.Text(marge, y, 9, false, strconv.Itoa(i+1))
+			p.Text(marge+30, y, 9, false, l.Reference)
+			p.TextRight(pdf.PageWidth-marge, y, 9, false, score)
+			y = p.Paragraph(marge+170, y, largeur-230, 9, false, l.NomComplet)
+			if len(l.Motifs) > 0 {
+				place(12)
+				for _, motif := range l.Motifs {
+					y = p.Paragraph(marge+180, y, largeur-240, 8, false, "Motif : "+motif)
+				}
+			}
+		}
+	}
+
+	y += 30
+	place(100)
+	cloture := ""
+	if s.ClotureLe != nil {
+		cloture = s.ClotureLe.Format("02/01/2006")
+	}
+	p.Text(marge, y, 10, false, fmt.Sprintf("Clôturé le %s. Décisions arrêtées : %d.", cloture, len(lignes)))
+	y += 40
+	p.Text(marge, y, 11, true, "Le Président du jury")
+	p.TextRight(pdf.PageWidth-marge, y, 11, true, "Les membres du jury")
+	y += 16
+	p.Text(marge, y, 10, false, s.President)
+
+	return doc.Bytes()
+}
@@ -0,0 +1,325 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// variableMessage matches a {{variable}} of a bulk message.
+var variableMessage = regexp.MustCompile(`\{\{\s*([a-zA-Z_]+)\s*\}\}`)
+
+// MessageHandler handles bulk e-mails to segments of candidates.
+type MessageHandler struct {
+	repo            *repository.MessageRepository
+	inscriptionRepo *repository.InscriptionRepository
+	programs        *client.ProgramClient
+	notifications   *client.NotificationClient
+}
+
+// NewMessageHandler creates a new MessageHandler.
+func NewMessageHandler(
+	repo *repository.MessageRepository,
+	inscriptionRepo *repository.InscriptionRepository,
+	programs *client.ProgramClient,
+	notifications *client.NotificationClient,
+) *MessageHandler {
+	return &MessageHandler{
+		repo:            repo,
+		inscriptionRepo: inscriptionRepo,
+		programs:        programs,
+		notifications:   notifications,
+	}
+}
+
+// Envoyer e-mails a message to the candidates selected by the filters of the
+// inscription listing (?formation_id=, ?etat=DOSSIER_SOUMIS, ...), rendering its
+// variables for each of them. A candidate selected through several inscriptions is
+// written to once; anonymised dossiers are left out. ?simulation=true returns the
+// recipient count and the message rendered for the first one without sending. At
+// least one filter is required, and coordinators only reach the candidates of their
+// own formations.
+func (h *MessageHandler) Envoyer(c *gin.Context) {
+	var input struct {
+		Sujet string `json:"sujet" binding:"required,max=255"`
+		Corps string `json:"corps" binding:"required,max=20000"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if inconnues := variablesInconnues(input.Sujet + input.Corps); len(inconnues) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":     "variables inconnues",
+			"inconnues": inconnues,
+			"variables": model.VariablesMessage,
+		})
+		return
+	}
+
+	filter := listFilter(c)
+	if filter.FormationID == 0 && len(filter.Etats) == 0 && filter.CandidatID == "" && filter.Reference == "" &&
+		filter.CIN == "" && filter.CNE == "" && len(filter.Reponses) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "au moins un filtre est requis pour désigner les destinataires"})
+		return
+	}
+
+	titres := map[uint]string{}
+	if c.GetString("role") == "COORDINATEUR" {
+		formations, err := h.programs.ListFormations(c.Request.Context(), c.GetString("institution_id"), c.GetHeader("Authorization"))
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		filter.FormationIDs = []uint{}
+		for _, f := range formations {
+			if f.CoordinateurID == c.GetString("user_id") {
+				titres[f.ID] = f.Titre
+				filter.FormationIDs = append(filter.FormationIDs, f.ID)
+			}
+		}
+	}
+
+	inscriptions, err := h.inscriptionRepo.FindAll(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	vus := map[string]bool{}
+	var envois []model.EnvoiMessage
+	var ignores int
+	for _, ins := range inscriptions {
+		email := strings.ToLower(strings.TrimSpace(ins.Email))
+		if ins.AnonymiseLe != nil || email == "" || vus[email] {
+			ignores++
+			continue
+		}
+		vus[email] = true
+
+		titre, ok := titres[ins.FormationID]
+		if !ok {
+			if f, err := h.programs.GetFormation(c.Request.Context(), ins.FormationID, c.GetHeader("Authorization")); err == nil {
+				titre = f.Titre
+			}
+			titres[ins.FormationID] = titre
+		}
+		variables := map[string]string{
+			"nom_complet": ins.NomComplet,
+			"email":       ins.Email,
+			"reference":   ins.NumeroDossier(),
+			"formation":   titre,
+			"etat":        string(ins.Etat),
+		}
+		envois = append(envois, model.EnvoiMessage{
+			InscriptionID: ins.ID,
+			Destinataire:  ins.Email,
+			Sujet:         rendreMessage(input.Sujet, variables),
+			Corps:         rendreMessage(input.Corps, variables),
+			Statut:        model.EnvoiEchec,
+		})
+	}
+
+	if c.Query("simulation") == "true" {
+		apercu := gin.H{"destinataires": len(envois), "ignores": ignores}
+		if len(envois) > 0 {
+			apercu["exemple"] = gin.H{
+				"destinataire": envois[0].Destinataire,
+				"sujet":        envois[0].Sujet,
+				"corps":        envois[0].Corps,
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"data": apercu, "simulation": true})
+		return
+	}
+	if len(envois) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "aucun destinataire ne correspond aux filtres"})
+		return
+	}
+
+	filtre, _ := json.Marshal(c.Request.URL.Query())
+	m := &model.MessageGroupe{
+		EtablissementID: etablissementScope(c),
+		Filtre:          model.JSON(filtre),
+		Sujet:           input.Sujet,
+		Corps:           input.Corps,
+		Destinataires:   len(envois),
+		EnvoyePar:       c.GetString("user_id"),
+		Envois:          envois,
+	}
+	if err := h.repo.Create(m); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Deliveries not handed over stay ECHEC and are retried by the tracking job; after
+	// a failure, the others are left to it rather than waiting on each timeout
+	for i := range m.Envois {
+		if err := h.transmettre(c, &m.Envois[i]); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if m.Envois[i].Statut == model.EnvoiEchec {
+			break
+		}
+	}
+
+	synthese, err := h.repo.SyntheseEnvois(m.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	m.Envois = nil
+	c.JSON(http.StatusCreated, gin.H{"data": m, "synthese": synthese, "ignores": ignores, "simulation": false})
+}
+
+// List returns the bulk messages of the caller's institution, newest first.
+func (h *MessageHandler) List(c *gin.Context) {
+	messages, err := h.repo.FindAll(etablissementScope(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": messages})
+}
+
+// Get returns a bulk message with the delivery status of every recipient.
+func (h *MessageHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	m, err := h.repo.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+		return
+	}
+	if scope := etablissementScope(c); scope != "" && m.EtablissementID != scope {
+		c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+		return
+	}
+
+	synthese := map[model.StatutEnvoi]int{}
+	for _, e := range m.Envois {
+		synthese[e.Statut]++
+	}
+	c.JSON(http.StatusOK, gin.H{"data": m, "synthese": synthese})
+}
+
+// Suivre follows up the deliveries still in progress: those not handed over yet are
+// queued again under the same idempotency key, the others get their latest status
+// from notification-service. Internal endpoint for the scheduler.
+func (h *MessageHandler) Suivre(c *gin.Context) {
+	const lot = 500
+
+	var suivis, envoyes, abandonnes int
+	var failed []uint
+	var apres uint
+	for {
+		envois, err := h.repo.FindEnvoisEnCours(apres, lot)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for i := range envois {
+			e := &envois[i]
+			if e.Statut == model.EnvoiEchec || e.NotificationID == nil {
+				err = h.transmettre(c, e)
+			} else {
+				err = h.actualiser(c, e)
+			}
+			if err != nil || e.Statut == model.EnvoiEchec {
+				failed = append(failed, e.InscriptionID)
+				continue
+			}
+			switch e.Statut {
+			case model.EnvoiEnvoye:
+				envoyes++
+			case model.EnvoiAbandonne:
+				abandonnes++
+			}
+		}
+		suivis += len(envois)
+		if len(envois) < lot {
+			break
+		}
+		apres = envois[len(envois)-1].ID
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "message deliveries followed up",
+		"suivis":                suivis,
+		"envoyes":               envoyes,
+		"abandonnes":            abandonnes,
+		"inscriptions_en_echec": failed,
+	})
+}
+
+// transmettre hands a delivery over to notification-service and records the outcome.
+// A refusal from notification-service is recorded as ECHEC; only storage errors are
+// returned.
+func (h *MessageHandler) transmettre(c *gin.Context, e *model.EnvoiMessage) error {
+	receipt, err := h.notifications.Queue(c.Request.Context(), e.CleIdempotence(), client.Notification{
+		TemplateKey: "message-groupe",
+		Recipient:   e.Destinataire,
+		Payload: map[string]interface{}{
+			"messageId":     e.MessageID,
+			"inscriptionId": e.InscriptionID,
+			"sujet":         e.Sujet,
+			"corps":         e.Corps,
+		},
+	})
+	if err != nil {
+		e.Statut, e.Erreur = model.EnvoiEchec, err.Error()
+	} else {
+		e.NotificationID, e.Statut, e.Erreur = &receipt.ID, model.StatutEnvoi(receipt.Status), ""
+	}
+	return h.repo.MarquerEnvoi(e)
+}
+
+// actualiser records the latest delivery status reported by notification-service.
+func (h *MessageHandler) actualiser(c *gin.Context, e *model.EnvoiMessage) error {
+	statut, err := h.notifications.Status(c.Request.Context(), *e.NotificationID)
+	if err != nil {
+		return err
+	}
+	if model.StatutEnvoi(statut) == e.Statut {
+		return nil
+	}
+	e.Statut = model.StatutEnvoi(statut)
+	return h.repo.MarquerEnvoi(e)
+}
+
+// variablesInconnues returns the variables of a message that are not in
+// model.VariablesMessage.
+func variablesInconnues(texte string) []string {
+	connues := map[string]bool{}
+	for _, v := range model.VariablesMessage {
+		connues[v] = true
+	}
+	var inconnues []string
+	for _, m := range variableMessage.FindAllStringSubmatch(texte, -1) {
+		if !connues[m[1]] {
+			inconnues = append(inconnues, m[1])
+			connues[m[1]] = true
+		}
+	}
+	return inconnues
+}
+
+// rendreMessage replaces the variables of a message with the recipient's values.
+func rendreMessage(texte string, variables map[string]string) string {
+	return variableMessage.ReplaceAllStringFunc(texte, func(v string) string {
+		return variables[variableMessage.FindStringSubmatch(v)[1]]
+	})
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MotifRefusHandler handles HTTP requests for the refusal reason catalogue.
+type MotifRefusHandler struct {
+	repo *repository.MotifRefusRepository
+}
+
+// NewMotifRefusHandler creates a new MotifRefusHandler.
+func NewMotifRefusHandler(repo *repository.MotifRefusRepository) *MotifRefusHandler {
+	return &MotifRefusHandler{repo: repo}
+}
+
+// List returns the reason codes available to the caller's institution (global + own).
+// Pass ?actif=true to hide deactivated codes.
+func (h *MotifRefusHandler) List(c *gin.Context) {
+	actifOnly := c.Query("actif") == "true"
+	motifs, err := h.repo.FindAvailable(c.GetString("institution_id"), actifOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": motifs})
+}
+
+// Create adds a reason code to the caller's institution catalogue.
+// A SUPER_ADMIN without institution creates a global code.
+func (h *MotifRefusHandler) Create(c *gin.Context) {
+	var input struct {
+		Code            string `json:"code" binding:"required,max=50"`
+		Libelle         string `json:"libelle" binding:"required"`
+		MessageCandidat string `json:"message_candidat"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	m := model.MotifRefus{
+		EtablissementID: c.GetString("institution_id"),
+		Code:            input.Code,
+		Libelle:         input.Libelle,
+		MessageCandidat: input.MessageCandidat,
+		Actif:           true,
+	}
+
+	err := h.repo.Create(&m)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		c.JSON(http.StatusConflict, gin.H{"error": "ce code existe déjà"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": m})
+}
+
+// Update modifies the label, candidate message or active flag of a reason code.
+// The code itself is immutable because past decisions reference it.
+func (h *MotifRefusHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	m, err := h.repo.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "motif not found"})
+		return
+	}
+
+	if m.EtablissementID != c.GetString("institution_id") && c.GetString("role") != "SUPER_ADMIN" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "ce motif appartient au catalogue d'un autre établissement"})
+		return
+	}
+
+	var input struct {
+		Libelle         *string `json:"libelle"`
+		MessageCandidat *string `json:"message_candidat"`
+		Actif           *bool   `json:"actif"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.Libelle != nil {
+		m.Libelle = *input.Libelle
+	}
+	if input.MessageCandidat != nil {
+		m.MessageCandidat = *input.MessageCandidat
+	}
+	if input.Actif != nil {
+		m.Actif = *input.Actif
+	}
+
+	if err := h.repo.Update(m); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": m})
+}
+
+// Stats returns the breakdown of refusals by reason, optionally for one ?formation_id=.
+func (h *MotifRefusHandler) Stats(c *gin.Context) {
+	var formationID uint
+	if v := c.Query("formation_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid formation_id"})
+			return
+		}
+		formationID = uint(id)
+	}
+
+	stats, err := h.repo.Stats(formationID, etablissementScope(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// OffreHandler handles HTTP requests for the confirmation of offers made to
+// accepted candidates.
+type OffreHandler struct {
+	repo          *repository.InscriptionRepository
+	notifications *client.NotificationClient
+	rappelHeures  int
+}
+
+// NewOffreHandler creates a new OffreHandler. rappelHeures is how long before the
+// deadline an unconfirmed offer is reminded.
+func NewOffreHandler(
+	repo *repository.InscriptionRepository,
+	notifications *client.NotificationClient,
+	rappelHeures int,
+) *OffreHandler {
+	return &OffreHandler{repo: repo, notifications: notifications, rappelHeures: rappelHeures}
+}
+
+// Repondre lets an accepted candidate confirm their offer, which keeps their seat
+// until enrolment, or decline it, which frees the seat (DESISTE).
+func (h *OffreHandler) Repondre(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Reponse string `json:"reponse" binding:"required,oneof=CONFIRMER DECLINER"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err := h.repo.FindByID(uint(id))
+	if err != nil || ins.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	if ins.Etat != model.EtatAccepte {
+		c.JSON(http.StatusConflict, gin.H{"error": "aucune offre en attente de réponse", "etat_actuel": ins.Etat})
+		return
+	}
+
+	switch input.Reponse {
+	case "CONFIRMER":
+		if ins.OffreConfirmeeLe == nil {
+			ok, err := h.repo.ConfirmerOffre(ins.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if !ok {
+				c.JSON(http.StatusConflict, gin.H{"error": "le délai de confirmation est dépassé"})
+				return
+			}
+		}
+	case "DECLINER":
+		ok, prise, err := h.repo.Desister(ins, ins.CandidatID, "Offre déclinée par le candidat")
+		if errors.Is(err, repository.ErrDejaPrisEnCharge) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "prise_en_charge": prise})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusConflict, gin.H{"error": "aucune offre en attente de réponse"})
+			return
+		}
+	}
+
+	ins, err = h.repo.FindByID(ins.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ins})
+}
+
+// RappelerOffres reminds the candidates whose offer expires within OFFER_REMINDER_HOURS
+// and is still unconfirmed (scheduler job). Each offer is reminded once.
+func (h *OffreHandler) RappelerOffres(c *gin.Context) {
+	offres, err := h.repo.FindOffresARappeler(time.Now().Add(time.Duration(h.rappelHeures) * time.Hour))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rappels int
+	var failed []uint
+	for i := range offres {
+		ins := &offres[i]
+		if err := h.notifier(c, ins, "offre-rappel"); err != nil {
+			log.Printf("inscription %d: offer reminder not sent: %v", ins.ID, err)
+			failed = append(failed, ins.ID)
+			continue
+		}
+		if err := h.repo.MarquerRappelOffre(ins.ID); err != nil {
+			failed = append(failed, ins.ID)
+			continue
+		}
+		rappels++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "offer reminders sent",
+		"rappels":               rappels,
+		"inscriptions_en_echec": failed,
+	})
+}
+
+// ExpirerOffres moves the offers not confirmed in time to DESISTE, freeing their
+// seats, and tells the candidates (scheduler job).
+func (h *OffreHandler) ExpirerOffres(c *gin.Context) {
+	offres, err := h.repo.FindOffresExpirees()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expirees int
+	var failed []uint
+	for i := range offres {
+		ins := &offres[i]
+		commentaire := fmt.Sprintf("Offre non confirmée avant le %s", ins.OffreExpireLe.Format("02/01/2006 15:04"))
+		ok, _, err := h.repo.ExpirerOffre(ins, commentaire)
+		if err != nil {
+			failed = append(failed, ins.ID)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		expirees++
+		if err := h.notifier(c, ins, "offre-expiree"); err != nil {
+			log.Printf("inscription %d: offer lapse notice not sent: %v", ins.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "lapsed offers processed",
+		"expirees":              expirees,
+		"inscriptions_en_echec": failed,
+	})
+}
+
+// notifier e-mails the candidate about their offer.
+func (h *OffreHandler) notifier(c *gin.Context, ins *model.Inscription, template string) error {
+	if ins.Email == "" {
+		return fmt.Errorf("aucune adresse e-mail sur l'inscription")
+	}
+
+	return h.notifications.Send(c.Request.Context(), fmt.Sprintf("%s-%d-%d", template, ins.ID, ins.OffreExpireLe.Unix()), client.Notification{
+		TemplateKey: template,
+		Recipient:   ins.Email,
+		Payload: map[string]interface{}{
+			"nomComplet":    ins.NomComplet,
+			"inscriptionId": ins.ID,
+			"reference":     ins.NumeroDossier(),
+			"formationId":   ins.FormationID,
+			"dateLimite":    ins.OffreExpireLe.Format(time.RFC3339),
+		},
+	})
+}
+
+// echeanceOffre returns the confirmation deadline of an offer made now in a formation.
+func echeanceOffre(paramRepo *repository.ParametresFormationRepository, formationID uint, delaiDefaut int) (time.Time, error) {
+	params, err := paramRepo.Get(formationID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	jours := delaiDefaut
+	if params.DelaiConfirmation != nil {
+		jours = *params.DelaiConfirmation
+	}
+	return time.Now().AddDate(0, 0, jours), nil
+}
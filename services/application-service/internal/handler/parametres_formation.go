@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/eligibility"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// ParametresFormationHandler handles HTTP requests for per-formation settings and eligibility rules.
+type ParametresFormationHandler struct {
+	repo      *repository.ParametresFormationRepository
+	regleRepo *repository.RegleEligibiliteRepository
+	programs  *client.ProgramClient
+}
+
+// NewParametresFormationHandler creates a new ParametresFormationHandler.
+func NewParametresFormationHandler(
+	repo *repository.ParametresFormationRepository,
+	regleRepo *repository.RegleEligibiliteRepository,
+	programs *client.ProgramClient,
+) *ParametresFormationHandler {
+	return &ParametresFormationHandler{repo: repo, regleRepo: regleRepo, programs: programs}
+}
+
+// Get returns the settings of a formation (defaults when never configured).
+func (h *ParametresFormationHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	p, err := h.repo.Get(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": p})
+}
+
+// Update modifies the settings of a formation.
+func (h *ParametresFormationHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		ModeEligibilite   *string                        `json:"mode_eligibilite" binding:"omitempty,oneof=SIGNALER REJETER"`
+		SeuilPresence     *int                           `json:"seuil_presence" binding:"omitempty,min=0,max=100"`
+		MoyenneValidation *float64                       `json:"moyenne_validation" binding:"omitempty,min=0,max=20"`
+		Capacite          *int                           `json:"capacite" binding:"omitempty,min=0"`
+		DelaiConfirmation *int                           `json:"delai_confirmation" binding:"omitempty,min=1,max=90"`
+		DelaisSla         *map[model.EtatInscription]int `json:"delais_sla"`
+		EmailCoordinateur *string                        `json:"email_coordinateur" binding:"omitempty,email"`
+		EmailEscalade     *string                        `json:"email_escalade" binding:"omitempty,email"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// An SLA applies to states a dossier leaves, for at most a year
+	if input.DelaisSla != nil {
+		for etat, heures := range *input.DelaisSla {
+			if _, ok := model.ValidTransitions[etat]; !ok {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("delais_sla: l'état %q n'admet pas de délai", etat)})
+				return
+			}
+			if heures < 1 || heures > 8760 {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("delais_sla: le délai de %s doit être compris entre 1 et 8760 heures", etat)})
+				return
+			}
+		}
+	}
+
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	p, err := h.repo.Get(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.ModeEligibilite != nil {
+		p.ModeEligibilite = *input.ModeEligibilite
+	}
+	if input.SeuilPresence != nil {
+		p.SeuilPresence = input.SeuilPresence
+	}
+	if input.MoyenneValidation != nil {
+		p.MoyenneValidation = *input.MoyenneValidation
+	}
+	if input.Capacite != nil {
+		p.Capacite = input.Capacite
+	}
+	if input.DelaiConfirmation != nil {
+		p.DelaiConfirmation = input.DelaiConfirmation
+	}
+	if input.DelaisSla != nil {
+		// An empty map falls back to DECISION_SLA_HOURS
+		p.DelaisSla = nil
+		if len(*input.DelaisSla) > 0 {
+			p.DelaisSla, _ = json.Marshal(*input.DelaisSla)
+		}
+	}
+	if input.EmailCoordinateur != nil {
+		p.EmailCoordinateur = *input.EmailCoordinateur
+	}
+	if input.EmailEscalade != nil {
+		p.EmailEscalade = *input.EmailEscalade
+	}
+	p.ModifiePar = c.GetString("user_id")
+
+	if err := h.repo.Save(p); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": p})
+}
+
+// ListRegles returns the eligibility rules of a formation.
+func (h *ParametresFormationHandler) ListRegles(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	regles, err := h.regleRepo.FindByFormationID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": regles})
+}
+
+// PutRegles replaces the whole rule set of a formation.
+func (h *ParametresFormationHandler) PutRegles(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Regles []struct {
+			Code      string          `json:"code" binding:"required,max=50"`
+			Libelle   string          `json:"libelle" binding:"required"`
+			Champ     string          `json:"champ" binding:"required"`
+			Operateur string          `json:"operateur" binding:"required"`
+			Valeur    json.RawMessage `json:"valeur"`
+		} `json:"regles" binding:"dive"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	regles := make([]model.RegleEligibilite, 0, len(input.Regles))
+	for i, r := range input.Regles {
+		regle := model.RegleEligibilite{
+			FormationID: uint(id),
+			Code:        r.Code,
+			Libelle:     r.Libelle,
+			Champ:       r.Champ,
+			Operateur:   r.Operateur,
+			Valeur:      model.JSON(r.Valeur),
+			Ordre:       i,
+		}
+		if err := eligibility.Check(regle); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		regles = append(regles, regle)
+	}
+
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	if err := h.regleRepo.Replace(uint(id), regles); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": regles})
+}
@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/pdf"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// PublicationHandler handles the publication of admission results lists.
+type PublicationHandler struct {
+	repo     *repository.PublicationRepository
+	programs *client.ProgramClient
+}
+
+// NewPublicationHandler creates a new PublicationHandler.
+func NewPublicationHandler(repo *repository.PublicationRepository, programs *client.ProgramClient) *PublicationHandler {
+	return &PublicationHandler{repo: repo, programs: programs}
+}
+
+// Publier freezes the current admitted and waitlisted candidates of a formation as
+// the next version of its public list. Candidates appear by dossier number and
+// initials only: admitted ones in dossier order, waitlisted ones by rank. Correcting
+// a published list requires a public rectificatif.
+func (h *PublicationHandler) Publier(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Rectificatif string `json:"rectificatif" binding:"max=2000"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	formation := formationInScope(c, h.programs, uint(id))
+	if formation == nil {
+		return
+	}
+
+	publiees, err := h.repo.FindByFormation(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(publiees) > 0 && input.Rectificatif == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "une liste a déjà été publiée : un rectificatif est requis pour la corriger",
+			"version": publiees[0].Version,
+		})
+		return
+	}
+
+	inscriptions, err := h.repo.FindAdmissions(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(inscriptions) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "aucun candidat admis ou en liste d'attente"})
+		return
+	}
+
+	var admis, attente []model.LignePublication
+	for _, ins := range inscriptions {
+		ligne := model.LignePublication{Reference: ins.NumeroDossier(), Etat: ins.Etat}
+		if ins.AnonymiseLe == nil {
+			ligne.Initiales = model.Initiales(ins.NomComplet)
+		}
+		if ins.Etat == model.EtatAccepte {
+			admis = append(admis, ligne)
+			continue
+		}
+		position := len(attente) + 1
+		ligne.Position = &position
+		attente = append(attente, ligne)
+	}
+	// The admitted list must not reveal the jury ranking
+	sort.Slice(admis, func(i, j int) bool { return admis[i].Reference < admis[j].Reference })
+
+	lignes, err := json.Marshal(append(admis, attente...))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	p := &model.PublicationResultats{
+		FormationID:     uint(id),
+		EtablissementID: formation.EtablissementID,
+		Formation:       formation.Titre,
+		Rectificatif:    input.Rectificatif,
+		Admis:           len(admis),
+		ListeAttente:    len(attente),
+		Lignes:          model.JSON(lignes),
+		PublieePar:      c.GetString("user_id"),
+		PublieeLe:       time.Now(),
+	}
+	if err := h.repo.Publier(p); err != nil {
+		if errors.Is(err, repository.ErrPublicationConcurrente) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": p})
+}
+
+// List returns the published versions of a formation's list, newest first.
+func (h *PublicationHandler) List(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	publications, err := h.repo.FindByFormation(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": publications})
+}
+
+// Consulter returns the latest published list of a formation, or the version given
+// by ?version=. Public endpoint for the faculty website.
+func (h *PublicationHandler) Consulter(c *gin.Context) {
+	p := h.publication(c)
+	if p == nil {
+		return
+	}
+
+	var lignes []model.LignePublication
+	if err := json.Unmarshal(p.Lignes, &lignes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"formation_id":  p.FormationID,
+		"formation":     p.Formation,
+		"version":       p.Version,
+		"publiee_le":    p.PublieeLe,
+		"rectificatif":  p.Rectificatif,
+		"admis":         p.Admis,
+		"liste_attente": p.ListeAttente,
+		"lignes":        lignes,
+	}})
+}
+
+// PDF returns a published list as a PDF for display. Public endpoint.
+func (h *PublicationHandler) PDF(c *gin.Context) {
+	p := h.publication(c)
+	if p == nil {
+		return
+	}
+
+	var lignes []model.LignePublication
+	if err := json.Unmarshal(p.Lignes, &lignes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="resultats-%d-v%d.pdf"`, p.FormationID, p.Version))
+	c.Data(http.StatusOK, "application/pdf", publicationPDF(p, lignes))
+}
+
+// publication loads the published list of the :formation_id parameter in the
+// version of ?version=, the latest by default. On failure it writes the response and
+// returns nil.
+func (h *PublicationHandler) publication(c *gin.Context) *model.PublicationResultats {
+	id, err := strconv.ParseUint(c.Param("formation_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil
+	}
+	version := 0
+	if v := c.Query("version"); v != "" {
+		if version, err = strconv.Atoi(v); err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+			return nil
+		}
+	}
+
+	p, err := h.repo.FindVersion(uint(id), version)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "publication not found"})
+		return nil
+	}
+	return p
+}
+
+// publicationPDF renders a published list: the admitted candidates, then the
+// waiting list in rank order.
+func publicationPDF(p *model.PublicationResultats, lignes []model.LignePublication) []byte {
+	doc := pdf.New(fmt.Sprintf("Résultats d'admission — %s (version %d)", p.Formation, p.Version))
+	page := doc.AddPage()
+	const marge, bas = 50.0, pdf.PageHeight - 70
+	largeur := pdf.PageWidth - 2*marge
+	y := 0.0
+	place := func(hauteur float64) {
+		if y+hauteur > bas {
+			page = doc.AddPage()
+			y = 60
+		}
+	}
+
+	page.TextCentered(60, 12, true, "Faculté des Sciences et Techniques")
+	page.TextCentered(76, 11, false, "Centre de Formation Continue")
+	page.TextCentered(120, 18, true, "RÉSULTATS D'ADMISSION")
+	page.Line(marge, 132, pdf.PageWidth-marge, 132, 1)
+
+	y = 160
+	y = page.Paragraph(marge, y, largeur, 13, true, p.Formation)
+	y = page.Paragraph(marge, y+2, largeur, 10, false,
+		fmt.Sprintf("Liste publiée le %s — version %d", p.PublieeLe.Format("02/01/2006 à 15h04"), p.Version))
+	if p.Rectificatif != "" {
+		y = page.Paragraph(marge, y+4, largeur, 10, true, "Rectificatif : "+p.Rectificatif)
+	}
+
+	sections := []struct {
+		etat  model.EtatInscription
+		titre string
+	}{
+		{model.EtatAccepte, "Candidats admis"},
+		{model.EtatListeAttente, "Liste d'attente"},
+	}
+	for _, sec := range sections {
+		var liste []model.LignePublication
+		for _, l := range lignes {
+			if l.Etat == sec.etat {
+				liste = append(liste, l)
+			}
+		}
+
+		y += 20
+		place(60)
+		page.Text(marge, y, 12, true, fmt.Sprintf("%s (%d)", sec.titre, len(liste)))
+		y += 18
+		if len(liste) == 0 {
+			page.Text(marge+10, y, 10, false, "Néant.")
+			y += 14
+			continue
+		}
+		page.Text(marge, y, 9, true, "N°")
+		page.Text(marge+40, y, 9, true, "Dossier")
+		page.Text(marge+200, y, 9, true, "Initiales")
+		y += 5
+		page.Line(marge, y, pdf.PageWidth-marge, y, 0.5)
+		y += 13
+		for i, l := range liste {
+			place(14)
+			rang := i + 1
+			if l.Position != nil {
+				rang = *l.Position
+			}
+			page.Text(marge, y, 9, false, strconv.Itoa(rang))
+			page.Text(marge+40, y, 9, false, l.Reference)
+			page.Text(marge+200, y, 9, false, l.Initiales)
+			y += 14
+		}
+	}
+
+	y += 24
+	place(30)
+	page.Paragraph(marge, y, largeur, 8, false,
+		"Les candidats admis doivent confirmer leur inscription dans le délai indiqué dans leur espace candidat. "+
+			"Seule la dernière version publiée fait foi.")
+
+	return doc.Bytes()
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Search runs a ranked full-text search over candidates' name, e-mail, phone and notes.
+// It accepts the same filters as the listing (candidat_id, formation_id, reponses[...])
+// and returns each hit with its score and highlighted fields.
+func (h *InscriptionHandler) Search(c *gin.Context) {
+	terme := strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(terme) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "le paramètre q doit contenir au moins 2 caractères"})
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	resultats, err := h.repo.Search(terme, listFilter(c), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": resultats})
+}
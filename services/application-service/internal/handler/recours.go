@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// RecoursHandler handles HTTP requests for appeals against refusals.
+type RecoursHandler struct {
+	repo            *repository.RecoursRepository
+	inscriptionRepo *repository.InscriptionRepository
+	documents       *client.DocumentClient
+	delaiJours      int
+}
+
+// NewRecoursHandler creates a new RecoursHandler.
+// delaiJours is the number of days after a refusal during which an appeal can be filed.
+func NewRecoursHandler(repo *repository.RecoursRepository, inscriptionRepo *repository.InscriptionRepository, documents *client.DocumentClient, delaiJours int) *RecoursHandler {
+	return &RecoursHandler{repo: repo, inscriptionRepo: inscriptionRepo, documents: documents, delaiJours: delaiJours}
+}
+
+// List returns all appeals, optionally filtered by ?statut=.
+func (h *RecoursHandler) List(c *gin.Context) {
+	recours, err := h.repo.FindAll(c.Query("statut"), etablissementScope(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": recours})
+}
+
+// Get returns a single appeal by ID.
+func (h *RecoursHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	rec, err := h.repo.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recours not found"})
+		return
+	}
+
+	if c.GetString("role") == "CANDIDAT" && rec.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "accès refusé"})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(rec.InscriptionID)
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recours not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rec})
+}
+
+// ListByInscription returns the appeals filed for an inscription.
+func (h *RecoursHandler) ListByInscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	if c.GetString("role") == "CANDIDAT" && ins.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "accès refusé"})
+		return
+	}
+
+	recours, err := h.repo.FindByInscriptionID(ins.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": recours})
+}
+
+// Create files an appeal against a refused inscription.
+// Only the owning candidate may appeal, once per refusal, within the configured window.
+// Supporting documents must be documents of the inscription in document-service.
+func (h *RecoursHandler) Create(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Justification string `json:"justification" binding:"required"`
+		DocumentIDs   []uint `json:"document_ids"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	if ins.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "seul le candidat concerné peut déposer un recours"})
+		return
+	}
+
+	if ins.Etat != model.EtatRefuse {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "un recours n'est possible que sur une inscription refusée",
+			"etat_actuel": ins.Etat,
+		})
+		return
+	}
+
+	refus, err := h.inscriptionRepo.FindLastDecision(ins.ID, model.EtatRefuse)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "aucune décision de refus trouvée"})
+		return
+	}
+
+	dateLimite := refus.CreatedAt.AddDate(0, 0, h.delaiJours)
+	if time.Now().After(dateLimite) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "délai de recours expiré",
+			"date_limite": dateLimite,
+		})
+		return
+	}
+
+	existing, err := h.repo.CountSince(ins.ID, refus)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": repository.ErrRecoursDepose.Error()})
+		return
+	}
+
+	// Supporting documents must have been uploaded for this inscription
+	if len(input.DocumentIDs) > 0 {
+		docs, err := h.documents.ListInscription(c.Request.Context(), ins.ID)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		if inconnus := documentsInconnus(input.DocumentIDs, docs); len(inconnus) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":    "documents inconnus ou non rattachés à cette inscription",
+				"inconnus": inconnus,
+			})
+			return
+		}
+	}
+
+	rec := model.Recours{
+		InscriptionID: ins.ID,
+		CandidatID:    ins.CandidatID,
+		Justification: input.Justification,
+		Statut:        model.RecoursEnAttente,
+	}
+	for _, docID := range input.DocumentIDs {
+		rec.Documents = append(rec.Documents, model.RecoursDocument{DocumentID: docID})
+	}
+
+	err = h.repo.Create(&rec, refus)
+	if errors.Is(err, repository.ErrRecoursDepose) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": rec})
+}
+
+// Decide records the review outcome of a pending appeal.
+// An upheld appeal reopens the inscription: REFUSE → EN_VALIDATION, with decision and
+// history, written together with the outcome.
+func (h *RecoursHandler) Decide(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Statut  string `json:"statut" binding:"required,oneof=ACCEPTE REJETE"`
+		Reponse string `json:"reponse" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rec, err := h.repo.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "recours not found"})
+		return
+	}
+
+	if rec.Statut != model.RecoursEnAttente {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":         "ce recours a déjà été examiné",
+			"statut_actuel": rec.Statut,
+		})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(rec.InscriptionID)
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	statut := model.StatutRecours(input.Statut)
+	if statut == model.RecoursAccepte && ins.Etat != model.EtatRefuse {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "l'inscription n'est plus à l'état REFUSE",
+			"etat_actuel": ins.Etat,
+		})
+		return
+	}
+
+	examinePar := c.GetString("user_id")
+	now := time.Now()
+	rec.Statut = statut
+	rec.Reponse = input.Reponse
+	rec.ExaminePar = examinePar
+	rec.DateDecision = &now
+
+	var decision *model.Decision
+	var historique *model.InscriptionHistorique
+	if statut == model.RecoursAccepte {
+		decision = &model.Decision{
+			InscriptionID: ins.ID,
+			DecidePar:     examinePar,
+			Etat:          model.EtatEnValidation,
+			Commentaire:   "Recours accepté : " + input.Reponse,
+		}
+		historique = &model.InscriptionHistorique{
+			InscriptionID: ins.ID,
+			AncienEtat:    ins.Etat,
+			NouvelEtat:    model.EtatEnValidation,
+			ModifiePar:    examinePar,
+			Commentaire:   decision.Commentaire,
+		}
+	}
+
+	err = h.repo.Examiner(rec, ins, decision, historique)
+	if errors.Is(err, repository.ErrRecoursExamine) || errors.Is(err, repository.ErrEtatModifie) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rec})
+}
+
+// documentsInconnus returns the requested document IDs that are not among docs.
+func documentsInconnus(ids []uint, docs []client.Document) []uint {
+	connus := make(map[uint]bool, len(docs))
+	for _, d := range docs {
+		connus[d.ID] = true
+	}
+	var inconnus []uint
+	for _, id := range ids {
+		if !connus[id] {
+			inconnus = append(inconnus, id)
+		}
+	}
+	return inconnus
+}
@@ -0,0 +1,678 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/pdf"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ResultatHandler handles HTTP requests for modules, grades, deliberation and
+// completion certificates.
+type ResultatHandler struct {
+	repo            *repository.ResultatRepository
+	inscriptionRepo *repository.InscriptionRepository
+	seanceRepo      *repository.SeanceRepository
+	paramRepo       *repository.ParametresFormationRepository
+	programs        *client.ProgramClient
+	seuilDefaut     int
+}
+
+// NewResultatHandler creates a new ResultatHandler. seuilDefaut is the attendance
+// threshold (percent) of formations that do not set their own.
+func NewResultatHandler(
+	repo *repository.ResultatRepository,
+	inscriptionRepo *repository.InscriptionRepository,
+	seanceRepo *repository.SeanceRepository,
+	paramRepo *repository.ParametresFormationRepository,
+	programs *client.ProgramClient,
+	seuilDefaut int,
+) *ResultatHandler {
+	return &ResultatHandler{
+		repo:            repo,
+		inscriptionRepo: inscriptionRepo,
+		seanceRepo:      seanceRepo,
+		paramRepo:       paramRepo,
+		programs:        programs,
+		seuilDefaut:     seuilDefaut,
+	}
+}
+
+// moduleInput is the body of module creation and update.
+type moduleInput struct {
+	Code             string   `json:"code" binding:"required,max=50"`
+	Intitule         string   `json:"intitule" binding:"required,max=255"`
+	Coefficient      *float64 `json:"coefficient" binding:"omitempty,gt=0"`
+	NoteEliminatoire *float64 `json:"note_eliminatoire" binding:"omitempty,min=0,max=20"`
+	Ordre            int      `json:"ordre"`
+}
+
+// apply copies the input onto a module.
+func (in moduleInput) apply(m *model.ModuleFormation) {
+	m.Code = in.Code
+	m.Intitule = in.Intitule
+	m.Coefficient = 1
+	if in.Coefficient != nil {
+		m.Coefficient = *in.Coefficient
+	}
+	m.NoteEliminatoire = in.NoteEliminatoire
+	m.Ordre = in.Ordre
+}
+
+// ListModules returns the graded modules of a formation.
+func (h *ResultatHandler) ListModules(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	modules, err := h.repo.FindModules(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": modules})
+}
+
+// CreateModule adds a graded module to a formation.
+func (h *ResultatHandler) CreateModule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input moduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	formation := formationInScope(c, h.programs, uint(id))
+	if formation == nil {
+		return
+	}
+
+	m := model.ModuleFormation{FormationID: uint(id), EtablissementID: formation.EtablissementID}
+	input.apply(&m)
+
+	if err := h.repo.CreateModule(&m); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "un module porte déjà ce code pour cette formation"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": m})
+}
+
+// UpdateModule changes a module's code, title, coefficient or eliminatory grade.
+func (h *ResultatHandler) UpdateModule(c *gin.Context) {
+	m := h.moduleInScope(c)
+	if m == nil {
+		return
+	}
+
+	var input moduleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.apply(m)
+
+	if err := h.repo.UpdateModule(m); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			c.JSON(http.StatusConflict, gin.H{"error": "un module porte déjà ce code pour cette formation"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": m})
+}
+
+// DeleteModule removes a module that has not been graded yet.
+func (h *ResultatHandler) DeleteModule(c *gin.Context) {
+	m := h.moduleInScope(c)
+	if m == nil {
+		return
+	}
+
+	if err := h.repo.DeleteModule(m.ID); err != nil {
+		if errors.Is(err, repository.ErrModuleNote) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "module supprimé"})
+}
+
+// Notes returns the grade sheet of a module: every enrolled or deliberated learner
+// of the formation, with the grade recorded so far (null when not yet graded).
+func (h *ResultatHandler) Notes(c *gin.Context) {
+	m := h.moduleInScope(c)
+	if m == nil {
+		return
+	}
+
+	inscriptions, err := h.apprenants(m.FormationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	notes, err := h.repo.FindNotesByModule(m.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byInscription := make(map[uint]float64, len(notes))
+	for _, n := range notes {
+		byInscription[n.InscriptionID] = n.Valeur
+	}
+
+	type ligne struct {
+		InscriptionID uint                  `json:"inscription_id"`
+		NomComplet    string                `json:"nom_complet"`
+		Etat          model.EtatInscription `json:"etat"`
+		Note          *float64              `json:"note"`
+	}
+	feuille := make([]ligne, 0, len(inscriptions))
+	for _, ins := range inscriptions {
+		l := ligne{InscriptionID: ins.ID, NomComplet: ins.NomComplet, Etat: ins.Etat}
+		if v, ok := byInscription[ins.ID]; ok {
+			l.Note = &v
+		}
+		feuille = append(feuille, l)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": feuille, "module": m})
+}
+
+// SaveNotes records or corrects the grades of enrolled learners in a module.
+// Grades are frozen once the learner's results have been deliberated.
+func (h *ResultatHandler) SaveNotes(c *gin.Context) {
+	m := h.moduleInScope(c)
+	if m == nil {
+		return
+	}
+
+	var input struct {
+		Notes []struct {
+			InscriptionID uint     `json:"inscription_id" binding:"required"`
+			Valeur        *float64 `json:"valeur" binding:"required,min=0,max=20"`
+		} `json:"notes" binding:"required,min=1,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inscriptions, err := h.apprenants(m.FormationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	etats := make(map[uint]model.EtatInscription, len(inscriptions))
+	for _, ins := range inscriptions {
+		etats[ins.ID] = ins.Etat
+	}
+
+	var inconnues, deliberees []uint
+	notes := make([]model.Note, 0, len(input.Notes))
+	for _, n := range input.Notes {
+		switch etats[n.InscriptionID] {
+		case model.EtatInscrit:
+			notes = append(notes, model.Note{
+				ModuleID:      m.ID,
+				InscriptionID: n.InscriptionID,
+				Valeur:        *n.Valeur,
+				SaisiePar:     c.GetString("user_id"),
+			})
+		case "":
+			inconnues = append(inconnues, n.InscriptionID)
+		default:
+			deliberees = append(deliberees, n.InscriptionID)
+		}
+	}
+	if len(inconnues) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":        "inscriptions non inscrites à cette formation",
+			"inscriptions": inconnues,
+		})
+		return
+	}
+	if len(deliberees) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        "les résultats de ces inscriptions sont déjà délibérés",
+			"inscriptions": deliberees,
+		})
+		return
+	}
+
+	if err := h.repo.SaveNotes(notes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": notes})
+}
+
+// NotesInscription returns the grades of one learner per module, with the
+// weighted average once every module is graded (owner or staff).
+func (h *ResultatHandler) NotesInscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	if c.GetString("role") == "CANDIDAT" && ins.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "accès refusé"})
+		return
+	}
+
+	modules, err := h.repo.FindModules(ins.FormationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	notes, err := h.repo.FindNotesByInscription(ins.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byModule := make(map[uint]float64, len(notes))
+	for _, n := range notes {
+		byModule[n.ModuleID] = n.Valeur
+	}
+
+	type ligne struct {
+		ModuleID    uint     `json:"module_id"`
+		Code        string   `json:"code"`
+		Intitule    string   `json:"intitule"`
+		Coefficient float64  `json:"coefficient"`
+		Note        *float64 `json:"note"`
+	}
+	lignes := make([]ligne, 0, len(modules))
+	for _, m := range modules {
+		l := ligne{ModuleID: m.ID, Code: m.Code, Intitule: m.Intitule, Coefficient: m.Coefficient}
+		if v, ok := byModule[m.ID]; ok {
+			l.Note = &v
+		}
+		lignes = append(lignes, l)
+	}
+
+	moyenne, _ := moyennePonderee(modules, byModule)
+	c.JSON(http.StatusOK, gin.H{"data": lignes, "moyenne": moyenne, "etat": ins.Etat})
+}
+
+// Deliberer applies the validation rules to every enrolled learner of a formation.
+// A learner validates when every module is graded, the weighted average reaches the
+// formation's MoyenneValidation, no grade is below its module's eliminatory grade and
+// the attendance rate, when known, reaches the threshold. Learners with ungraded
+// modules are left INSCRIT. ?simulation=true returns the outcome without applying it.
+func (h *ResultatHandler) Deliberer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	params, err := h.paramRepo.Get(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	seuil := h.seuilDefaut
+	if params.SeuilPresence != nil {
+		seuil = *params.SeuilPresence
+	}
+
+	modules, err := h.repo.FindModules(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(modules) == 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "aucun module défini pour cette formation"})
+		return
+	}
+
+	notes, err := h.repo.FindNotesByFormation(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byInscription := make(map[uint]map[uint]float64)
+	for _, n := range notes {
+		if byInscription[n.InscriptionID] == nil {
+			byInscription[n.InscriptionID] = make(map[uint]float64)
+		}
+		byInscription[n.InscriptionID][n.ModuleID] = n.Valeur
+	}
+
+	// Taux lists exactly the learners still INSCRIT in the formation
+	taux, err := h.seanceRepo.Taux(uint(id), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resultats := make([]model.ResultatDeliberation, 0, len(taux))
+	for _, t := range taux {
+		r := deliberer(modules, byInscription[t.InscriptionID], t.Taux, seuil, params.MoyenneValidation)
+		r.InscriptionID = t.InscriptionID
+		r.NomComplet = t.NomComplet
+		resultats = append(resultats, r)
+	}
+
+	simulation := c.Query("simulation") == "true"
+	if !simulation {
+		for i := range resultats {
+			if resultats[i].Decision == "" {
+				continue
+			}
+			if err := h.appliquer(c, &resultats[i]); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":               resultats,
+		"simulation":         simulation,
+		"moyenne_validation": params.MoyenneValidation,
+		"seuil_presence":     seuil,
+	})
+}
+
+// Attestation returns the completion certificate of a validated learner as a PDF
+// (owner or staff).
+func (h *ResultatHandler) Attestation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	if c.GetString("role") == "CANDIDAT" && ins.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "accès refusé"})
+		return
+	}
+
+	a, err := h.repo.FindAttestation(ins.ID)
+	if ins.Etat != model.EtatValide || errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusConflict, gin.H{"error": "aucune attestation : la formation n'a pas été validée"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	formation, err := h.programs.GetFormation(c.Request.Context(), ins.FormationID, c.GetHeader("Authorization"))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="attestation-%s.pdf"`, a.Numero))
+	c.Data(http.StatusOK, "application/pdf", attestationPDF(ins, formation, a))
+}
+
+// Verifier lets anyone check a certificate from the code printed on it.
+// Public endpoint: only what the certificate itself shows is returned.
+func (h *ResultatHandler) Verifier(c *gin.Context) {
+	a, err := h.repo.FindAttestationByCode(strings.ToLower(c.Param("code")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attestation not found"})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(a.InscriptionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attestation not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{
+		"numero":       a.Numero,
+		"nom_complet":  ins.NomComplet,
+		"formation_id": ins.FormationID,
+		"moyenne":      a.Moyenne,
+		"delivree_le":  a.CreatedAt,
+		"valide":       ins.Etat == model.EtatValide,
+	}})
+}
+
+// appliquer records the deliberation outcome of one learner: state, decision,
+// history and, for a validated learner, the completion certificate, all or nothing.
+func (h *ResultatHandler) appliquer(c *gin.Context, r *model.ResultatDeliberation) error {
+	ins, err := h.inscriptionRepo.FindByID(r.InscriptionID)
+	if err != nil {
+		return err
+	}
+	if ins.Etat != model.EtatInscrit {
+		// Deliberated concurrently, or abandoned in the meantime
+		r.Decision = ""
+		return nil
+	}
+
+	userID := c.GetString("user_id")
+	decision := model.Decision{
+		InscriptionID: ins.ID,
+		DecidePar:     userID,
+		Etat:          r.Decision,
+		Commentaire:   strings.Join(r.Motifs, " ; "),
+	}
+	historique := model.InscriptionHistorique{
+		InscriptionID: ins.ID,
+		AncienEtat:    model.EtatInscrit,
+		NouvelEtat:    r.Decision,
+		ModifiePar:    userID,
+	}
+
+	var a *model.Attestation
+	if r.Decision == model.EtatValide {
+		code := make([]byte, 16)
+		if _, err := rand.Read(code); err != nil {
+			return err
+		}
+		a = &model.Attestation{
+			InscriptionID:    ins.ID,
+			CodeVerification: hex.EncodeToString(code),
+			Moyenne:          r.Moyenne,
+			DelivreePar:      userID,
+		}
+	}
+
+	err = h.repo.Deliberer(ins, &decision, &historique, a, func(id uint) string {
+		return fmt.Sprintf("ATT-%d-%06d", time.Now().Year(), id)
+	})
+	if errors.Is(err, repository.ErrEtatModifie) {
+		r.Decision = ""
+		return nil
+	}
+	return err
+}
+
+// moduleInScope loads the module named by the :id parameter and checks that staff
+// callers belong to its institution. On failure it writes the response and returns nil.
+func (h *ResultatHandler) moduleInScope(c *gin.Context) *model.ModuleFormation {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil
+	}
+
+	m, err := h.repo.FindModuleByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "module not found"})
+		return nil
+	}
+	if scope := etablissementScope(c); scope != "" && m.EtablissementID != scope {
+		c.JSON(http.StatusNotFound, gin.H{"error": "module not found"})
+		return nil
+	}
+	return m
+}
+
+// apprenants returns the learners of a formation that appear on grade sheets:
+// enrolled ones and those whose results were deliberated.
+func (h *ResultatHandler) apprenants(formationID uint) ([]model.Inscription, error) {
+	inscriptions, err := h.inscriptionRepo.FindAll(repository.InscriptionFilter{FormationID: formationID})
+	if err != nil {
+		return nil, err
+	}
+	apprenants := inscriptions[:0]
+	for _, ins := range inscriptions {
+		switch ins.Etat {
+		case model.EtatInscrit, model.EtatValide, model.EtatNonValide:
+			apprenants = append(apprenants, ins)
+		}
+	}
+	return apprenants, nil
+}
+
+// deliberer evaluates the validation rules for one learner. notes maps module IDs
+// to grades; taux is the attendance rate, nil when no attendance was recorded.
+func deliberer(modules []model.ModuleFormation, notes map[uint]float64, taux *float64, seuil int, moyenneRequise float64) model.ResultatDeliberation {
+	r := model.ResultatDeliberation{TauxPresence: taux}
+
+	for _, m := range modules {
+		if _, ok := notes[m.ID]; !ok {
+			r.ModulesManquants = append(r.ModulesManquants, m.Code)
+		}
+	}
+	if len(r.ModulesManquants) > 0 {
+		return r
+	}
+
+	moyenne, _ := moyennePonderee(modules, notes)
+	r.Moyenne = moyenne
+
+	if *moyenne < moyenneRequise {
+		r.Motifs = append(r.Motifs, fmt.Sprintf("moyenne %.2f inférieure à %.2f", *moyenne, moyenneRequise))
+	}
+	for _, m := range modules {
+		if m.NoteEliminatoire != nil && notes[m.ID] < *m.NoteEliminatoire {
+			r.Motifs = append(r.Motifs, fmt.Sprintf("note éliminatoire en %s (%.2f < %.2f)",
+				m.Code, notes[m.ID], *m.NoteEliminatoire))
+		}
+	}
+	if taux != nil && *taux < float64(seuil) {
+		r.Motifs = append(r.Motifs, fmt.Sprintf("assiduité %.1f %% inférieure au seuil de %d %%", *taux, seuil))
+	}
+
+	r.Decision = model.EtatValide
+	if len(r.Motifs) > 0 {
+		r.Decision = model.EtatNonValide
+	}
+	return r
+}
+
+// moyennePonderee returns the coefficient-weighted average of the grades, rounded
+// to two decimals, and whether every module is graded. The average is nil until then.
+func moyennePonderee(modules []model.ModuleFormation, notes map[uint]float64) (*float64, bool) {
+	if len(modules) == 0 {
+		return nil, false
+	}
+	var somme, coefficients float64
+	for _, m := range modules {
+		v, ok := notes[m.ID]
+		if !ok {
+			return nil, false
+		}
+		somme += v * m.Coefficient
+		coefficients += m.Coefficient
+	}
+	moyenne := math.Round(somme/coefficients*100) / 100
+	return &moyenne, true
+}
+
+// attestationPDF renders the completion certificate.
+func attestationPDF(ins *model.Inscription, formation *client.Formation, a *model.Attestation) []byte {
+	doc := pdf.New("Attestation de réussite " + a.Numero)
+	p := doc.AddPage()
+	largeur := pdf.PageWidth - 180
+
+	p.Rect(40, 40, pdf.PageWidth-80, pdf.PageHeight-80, 2)
+	p.Rect(46, 46, pdf.PageWidth-92, pdf.PageHeight-92, 0.5)
+
+	p.TextCentered(120, 12, true, "Faculté des Sciences et Techniques")
+	p.TextCentered(138, 11, false, "Centre de Formation Continue")
+	p.TextCentered(230, 26, true, "ATTESTATION DE RÉUSSITE")
+	p.Line(180, 245, pdf.PageWidth-180, 245, 1)
+	p.TextRight(pdf.PageWidth-80, 290, 10, false, "N° "+a.Numero)
+	p.TextRight(pdf.PageWidth-80, 304, 9, false, "Dossier "+ins.NumeroDossier())
+
+	y := 360.0
+	p.TextCentered(y, 13, false, "Nous soussignés attestons que")
+	y += 40
+	for _, ligne := range pdf.Wrap(ins.NomComplet, largeur, 18, true) {
+		p.TextCentered(y, 18, true, ligne)
+		y += 25
+	}
+	y += 15
+	p.TextCentered(y, 13, false, "a suivi avec succès et validé la formation")
+	y += 30
+	for _, ligne := range pdf.Wrap(formation.Titre, largeur, 15, true) {
+		p.TextCentered(y, 15, true, ligne)
+		y += 21
+	}
+	if a.Moyenne != nil {
+		y += 15
+		p.TextCentered(y, 13, false, fmt.Sprintf("avec une moyenne générale de %.2f / 20.", *a.Moyenne))
+	}
+
+	y += 70
+	p.Text(90, y, 11, false, "Délivrée le "+a.CreatedAt.Format("02/01/2006"))
+	p.TextRight(pdf.PageWidth-90, y, 11, true, "Le Directeur")
+
+	p.Line(80, pdf.PageHeight-120, pdf.PageWidth-80, pdf.PageHeight-120, 0.5)
+	p.TextCentered(pdf.PageHeight-100, 9, false, "Code de vérification : "+a.CodeVerification)
+	p.TextCentered(pdf.PageHeight-86, 8, false, "Authenticité vérifiable auprès du service des inscriptions : GET /attestations/<code>")
+
+	return doc.Bytes()
+}
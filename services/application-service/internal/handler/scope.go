@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// etablissementScope returns the institution a staff caller is restricted to,
+// or "" when the caller has global access (SUPER_ADMIN, SYSTEM) or is a candidate.
+// middleware.RequireInstitution guarantees staff tokens carry an institution.
+func etablissementScope(c *gin.Context) string {
+	switch c.GetString("role") {
+	case "SUPER_ADMIN", "SYSTEM", "CANDIDAT":
+		return ""
+	}
+	return c.GetString("institution_id")
+}
+
+// inScope reports whether the caller may see or act on the inscription.
+func inScope(c *gin.Context, ins *model.Inscription) bool {
+	scope := etablissementScope(c)
+	return scope == "" || ins.EtablissementID == scope
+}
+
+// formationInScope fetches a formation from program-service and checks that
+// staff callers belong to its institution. On failure it writes the response
+// and returns nil.
+func formationInScope(c *gin.Context, programs *client.ProgramClient, formationID uint) *client.Formation {
+	formation, err := programs.GetFormation(c.Request.Context(), formationID, c.GetHeader("Authorization"))
+	if errors.Is(err, client.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "formation not found"})
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return nil
+	}
+
+	scope := etablissementScope(c)
+	if scope != "" && formation.EtablissementID != scope {
+		c.JSON(http.StatusNotFound, gin.H{"error": "formation not found"})
+		return nil
+	}
+	return formation
+}
@@ -0,0 +1,457 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// SeanceHandler handles HTTP requests for training sessions and attendance.
+type SeanceHandler struct {
+	repo            *repository.SeanceRepository
+	inscriptionRepo *repository.InscriptionRepository
+	groupeRepo      *repository.GroupeRepository
+	paramRepo       *repository.ParametresFormationRepository
+	programs        *client.ProgramClient
+	notifications   *client.NotificationClient
+	seuilDefaut     int
+}
+
+// NewSeanceHandler creates a new SeanceHandler. seuilDefaut is the attendance
+// threshold (percent) of formations that do not set their own.
+func NewSeanceHandler(
+	repo *repository.SeanceRepository,
+	inscriptionRepo *repository.InscriptionRepository,
+	groupeRepo *repository.GroupeRepository,
+	paramRepo *repository.ParametresFormationRepository,
+	programs *client.ProgramClient,
+	notifications *client.NotificationClient,
+	seuilDefaut int,
+) *SeanceHandler {
+	return &SeanceHandler{
+		repo:            repo,
+		inscriptionRepo: inscriptionRepo,
+		groupeRepo:      groupeRepo,
+		paramRepo:       paramRepo,
+		programs:        programs,
+		notifications:   notifications,
+		seuilDefaut:     seuilDefaut,
+	}
+}
+
+// List returns the sessions of a formation (?groupe_id= keeps a group's sessions
+// and the formation-wide ones).
+func (h *SeanceHandler) List(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var groupeID uint
+	if g, err := strconv.ParseUint(c.Query("groupe_id"), 10, 32); err == nil {
+		groupeID = uint(g)
+	}
+
+	seances, err := h.repo.FindByFormationID(uint(id), groupeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	scope := etablissementScope(c)
+	visibles := make([]model.Seance, 0, len(seances))
+	for _, s := range seances {
+		if scope == "" || s.EtablissementID == scope {
+			visibles = append(visibles, s)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": visibles})
+}
+
+// Create schedules a session for a formation, or for one of its groups.
+func (h *SeanceHandler) Create(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		Titre    string    `json:"titre" binding:"required,max=255"`
+		Debut    time.Time `json:"debut" binding:"required"`
+		Fin      time.Time `json:"fin" binding:"required"`
+		GroupeID *uint     `json:"groupe_id"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !input.Fin.After(input.Debut) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "la fin de la séance doit suivre son début"})
+		return
+	}
+
+	formation := formationInScope(c, h.programs, uint(id))
+	if formation == nil {
+		return
+	}
+
+	if input.GroupeID != nil {
+		g, err := h.groupeRepo.FindByID(*input.GroupeID)
+		if err != nil || g.FormationID != uint(id) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "ce groupe n'appartient pas à la formation"})
+			return
+		}
+	}
+
+	s := model.Seance{
+		FormationID:     uint(id),
+		GroupeID:        input.GroupeID,
+		EtablissementID: formation.EtablissementID,
+		Titre:           input.Titre,
+		Debut:           input.Debut,
+		Fin:             input.Fin,
+		CreePar:         c.GetString("user_id"),
+	}
+
+	if err := h.repo.Create(&s); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": s})
+}
+
+// Delete cancels a session and drops the attendance recorded for it.
+func (h *SeanceHandler) Delete(c *gin.Context) {
+	s := h.seanceInScope(c)
+	if s == nil {
+		return
+	}
+
+	if err := h.repo.Delete(s.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.verifierAssiduite(c, s.FormationID, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "séance supprimée"})
+}
+
+// Presences returns the attendance sheet of a session: every learner it concerns,
+// with the status recorded so far (null when not yet recorded).
+func (h *SeanceHandler) Presences(c *gin.Context) {
+	s := h.seanceInScope(c)
+	if s == nil {
+		return
+	}
+
+	inscriptions, err := h.repo.Concernes(s)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	presences, err := h.repo.FindPresences(s.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byInscription := make(map[uint]model.Presence, len(presences))
+	for _, p := range presences {
+		byInscription[p.InscriptionID] = p
+	}
+
+	type ligne struct {
+		InscriptionID uint                  `json:"inscription_id"`
+		NomComplet    string                `json:"nom_complet"`
+		Statut        *model.StatutPresence `json:"statut"`
+		Commentaire   string                `json:"commentaire,omitempty"`
+	}
+	feuille := make([]ligne, 0, len(inscriptions))
+	for _, ins := range inscriptions {
+		l := ligne{InscriptionID: ins.ID, NomComplet: ins.NomComplet}
+		if p, ok := byInscription[ins.ID]; ok {
+			statut := p.Statut
+			l.Statut = &statut
+			l.Commentaire = p.Commentaire
+		}
+		feuille = append(feuille, l)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": feuille, "seance": s})
+}
+
+// SavePresences records or corrects the attendance of learners at a session,
+// then raises or clears attendance alerts for them.
+func (h *SeanceHandler) SavePresences(c *gin.Context) {
+	s := h.seanceInScope(c)
+	if s == nil {
+		return
+	}
+
+	var input struct {
+		Presences []struct {
+			InscriptionID uint   `json:"inscription_id" binding:"required"`
+			Statut        string `json:"statut" binding:"required,oneof=PRESENT ABSENT EXCUSE"`
+			Commentaire   string `json:"commentaire"`
+		} `json:"presences" binding:"required,min=1,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if s.Debut.After(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "la séance n'a pas encore commencé"})
+		return
+	}
+
+	inscriptions, err := h.repo.Concernes(s)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	concernes := make(map[uint]bool, len(inscriptions))
+	for _, ins := range inscriptions {
+		concernes[ins.ID] = true
+	}
+
+	var inconnues []uint
+	presences := make([]model.Presence, 0, len(input.Presences))
+	ids := make([]uint, 0, len(input.Presences))
+	for _, p := range input.Presences {
+		if !concernes[p.InscriptionID] {
+			inconnues = append(inconnues, p.InscriptionID)
+			continue
+		}
+		presences = append(presences, model.Presence{
+			SeanceID:      s.ID,
+			InscriptionID: p.InscriptionID,
+			Statut:        model.StatutPresence(p.Statut),
+			Commentaire:   p.Commentaire,
+			SaisiPar:      c.GetString("user_id"),
+		})
+		ids = append(ids, p.InscriptionID)
+	}
+	if len(inconnues) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":        "inscriptions non concernées par cette séance",
+			"inscriptions": inconnues,
+		})
+		return
+	}
+
+	if err := h.repo.SavePresences(presences); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	taux := h.verifierAssiduite(c, s.FormationID, ids)
+	c.JSON(http.StatusOK, gin.H{"data": presences, "assiduite": taux})
+}
+
+// Assiduite returns the attendance rate of every enrolled learner of a formation
+// (?sous_seuil=true keeps those below the threshold).
+func (h *SeanceHandler) Assiduite(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	taux, err := h.taux(uint(id), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.Query("sous_seuil") == "true" {
+		filtres := taux[:0]
+		for _, t := range taux {
+			if t.SousSeuil {
+				filtres = append(filtres, t)
+			}
+		}
+		taux = filtres
+	}
+	c.JSON(http.StatusOK, gin.H{"data": taux})
+}
+
+// Alertes returns the attendance alerts of a formation (?actives=true keeps open ones).
+func (h *SeanceHandler) Alertes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if formationInScope(c, h.programs, uint(id)) == nil {
+		return
+	}
+
+	alertes, err := h.repo.FindAlertes(uint(id), c.Query("actives") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": alertes})
+}
+
+// AssiduiteInscription returns the attendance of one learner (owner or staff).
+func (h *SeanceHandler) AssiduiteInscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	ins, err := h.inscriptionRepo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	if c.GetString("role") == "CANDIDAT" && ins.CandidatID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "accès refusé"})
+		return
+	}
+
+	taux, err := h.taux(ins.FormationID, []uint{ins.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(taux) == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "l'assiduité ne concerne que les candidats inscrits"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": taux[0]})
+}
+
+// taux computes attendance rates and compares them with the formation's threshold.
+func (h *SeanceHandler) taux(formationID uint, inscriptionIDs []uint) ([]model.TauxPresence, error) {
+	params, err := h.paramRepo.Get(formationID)
+	if err != nil {
+		return nil, err
+	}
+	seuil := h.seuilDefaut
+	if params.SeuilPresence != nil {
+		seuil = *params.SeuilPresence
+	}
+
+	taux, err := h.repo.Taux(formationID, inscriptionIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range taux {
+		taux[i].Seuil = seuil
+		taux[i].SousSeuil = taux[i].Taux != nil && *taux[i].Taux < float64(seuil)
+	}
+	return taux, nil
+}
+
+// verifierAssiduite opens an alert, and notifies the learner, for each learner who
+// fell below the threshold, and closes the alerts of learners back above it.
+// Failures are logged: attendance is already saved at this point.
+func (h *SeanceHandler) verifierAssiduite(c *gin.Context, formationID uint, inscriptionIDs []uint) []model.TauxPresence {
+	taux, err := h.taux(formationID, inscriptionIDs)
+	if err != nil {
+		log.Printf("formation %d: attendance check failed: %v", formationID, err)
+		return nil
+	}
+
+	for _, t := range taux {
+		alerte, err := h.repo.FindAlerteActive(t.InscriptionID)
+		if err != nil {
+			log.Printf("inscription %d: attendance alert lookup failed: %v", t.InscriptionID, err)
+			continue
+		}
+
+		switch {
+		case t.SousSeuil && alerte == nil:
+			alerte = &model.AlerteAssiduite{
+				InscriptionID: t.InscriptionID,
+				FormationID:   formationID,
+				Taux:          *t.Taux,
+				Seuil:         t.Seuil,
+				Active:        true,
+			}
+			ouverte, err := h.repo.CreateAlerte(alerte)
+			if err != nil {
+				log.Printf("inscription %d: attendance alert not saved: %v", t.InscriptionID, err)
+				continue
+			}
+			// A concurrent entry opened it and tells the learner
+			if !ouverte {
+				continue
+			}
+			if err := h.notifierAlerte(c, alerte); err != nil {
+				log.Printf("inscription %d: attendance alert not sent: %v", t.InscriptionID, err)
+			}
+		case !t.SousSeuil && alerte != nil:
+			if err := h.repo.LeverAlerte(alerte); err != nil {
+				log.Printf("inscription %d: attendance alert not closed: %v", t.InscriptionID, err)
+			}
+		}
+	}
+	return taux
+}
+
+func (h *SeanceHandler) notifierAlerte(c *gin.Context, a *model.AlerteAssiduite) error {
+	ins, err := h.inscriptionRepo.FindByID(a.InscriptionID)
+	if err != nil {
+		return err
+	}
+	if ins.Email == "" {
+		return fmt.Errorf("aucune adresse e-mail sur l'inscription")
+	}
+
+	return h.notifications.Send(c.Request.Context(), fmt.Sprintf("alerte-assiduite-%d", a.ID), client.Notification{
+		TemplateKey: "alerte-assiduite",
+		Recipient:   ins.Email,
+		Payload: map[string]interface{}{
+			"nomComplet":    ins.NomComplet,
+			"inscriptionId": ins.ID,
+			"reference":     ins.NumeroDossier(),
+			"formationId":   a.FormationID,
+			"taux":          strconv.FormatFloat(a.Taux, 'f', 1, 64),
+			"seuil":         a.Seuil,
+		},
+	})
+}
+
+// seanceInScope loads the session named by the :id parameter and checks that staff
+// callers belong to its institution. On failure it writes the response and returns nil.
+func (h *SeanceHandler) seanceInScope(c *gin.Context) *model.Seance {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return nil
+	}
+
+	s, err := h.repo.FindByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "seance not found"})
+		return nil
+	}
+	if scope := etablissementScope(c); scope != "" && s.EtablissementID != scope {
+		c.JSON(http.StatusNotFound, gin.H{"error": "seance not found"})
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/client"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// SlaHandler monitors how long dossiers wait in each state and escalates breaches.
+type SlaHandler struct {
+	repo           *repository.SlaRepository
+	paramRepo      *repository.ParametresFormationRepository
+	notifications  *client.NotificationClient
+	defauts        model.DelaisSla
+	escaladeHeures int
+}
+
+// NewSlaHandler creates a new SlaHandler. defauts holds the SLA, in hours per state,
+// of formations that set none; escaladeHeures is how long a breach stays with the
+// coordinator before it is escalated to the institution administrator.
+func NewSlaHandler(
+	repo *repository.SlaRepository,
+	paramRepo *repository.ParametresFormationRepository,
+	notifications *client.NotificationClient,
+	defauts map[string]int,
+	escaladeHeures int,
+) *SlaHandler {
+	return &SlaHandler{
+		repo:           repo,
+		paramRepo:      paramRepo,
+		notifications:  notifications,
+		defauts:        model.DelaisSlaDe(defauts),
+		escaladeHeures: escaladeHeures,
+	}
+}
+
+// Depassements is the breach dashboard: open breaches of the caller's institution,
+// longest overdue first, optionally filtered by ?formation_id=, ?etat= and ?niveau=
+// (?resolus=true includes resolved ones), with a breakdown by state and level.
+func (h *SlaHandler) Depassements(c *gin.Context) {
+	f := repository.SlaFilter{
+		EtablissementID: etablissementScope(c),
+		Etat:            c.Query("etat"),
+		Resolus:         c.Query("resolus") == "true",
+	}
+	if id, err := strconv.ParseUint(c.Query("formation_id"), 10, 32); err == nil {
+		f.FormationID = uint(id)
+	}
+	if n, err := strconv.Atoi(c.Query("niveau")); err == nil {
+		f.Niveau = n
+	}
+
+	depassements, err := h.repo.FindAll(f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	maintenant := time.Now()
+	type ligne struct {
+		model.DepassementSla
+		RetardHeures int `json:"retard_heures"`
+	}
+	lignes := make([]ligne, len(depassements))
+	parEtat := map[model.EtatInscription]int{}
+	parNiveau := map[int]int{}
+	for i, d := range depassements {
+		lignes[i] = ligne{DepassementSla: d, RetardHeures: d.RetardHeures(maintenant)}
+		parEtat[d.Etat]++
+		parNiveau[d.Niveau]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": lignes,
+		"synthese": gin.H{
+			"total":      len(lignes),
+			"par_etat":   parEtat,
+			"par_niveau": parNiveau,
+		},
+	})
+}
+
+// Evaluer computes, from the history, how long every open dossier has been in its
+// state, records the dossiers past their formation's SLA and escalates them: the
+// coordinator is alerted first, then the institution administrator once the breach
+// has stayed unresolved for the escalation delay. Breaches of dossiers that moved on
+// are resolved. Internal endpoint for the scheduler.
+func (h *SlaHandler) Evaluer(c *gin.Context) {
+	etats := make([]model.EtatInscription, 0, len(model.ValidTransitions))
+	for etat := range model.ValidTransitions {
+		etats = append(etats, etat)
+	}
+	dossiers, err := h.repo.FindEnCours(etats)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	maintenant := time.Now()
+	parametres := map[uint]*model.ParametresFormation{}
+	var enCours []uint
+	var escalades int
+	var failed []uint
+	for _, dos := range dossiers {
+		p, ok := parametres[dos.FormationID]
+		if !ok {
+			p, err = h.paramRepo.Get(dos.FormationID)
+			if err != nil {
+				failed = append(failed, dos.ID)
+				continue
+			}
+			parametres[dos.FormationID] = p
+		}
+
+		heures, ok := p.Sla(h.defauts)[dos.Etat]
+		if !ok || heures <= 0 {
+			continue
+		}
+		echeance := dos.EntreeEtat.Add(time.Duration(heures) * time.Hour)
+		if !maintenant.After(echeance) {
+			continue
+		}
+
+		d, err := h.repo.Enregistrer(&model.DepassementSla{
+			InscriptionID:   dos.ID,
+			Etat:            dos.Etat,
+			EntreeEtat:      dos.EntreeEtat,
+			FormationID:     dos.FormationID,
+			EtablissementID: dos.EtablissementID,
+			DelaiHeures:     heures,
+			Echeance:        echeance,
+		})
+		if err != nil {
+			failed = append(failed, dos.ID)
+			continue
+		}
+		enCours = append(enCours, d.ID)
+
+		numero := fmt.Sprintf("#%d", dos.ID)
+		if dos.Reference != nil {
+			numero = *dos.Reference
+		}
+		escalade, err := h.escalader(c, d, p, numero, maintenant)
+		if err != nil {
+			log.Printf("SLA breach %d: escalation not sent: %v", d.ID, err)
+			failed = append(failed, dos.ID)
+			continue
+		}
+		if escalade {
+			escalades++
+		}
+	}
+
+	// A failed dossier may still be in breach: keep its breach open
+	resolus, err := h.repo.ResoudreAutres(enCours, failed, maintenant)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "SLA evaluated",
+		"depassements":          len(enCours),
+		"escalades":             escalades,
+		"resolus":               resolus,
+		"inscriptions_en_echec": failed,
+	})
+}
+
+// escalader moves a breach to its next escalation level when due and e-mails the
+// contact of that level. A level without a contact is recorded without e-mail.
+func (h *SlaHandler) escalader(c *gin.Context, d *model.DepassementSla, p *model.ParametresFormation, reference string, maintenant time.Time) (bool, error) {
+	var niveau int
+	var destinataire, template string
+	switch {
+	case d.EscaladeCoordinateurLe == nil:
+		niveau, destinataire, template = model.NiveauSlaCoordinateur, p.EmailCoordinateur, "sla-depassement"
+	case d.EscaladeAdminLe == nil &&
+		maintenant.Sub(*d.EscaladeCoordinateurLe) >= time.Duration(h.escaladeHeures)*time.Hour:
+		niveau, destinataire, template = model.NiveauSlaAdmin, p.EmailEscalade, "sla-escalade"
+	default:
+		return false, nil
+	}
+
+	if destinataire != "" {
+		err := h.notifications.Send(c.Request.Context(), fmt.Sprintf("sla-%d-%d", d.ID, niveau), client.Notification{
+			TemplateKey: template,
+			Recipient:   destinataire,
+			Payload: map[string]interface{}{
+				"inscriptionId": d.InscriptionID,
+				"reference":     reference,
+				"formationId":   d.FormationID,
+				"etat":          d.Etat,
+				"entreeEtat":    d.EntreeEtat.Format(time.RFC3339),
+				"echeance":      d.Echeance.Format(time.RFC3339),
+				"delaiHeures":   d.DelaiHeures,
+				"retardHeures":  d.RetardHeures(maintenant),
+				"niveau":        niveau,
+			},
+		})
+		if err != nil {
+			return false, err
+		}
+	} else {
+		log.Printf("SLA breach %d: no level %d contact for formation %d", d.ID, niveau, d.FormationID)
+	}
+	return true, h.repo.Escalader(d, niveau, maintenant)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// Transferer moves an inscription to another formation of the institution, keeping
+// its decisions, history and documents. The target formation's prerequisites and
+// capacity are checked first. A waiting-list dossier goes back to EN_VALIDATION,
+// since its rank belonged to the former formation, and the jury score and interview
+// booking of the former formation are dropped.
+func (h *InscriptionHandler) Transferer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	var input struct {
+		FormationID uint   `json:"formation_id" binding:"required"`
+		Motif       string `json:"motif" binding:"required,max=1000"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err := h.repo.FindByID(uint(id))
+	if err != nil || !inScope(c, ins) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "inscription not found"})
+		return
+	}
+
+	switch ins.Etat {
+	case model.EtatPreinscription, model.EtatDossierSoumis, model.EtatEnValidation,
+		model.EtatListeAttente, model.EtatAccepte:
+	default:
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "seul un dossier en cours ou accepté peut être transféré",
+			"etat_actuel": ins.Etat,
+		})
+		return
+	}
+	if input.FormationID == ins.FormationID {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "l'inscription appartient déjà à cette formation"})
+		return
+	}
+
+	cible := formationInScope(c, h.programs, input.FormationID)
+	if cible == nil {
+		return
+	}
+
+	existe, err := h.repo.ExistsInFormation(ins.CandidatID, cible.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if existe {
+		c.JSON(http.StatusConflict, gin.H{"error": "le candidat a déjà un dossier dans la formation cible"})
+		return
+	}
+
+	// Capacity of the target formation
+	params, err := h.paramRepo.Get(cible.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if params.Capacite != nil {
+		prises, err := h.repo.CountPlaces(cible.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if prises >= int64(*params.Capacite) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":         repository.ErrCapaciteAtteinte.Error(),
+				"capacite":      *params.Capacite,
+				"places_prises": prises,
+			})
+			return
+		}
+	}
+
+	// Prerequisites of the target formation, over the answers already given
+	source := ins.FormationID
+	ins.FormationID = cible.ID
+	rejeter, err := h.evaluerEligibilite(ins)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if rejeter {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":       "prérequis d'accès de la formation cible non satisfaits",
+			"eligibilite": ins.Eligibilite,
+		})
+		return
+	}
+
+	ancienEtat := ins.Etat
+	if ins.Etat == model.EtatListeAttente {
+		ins.Etat = model.EtatEnValidation
+	}
+	ins.EtablissementID = cible.EtablissementID
+	ins.ScoreJury = nil
+
+	details, _ := json.Marshal(gin.H{"formation_source": source, "formation_cible": cible.ID})
+	historique := model.InscriptionHistorique{
+		InscriptionID: ins.ID,
+		AncienEtat:    ancienEtat,
+		NouvelEtat:    ins.Etat,
+		ModifiePar:    c.GetString("user_id"),
+		Evenement:     model.EvenementTransfert,
+		Commentaire:   fmt.Sprintf("Transfert vers « %s » : %s", cible.Titre, input.Motif),
+		Details:       details,
+	}
+
+	// Capacity is checked again as the transfer is written, against concurrent transfers
+	err = h.repo.Transferer(ins, ancienEtat, source, params.Capacite, &historique)
+	if errors.Is(err, repository.ErrCapaciteAtteinte) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "capacite": *params.Capacite})
+		return
+	}
+	if errors.Is(err, repository.ErrEtatModifie) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ins, err = h.repo.FindByID(ins.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ins})
+}
@@ -0,0 +1,90 @@
+// Package ics renders iCalendar (RFC 5545) invitations for interview bookings.
+package ics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Event is a single calendar event.
+type Event struct {
+	UID          string
+	Sequence     int
+	Debut        time.Time
+	Fin          time.Time
+	Titre        string
+	Description  string
+	Lieu         string
+	URL          string
+	Organisateur string
+	Participant  string
+}
+
+const stamp = "20060102T150405Z"
+
+// Request renders a METHOD:REQUEST calendar holding one event. Sending it again
+// with the same UID and a higher Sequence updates the event in the recipient's calendar.
+func Request(e Event, now time.Time) []byte {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//FST-CFC//application-service//FR",
+		"CALSCALE:GREGORIAN",
+		"METHOD:REQUEST",
+		"BEGIN:VEVENT",
+		"UID:" + e.UID,
+		fmt.Sprintf("SEQUENCE:%d", e.Sequence),
+		"DTSTAMP:" + now.UTC().Format(stamp),
+		"DTSTART:" + e.Debut.UTC().Format(stamp),
+		"DTEND:" + e.Fin.UTC().Format(stamp),
+		"SUMMARY:" + escape(e.Titre),
+	}
+	if e.Description != "" {
+		lines = append(lines, "DESCRIPTION:"+escape(e.Description))
+	}
+	if e.Lieu != "" {
+		lines = append(lines, "LOCATION:"+escape(e.Lieu))
+	}
+	if e.URL != "" {
+		lines = append(lines, "URL:"+e.URL)
+	}
+	if e.Organisateur != "" {
+		lines = append(lines, "ORGANIZER:mailto:"+e.Organisateur)
+	}
+	if e.Participant != "" {
+		lines = append(lines, "ATTENDEE;ROLE=REQ-PARTICIPANT;RSVP=FALSE:mailto:"+e.Participant)
+	}
+	lines = append(lines, "STATUS:CONFIRMED", "END:VEVENT", "END:VCALENDAR")
+
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(fold(l))
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
+
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// fold splits content lines longer than 75 octets, without cutting a UTF-8 sequence.
+func fold(l string) string {
+	if len(l) <= 75 {
+		return l
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range l {
+		size := len(string(r))
+		if n+size > 75 {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	return b.String()
+}
@@ -0,0 +1,224 @@
+// Package identite validates and normalises the identity fields of a candidate:
+// CIN, CNE or Massar code, phone number, date of birth and last diploma.
+package identite
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/formschema"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+)
+
+var (
+	// CIN: one or two letters for the issuing office, then up to six digits (AB123456)
+	formatCIN = regexp.MustCompile(`^[A-Z]{1,2}[0-9]{1,6}$`)
+	// CNE: ten digits, or a letter and nine digits for Massar codes (R130123456)
+	formatCNE = regexp.MustCompile(`^([0-9]{10}|[A-Z][0-9]{9})$`)
+	// Moroccan national significant number: nine digits, mobile (6, 7) or fixed (5, 8)
+	formatMaroc = regexp.MustCompile(`^[5-8][0-9]{8}$`)
+	// Any other E.164 number: country code and subscriber number, at most 15 digits
+	formatE164 = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+)
+
+// AgeMinimum is the youngest a candidate can be, in years.
+const AgeMinimum = 15
+
+var (
+	ErrCIN       = errors.New("CIN invalide : une ou deux lettres suivies de 1 à 6 chiffres (ex. AB123456)")
+	ErrCNE       = errors.New("CNE invalide : 10 chiffres, ou une lettre suivie de 9 chiffres pour un code Massar")
+	ErrTelephone = errors.New("numéro de téléphone invalide (ex. 0612345678 ou +212612345678)")
+)
+
+// Saisie holds the identity fields as entered by the candidate. Empty fields are
+// left unset.
+type Saisie struct {
+	Telephone      string `json:"telephone"`
+	CIN            string `json:"cin"`
+	CNE            string `json:"cne"`
+	DateNaissance  string `json:"date_naissance"` // YYYY-MM-DD
+	DernierDiplome string `json:"dernier_diplome" binding:"max=255"`
+	AnneeDiplome   *int   `json:"annee_diplome"`
+}
+
+// Identite holds the validated, normalised identity fields.
+type Identite struct {
+	Telephone      string
+	CIN            *string
+	CNE            *string
+	DateNaissance  *time.Time
+	DernierDiplome string
+	AnneeDiplome   *int
+}
+
+// Valider checks every field of the input and returns them normalised, or the
+// list of invalid fields.
+func Valider(s Saisie, maintenant time.Time) (Identite, []formschema.FieldError) {
+	var id Identite
+	var erreurs []formschema.FieldError
+	erreur := func(champ string, err error) {
+		erreurs = append(erreurs, formschema.FieldError{Champ: champ, Message: err.Error()})
+	}
+
+	if strings.TrimSpace(s.Telephone) != "" {
+		tel, err := NormaliserTelephone(s.Telephone)
+		if err != nil {
+			erreur("telephone", err)
+		}
+		id.Telephone = tel
+	}
+	if strings.TrimSpace(s.CIN) != "" {
+		cin, err := NormaliserCIN(s.CIN)
+		if err != nil {
+			erreur("cin", err)
+		}
+		id.CIN = &cin
+	}
+	if strings.TrimSpace(s.CNE) != "" {
+		cne, err := NormaliserCNE(s.CNE)
+		if err != nil {
+			erreur("cne", err)
+		}
+		id.CNE = &cne
+	}
+	if s.DateNaissance != "" {
+		d, err := time.Parse("2006-01-02", s.DateNaissance)
+		switch {
+		case err != nil:
+			erreur("date_naissance", errors.New("date de naissance invalide (format AAAA-MM-JJ)"))
+		case d.Year() < 1900 || d.AddDate(AgeMinimum, 0, 0).After(maintenant):
+			erreur("date_naissance", errors.New("date de naissance hors limites"))
+		default:
+			id.DateNaissance = &d
+		}
+	}
+	id.DernierDiplome = strings.TrimSpace(s.DernierDiplome)
+	if s.AnneeDiplome != nil {
+		annee := *s.AnneeDiplome
+		switch {
+		case annee < 1950 || annee > maintenant.Year():
+			erreur("annee_diplome", errors.New("année d'obtention du diplôme hors limites"))
+		case id.DateNaissance != nil && annee <= id.DateNaissance.Year():
+			erreur("annee_diplome", errors.New("année d'obtention antérieure à la date de naissance"))
+		default:
+			id.AnneeDiplome = &annee
+		}
+	}
+	return id, erreurs
+}
+
+// Colonnes returns the inscription columns to write for the fields given; fields
+// left empty are not written.
+func (id Identite) Colonnes() map[string]interface{} {
+	colonnes := map[string]interface{}{}
+	if id.Telephone != "" {
+		colonnes["telephone"] = id.Telephone
+	}
+	if id.CIN != nil {
+		colonnes["cin"] = *id.CIN
+	}
+	if id.CNE != nil {
+		colonnes["cne"] = *id.CNE
+	}
+	if id.DateNaissance != nil {
+		colonnes["date_naissance"] = *id.DateNaissance
+	}
+	if id.DernierDiplome != "" {
+		colonnes["dernier_diplome"] = id.DernierDiplome
+	}
+	if id.AnneeDiplome != nil {
+		colonnes["annee_diplome"] = *id.AnneeDiplome
+	}
+	return colonnes
+}
+
+// Appliquer records the identity fields on an inscription; fields left empty keep
+// their current value.
+func (id Identite) Appliquer(ins *model.Inscription) {
+	if id.Telephone != "" {
+		ins.Telephone = id.Telephone
+	}
+	if id.CIN != nil {
+		ins.CIN = id.CIN
+	}
+	if id.CNE != nil {
+		ins.CNE = id.CNE
+	}
+	if id.DateNaissance != nil {
+		ins.DateNaissance = id.DateNaissance
+	}
+	if id.DernierDiplome != "" {
+		ins.DernierDiplome = id.DernierDiplome
+	}
+	if id.AnneeDiplome != nil {
+		ins.AnneeDiplome = id.AnneeDiplome
+	}
+}
+
+// NormaliserCIN upper-cases a CIN number, drops its spaces and checks its format.
+func NormaliserCIN(s string) (string, error) {
+	cin := compacter(s)
+	if !formatCIN.MatchString(cin) {
+		return cin, ErrCIN
+	}
+	return cin, nil
+}
+
+// NormaliserCNE upper-cases a CNE or Massar code, drops its spaces and checks its format.
+func NormaliserCNE(s string) (string, error) {
+	cne := compacter(s)
+	if !formatCNE.MatchString(cne) {
+		return cne, ErrCNE
+	}
+	return cne, nil
+}
+
+// NormaliserTelephone converts a phone number to E.164. Numbers without a country
+// code are Moroccan: 0612345678, 612345678, 212612345678 and +212 0 612 34 56 78
+// all become +212612345678. Other countries need their code (+33…, 0033…).
+func NormaliserTelephone(s string) (string, error) {
+	tel := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '.', '-', '(', ')', '/':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+	if strings.HasPrefix(tel, "00") {
+		tel = "+" + tel[2:]
+	}
+
+	var national string
+	switch {
+	case strings.HasPrefix(tel, "+212"):
+		national = strings.TrimPrefix(tel[4:], "0")
+	case strings.HasPrefix(tel, "+"):
+		if !formatE164.MatchString(tel) {
+			return tel, ErrTelephone
+		}
+		return tel, nil
+	case strings.HasPrefix(tel, "212") && len(tel) == 12:
+		national = tel[3:]
+	case strings.HasPrefix(tel, "0") && len(tel) == 10:
+		national = tel[1:]
+	default:
+		national = tel
+	}
+	if !formatMaroc.MatchString(national) {
+		return tel, ErrTelephone
+	}
+	return "+212" + national, nil
+}
+
+// compacter upper-cases an identifier and drops its spaces and dashes.
+func compacter(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(s)))
+}
@@ -90,3 +90,23 @@ func RequireRole(allowedRoles ...string) gin.HandlerFunc {
 		})
 	}
 }
+
+// RequireInstitution rejects staff tokens that carry no institution_id,
+// so that institution scoping can never silently widen to global access.
+// SUPER_ADMIN, SYSTEM and CANDIDAT tokens are not institution-bound.
+func RequireInstitution() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.GetString("role") {
+		case "SUPER_ADMIN", "SYSTEM", "CANDIDAT":
+			c.Next()
+			return
+		}
+
+		if c.GetString("institution_id") == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "aucun établissement rattaché au jeton"})
+			return
+		}
+
+		c.Next()
+	}
+}
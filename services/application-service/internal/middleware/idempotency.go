@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyHeader is the request header carrying the client-generated key.
+const IdempotencyHeader = "Idempotency-Key"
+
+// Idempotency makes mutating requests (POST, PUT, PATCH, DELETE) safe to retry.
+// The first response sent with a given Idempotency-Key is stored with a hash of
+// the request (method, path, query string and body); a retry with the same key and
+// request replays it, while reusing the key for a different request answers 422.
+// Keys are scoped to the authenticated user, so this must run after AuthMiddleware,
+// and expire after ttl.
+// Requests without the header are processed as usual.
+func Idempotency(repo *repository.IdempotenceRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" || !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key trop longue (255 caractères max.)"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "corps de requête illisible"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// The query string is part of the request: ?simulation=true must not replay as a real run
+		chemin := c.Request.URL.RequestURI()
+		h := sha256.New()
+		h.Write([]byte(c.Request.Method + " " + chemin + "\n"))
+		h.Write(body)
+		empreinte := hex.EncodeToString(h.Sum(nil))
+
+		cle := &model.CleIdempotence{
+			Cle:           key,
+			UtilisateurID: c.GetString("user_id"),
+			Methode:       c.Request.Method,
+			Chemin:        chemin,
+			Empreinte:     empreinte,
+			ExpireLe:      time.Now().Add(ttl),
+		}
+		existing, err := repo.Reserver(cle)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.Empreinte != empreinte:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error": "Idempotency-Key déjà utilisée pour une requête différente",
+				})
+			case existing.Statut == 0:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"error": "une requête avec cette Idempotency-Key est en cours de traitement",
+				})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.Statut, existing.ContentType, existing.Reponse)
+				c.Abort()
+			}
+			return
+		}
+
+		// Server errors are not cached, and neither are panics, which unwind past c.Next():
+		// the client may retry with the same key
+		stockee := false
+		defer func() {
+			if stockee {
+				return
+			}
+			if err := repo.Liberer(cle.ID); err != nil {
+				log.Printf("idempotency key %q: release failed: %v", key, err)
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+
+		status := rec.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		if err := repo.Terminer(cle.ID, status, rec.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+			log.Printf("idempotency key %q: store failed: %v", key, err)
+			return
+		}
+		stockee = true
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// responseRecorder copies the response body while it is written to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// Affectation is an applied allocation run over a set of formations, with the
+// report explaining the outcome for every formation and candidate.
+type Affectation struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	EtablissementID string    `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';index"`
+	FormationIDs    JSON      `json:"formation_ids" gorm:"type:jsonb;not null"`
+	Rapport         JSON      `json:"rapport" gorm:"type:jsonb;not null"`
+	LancePar        string    `json:"lance_par" gorm:"type:varchar(100);not null"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// RapportAffectation explains an allocation run. NonClasses lists the inscriptions
+// left out for lack of a jury score, DejaAffectes the candidates who already hold a
+// seat in one of the formations.
+type RapportAffectation struct {
+	Formations   []BilanFormation      `json:"formations"`
+	Candidats    []AffectationCandidat `json:"candidats"`
+	NonClasses   []uint                `json:"non_classes,omitempty"`
+	DejaAffectes []string              `json:"deja_affectes,omitempty"`
+}
+
+// BilanFormation sums up the run for one formation. PlacesOffertes is nil when the
+// formation has no capacity; Barre is the score of the last admitted candidate.
+type BilanFormation struct {
+	FormationID    uint     `json:"formation_id"`
+	Capacite       *int     `json:"capacite"`
+	PlacesPrises   int      `json:"places_prises"`
+	PlacesOffertes *int     `json:"places_offertes"`
+	Admis          int      `json:"admis"`
+	Barre          *float64 `json:"barre"`
+	ListeAttente   int      `json:"liste_attente"`
+}
+
+// AffectationCandidat is the outcome for one candidate: the seat obtained, if any,
+// and the result of each of their choices.
+type AffectationCandidat struct {
+	CandidatID    string         `json:"candidat_id"`
+	NomComplet    string         `json:"nom_complet"`
+	InscriptionID *uint          `json:"inscription_id"`
+	FormationID   *uint          `json:"formation_id"`
+	RangObtenu    *int           `json:"rang_obtenu"`
+	Voeux         []ResultatVoeu `json:"voeux"`
+}
+
+// ResultatVoeu is the outcome of one choice: ACCEPTE for the seat obtained,
+// LISTE_ATTENTE for better choices not obtained, REFUSE for the choices below.
+type ResultatVoeu struct {
+	Rang            int             `json:"rang"`
+	InscriptionID   uint            `json:"inscription_id"`
+	FormationID     uint            `json:"formation_id"`
+	Score           float64         `json:"score"`
+	Resultat        EtatInscription `json:"resultat"`
+	PositionAttente *int            `json:"position_attente,omitempty"`
+	Explication     string          `json:"explication"`
+}
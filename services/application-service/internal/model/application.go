@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,15 +16,22 @@ const (
 	EtatEnValidation   EtatInscription = "EN_VALIDATION"
 	EtatAccepte        EtatInscription = "ACCEPTE"
 	EtatRefuse         EtatInscription = "REFUSE"
+	EtatListeAttente   EtatInscription = "LISTE_ATTENTE"
 	EtatInscrit        EtatInscription = "INSCRIT"
+	EtatValide         EtatInscription = "VALIDE"
+	EtatNonValide      EtatInscription = "NON_VALIDE"
+	EtatAbandon        EtatInscription = "ABANDON"
+	EtatDesiste        EtatInscription = "DESISTE"
 )
 
 // ValidTransitions defines which status transitions are allowed.
 var ValidTransitions = map[EtatInscription][]EtatInscription{
 	EtatPreinscription: {EtatDossierSoumis},
 	EtatDossierSoumis:  {EtatEnValidation},
-	EtatEnValidation:   {EtatAccepte, EtatRefuse},
-	EtatAccepte:        {EtatInscrit},
+	EtatEnValidation:   {EtatAccepte, EtatRefuse, EtatListeAttente},
+	EtatListeAttente:   {EtatAccepte, EtatRefuse},
+	EtatAccepte:        {EtatInscrit, EtatDesiste},
+	EtatInscrit:        {EtatValide, EtatNonValide, EtatAbandon},
 }
 
 // CanTransitionTo checks whether a transition from the current status to the target is allowed.
@@ -41,20 +49,73 @@ func (s EtatInscription) CanTransitionTo(target EtatInscription) bool {
 }
 
 // Inscription represents a candidate application (préinscription / dossier / inscription).
+// RangVoeu is the candidate's order of preference among their applications (1 = most
+// wanted) and ScoreJury the jury's ranking score; both feed the allocation run.
+// An ACCEPTE offer must be confirmed by OffreExpireLe, or it lapses into DESISTE.
+// Reference is the dossier number shown to people (CFC-2026-FST-000123); it is nil
+// only for dossiers created before numbering, until the numbering job has run.
+// Telephone is in E.164 form (+212612345678) and CIN / CNE are upper-cased without
+// spaces, as normalised by the identite package. Once AnonymiseLe is set, the
+// personal data has been erased and only the dossier's course remains. A deleted
+// inscription stays in the trash, with who deleted it and why, until it is restored
+// or purged.
 type Inscription struct {
-	ID           uint            `json:"id" gorm:"primaryKey"`
-	CandidatID   string          `json:"candidat_id" gorm:"type:varchar(100);not null;index"`
-	FormationID  uint            `json:"formation_id" gorm:"not null;index"`
-	Etat         EtatInscription `json:"etat" gorm:"type:varchar(20);not null;default:'PREINSCRIPTION';index"`
-	NomComplet   string          `json:"nom_complet" gorm:"type:varchar(255);not null"`
-	Email        string          `json:"email" gorm:"type:varchar(255);not null"`
-	Telephone    string          `json:"telephone" gorm:"type:varchar(50)"`
-	Notes        string          `json:"notes" gorm:"type:text"`
-	DateCreation time.Time       `json:"date_creation" gorm:"autoCreateTime"`
-	CreatedAt    time.Time       `json:"created_at"`
-	UpdatedAt    time.Time       `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt  `json:"-" gorm:"index"`
+	ID                uint            `json:"id" gorm:"primaryKey"`
+	Reference         *string         `json:"reference" gorm:"type:varchar(40);uniqueIndex"`
+	CandidatID        string          `json:"candidat_id" gorm:"type:varchar(100);not null;index"`
+	FormationID       uint            `json:"formation_id" gorm:"not null;index"`
+	EtablissementID   string          `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';index"`
+	Etat              EtatInscription `json:"etat" gorm:"type:varchar(20);not null;default:'PREINSCRIPTION';index"`
+	NomComplet        string          `json:"nom_complet" gorm:"type:varchar(255);not null"`
+	Email             string          `json:"email" gorm:"type:varchar(255);not null"`
+	Telephone         string          `json:"telephone" gorm:"type:varchar(50)"`
+	CIN               *string         `json:"cin,omitempty" gorm:"column:cin;type:varchar(10);index"`
+	CNE               *string         `json:"cne,omitempty" gorm:"column:cne;type:varchar(10);index"`
+	DateNaissance     *time.Time      `json:"date_naissance,omitempty" gorm:"type:date"`
+	DernierDiplome    string          `json:"dernier_diplome,omitempty" gorm:"type:varchar(255)"`
+	AnneeDiplome      *int            `json:"annee_diplome,omitempty"`
+	Notes             string          `json:"notes" gorm:"type:text"`
+	Reponses          JSON            `json:"reponses,omitempty" gorm:"type:jsonb"`
+	FormulaireVersion int             `json:"formulaire_version,omitempty"`
+	EligibiliteStatut string          `json:"eligibilite_statut,omitempty" gorm:"type:varchar(20);index"`
+	Eligibilite       JSON            `json:"eligibilite,omitempty" gorm:"type:jsonb"`
+	RangVoeu          *int            `json:"rang_voeu,omitempty"`
+	ScoreJury         *float64        `json:"score_jury,omitempty"`
+	OffreExpireLe     *time.Time      `json:"offre_expire_le,omitempty" gorm:"index"`
+	OffreConfirmeeLe  *time.Time      `json:"offre_confirmee_le,omitempty"`
+	OffreRappelLe     *time.Time      `json:"offre_rappel_le,omitempty"`
+	AnonymiseLe       *time.Time      `json:"anonymise_le,omitempty"`
+	SupprimeePar      string          `json:"supprimee_par,omitempty" gorm:"type:varchar(100)"`
+	MotifSuppression  string          `json:"motif_suppression,omitempty" gorm:"type:text"`
+	DateCreation      time.Time       `json:"date_creation" gorm:"autoCreateTime"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt  `json:"-" gorm:"index"`
 
 	Decisions []Decision              `json:"decisions,omitempty" gorm:"foreignKey:InscriptionID"`
 	History   []InscriptionHistorique `json:"history,omitempty" gorm:"foreignKey:InscriptionID"`
 }
+
+// NumeroDossier returns the dossier number to show people, falling back to the
+// internal ID for dossiers not numbered yet.
+func (ins *Inscription) NumeroDossier() string {
+	if ins.Reference != nil {
+		return *ins.Reference
+	}
+	return fmt.Sprintf("#%d", ins.ID)
+}
+
+// SequenceDossier is the last dossier number handed out for an institution code and
+// academic year. Numbers are allocated inside the creating transaction, which holds
+// the row lock until it commits, so two dossiers never share a number. Numbers are
+// never reused either: deleted dossiers leave gaps.
+type SequenceDossier struct {
+	Code   string `gorm:"type:varchar(20);primaryKey"`
+	Annee  int    `gorm:"primaryKey;autoIncrement:false"`
+	Valeur int64  `gorm:"not null"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (SequenceDossier) TableName() string {
+	return "sequences_dossier"
+}
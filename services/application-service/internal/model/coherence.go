@@ -0,0 +1,152 @@
+package model
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// Anomalies found by replaying the history of an inscription.
+const (
+	// AnomalieLacune: an entry does not start from the state the previous one left.
+	AnomalieLacune = "LACUNE"
+	// AnomalieEtatDivergent: the history does not end in the current state.
+	AnomalieEtatDivergent = "ETAT_DIVERGENT"
+)
+
+// EvenementReconstitution marks a history entry added by the consistency checker
+// for a state change that was not recorded.
+const EvenementReconstitution = "RECONSTITUTION"
+
+// AnomalieHistorique is a state change missing from the history of an inscription:
+// the dossier went from De to Vers some time between Apres and Avant. EstimeLe is
+// the best guess of when — the decision recorded for Vers in that interval if there
+// is one, Avant otherwise. HistoriqueID is the entry that does not follow on, for a
+// LACUNE.
+type AnomalieHistorique struct {
+	Type         string          `json:"type"`
+	De           EtatInscription `json:"de"`
+	Vers         EtatInscription `json:"vers"`
+	Apres        time.Time       `json:"apres"`
+	Avant        time.Time       `json:"avant"`
+	EstimeLe     time.Time       `json:"estime_le"`
+	HistoriqueID *uint           `json:"historique_id,omitempty"`
+}
+
+// RapportCoherence lists the anomalies of the history of one inscription.
+type RapportCoherence struct {
+	InscriptionID uint                 `json:"inscription_id"`
+	Reference     string               `json:"reference"`
+	Etat          EtatInscription      `json:"etat"`
+	Anomalies     []AnomalieHistorique `json:"anomalies"`
+}
+
+// HistoriqueOrdonne returns the history of the inscription in chronological order.
+func (ins *Inscription) HistoriqueOrdonne() []InscriptionHistorique {
+	historique := append([]InscriptionHistorique(nil), ins.History...)
+	sort.SliceStable(historique, func(i, j int) bool {
+		if !historique[i].CreatedAt.Equal(historique[j].CreatedAt) {
+			return historique[i].CreatedAt.Before(historique[j].CreatedAt)
+		}
+		return historique[i].ID < historique[j].ID
+	})
+	return historique
+}
+
+// VerifierHistorique replays the history of the inscription from the PREINSCRIPTION
+// state it was created in and returns the state changes missing from it. History and
+// Decisions must be loaded.
+func (ins *Inscription) VerifierHistorique() []AnomalieHistorique {
+	anomalies := []AnomalieHistorique{}
+	etat := EtatPreinscription
+	depuis := ins.DateCreation
+
+	for _, h := range ins.HistoriqueOrdonne() {
+		if h.AncienEtat != etat {
+			id := h.ID
+			anomalies = append(anomalies, ins.anomalie(AnomalieLacune, etat, h.AncienEtat, depuis, h.CreatedAt, &id))
+		}
+		etat = h.NouvelEtat
+		depuis = h.CreatedAt
+	}
+
+	if etat != ins.Etat {
+		// The last change of state also touched UpdatedAt, unless something else did since
+		avant := ins.UpdatedAt
+		if !avant.After(depuis) {
+			avant = depuis.Add(time.Microsecond)
+		}
+		anomalies = append(anomalies, ins.anomalie(AnomalieEtatDivergent, etat, ins.Etat, depuis, avant, nil))
+	}
+	return anomalies
+}
+
+func (ins *Inscription) anomalie(typ string, de, vers EtatInscription, apres, avant time.Time, historiqueID *uint) AnomalieHistorique {
+	a := AnomalieHistorique{
+		Type:         typ,
+		De:           de,
+		Vers:         vers,
+		Apres:        apres,
+		Avant:        avant,
+		EstimeLe:     avant,
+		HistoriqueID: historiqueID,
+	}
+	if typ == AnomalieLacune {
+		// Sort before the entry that does not follow on
+		a.EstimeLe = avant.Add(-time.Microsecond)
+	}
+	var decidee *time.Time
+	for _, d := range ins.Decisions {
+		if d.Etat != vers || !d.CreatedAt.After(apres) || !d.CreatedAt.Before(a.EstimeLe) {
+			continue
+		}
+		if decidee == nil || d.CreatedAt.After(*decidee) {
+			t := d.CreatedAt
+			decidee = &t
+		}
+	}
+	if decidee != nil {
+		a.EstimeLe = *decidee
+	}
+	return a
+}
+
+// Reconstitution returns the synthetic history entry filling the anomaly, dated at
+// its estimated time.
+func (a AnomalieHistorique) Reconstitution(inscriptionID uint, par string) InscriptionHistorique {
+	details, _ := json.Marshal(map[string]interface{}{
+		"anomalie": a.Type,
+		"apres":    a.Apres,
+		"avant":    a.Avant,
+	})
+	return InscriptionHistorique{
+		InscriptionID: inscriptionID,
+		AncienEtat:    a.De,
+		NouvelEtat:    a.Vers,
+		ModifiePar:    par,
+		Evenement:     EvenementReconstitution,
+		Commentaire:   "Changement d'état non enregistré, reconstitué par le contrôle de cohérence",
+		Details:       details,
+		CreatedAt:     a.EstimeLe,
+	}
+}
+
+// EtatAu returns the state the inscription was in at the given time according to its
+// history, with the entry that put it there (nil while still in PREINSCRIPTION).
+// It returns false if the inscription did not exist yet.
+func (ins *Inscription) EtatAu(date time.Time) (EtatInscription, *InscriptionHistorique, bool) {
+	if date.Before(ins.DateCreation) {
+		return "", nil, false
+	}
+	etat := EtatPreinscription
+	var entree *InscriptionHistorique
+	for _, h := range ins.HistoriqueOrdonne() {
+		if h.CreatedAt.After(date) {
+			break
+		}
+		h := h
+		etat = h.NouvelEtat
+		entree = &h
+	}
+	return etat, entree, true
+}
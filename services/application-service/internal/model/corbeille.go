@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// ElementCorbeille is a deleted inscription as listed in the trash, with the date
+// it is purged for good unless restored first.
+type ElementCorbeille struct {
+	Inscription
+	SupprimeeLe time.Time `json:"supprimee_le"`
+	PurgeeLe    time.Time `json:"purgee_le"`
+}
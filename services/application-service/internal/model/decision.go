@@ -2,12 +2,16 @@ package model
 
 import "time"
 
-// Decision records an admin decision on an inscription.
+// Decision records an admin decision on an inscription. Admission decisions taken
+// while a jury session of the formation is open are attached to it.
 type Decision struct {
 	ID            uint            `json:"id" gorm:"primaryKey"`
 	InscriptionID uint            `json:"inscription_id" gorm:"not null;index"`
 	DecidePar     string          `json:"decide_par" gorm:"type:varchar(100);not null"`
 	Etat          EtatInscription `json:"etat" gorm:"type:varchar(20);not null"`
 	Commentaire   string          `json:"commentaire" gorm:"type:text"`
+	SessionJuryID *uint           `json:"session_jury_id,omitempty" gorm:"index"`
 	CreatedAt     time.Time       `json:"created_at"`
+
+	Motifs []MotifRefus `json:"motifs,omitempty" gorm:"many2many:decision_motifs;joinForeignKey:DecisionID;joinReferences:MotifRefusID"`
 }
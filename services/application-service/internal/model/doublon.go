@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// Review status of a suspected duplicate.
+const (
+	StatutDoublonEnAttente = "EN_ATTENTE"
+	StatutDoublonFusionne  = "FUSIONNE"
+	StatutDoublonEcarte    = "ECARTE"
+)
+
+// What two inscriptions of a suspected duplicate have in common.
+const (
+	CritereEmail     = "EMAIL"     // same e-mail once normalised (case, +tag, Gmail dots)
+	CritereTelephone = "TELEPHONE" // same last nine digits of the phone number
+	CritereNom       = "NOM"       // similar names, accents and case ignored
+	CritereCIN       = "CIN"       // same national identity card number
+	CritereCNE       = "CNE"       // same national student code (CNE / Massar)
+)
+
+// DoublonSuspect pairs two inscriptions of the same institution, filed under
+// different candidate accounts, that likely belong to the same person.
+// InscriptionID is always the older of the two. Staff either merge the accounts
+// or dismiss the suspicion; the resolution is kept here and in the history of both
+// inscriptions, and a dismissed pair is not raised again.
+type DoublonSuspect struct {
+	ID                 uint       `json:"id" gorm:"primaryKey"`
+	InscriptionID      uint       `json:"inscription_id" gorm:"not null;uniqueIndex:idx_doublons_paire"`
+	AutreInscriptionID uint       `json:"autre_inscription_id" gorm:"not null;uniqueIndex:idx_doublons_paire;index"`
+	EtablissementID    string     `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';index"`
+	Score              float64    `json:"score" gorm:"not null"`
+	Criteres           JSON       `json:"criteres" gorm:"type:jsonb;not null"`
+	SimilariteNom      float64    `json:"similarite_nom"`
+	Statut             string     `json:"statut" gorm:"type:varchar(20);not null;default:'EN_ATTENTE';index"`
+	ConserveeID        *uint      `json:"conservee_id,omitempty"`
+	ResoluPar          string     `json:"resolu_par,omitempty" gorm:"type:varchar(100)"`
+	ResoluLe           *time.Time `json:"resolu_le,omitempty"`
+	Commentaire        string     `json:"commentaire,omitempty" gorm:"type:text"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	Inscription      *Inscription `json:"inscription,omitempty" gorm:"foreignKey:InscriptionID"`
+	AutreInscription *Inscription `json:"autre_inscription,omitempty" gorm:"foreignKey:AutreInscriptionID"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (DoublonSuspect) TableName() string {
+	return "doublons_suspects"
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// Operators supported by eligibility rules.
+const (
+	OperateurEgal       = "eq"
+	OperateurDifferent  = "ne"
+	OperateurSuperieur  = "gt"
+	OperateurSupOuEgal  = "gte"
+	OperateurInferieur  = "lt"
+	OperateurInfOuEgal  = "lte"
+	OperateurParmi      = "in"
+	OperateurHorsDe     = "not_in"
+	OperateurContient   = "contains"
+	OperateurRenseigne  = "exists"
+	OperateurCorrespond = "regex"
+)
+
+// Eligibility outcome stored on an inscription.
+const (
+	EligibiliteEligible    = "ELIGIBLE"
+	EligibiliteNonEligible = "NON_ELIGIBLE"
+)
+
+// RegleEligibilite is one entry requirement of a formation, evaluated over the
+// application form answers, e.g. {"champ": "experience_annees", "operateur": "gte", "valeur": 2}.
+type RegleEligibilite struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	FormationID uint      `json:"formation_id" gorm:"not null;index"`
+	Code        string    `json:"code" gorm:"type:varchar(50);not null"`
+	Libelle     string    `json:"libelle" gorm:"type:varchar(255);not null"`
+	Champ       string    `json:"champ" gorm:"type:varchar(100);not null"`
+	Operateur   string    `json:"operateur" gorm:"type:varchar(20);not null"`
+	Valeur      JSON      `json:"valeur" gorm:"type:jsonb"`
+	Ordre       int       `json:"ordre" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (RegleEligibilite) TableName() string {
+	return "regles_eligibilite"
+}
+
+// ResultatRegle is the outcome of one rule for one inscription.
+type ResultatRegle struct {
+	Code       string `json:"code"`
+	Libelle    string `json:"libelle"`
+	Satisfaite bool   `json:"satisfaite"`
+	Detail     string `json:"detail,omitempty"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// CreneauEntretien is an interview slot published by a coordinator for a formation.
+// Jury identifies the interview panel; a panel cannot sit in two overlapping slots.
+type CreneauEntretien struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	FormationID     uint      `json:"formation_id" gorm:"not null;index"`
+	EtablissementID string    `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';index"`
+	Debut           time.Time `json:"debut" gorm:"type:timestamptz;not null"`
+	Fin             time.Time `json:"fin" gorm:"type:timestamptz;not null"`
+	Capacite        int       `json:"capacite" gorm:"not null;default:1"`
+	Lieu            string    `json:"lieu,omitempty" gorm:"type:varchar(255)"`
+	LienVisio       string    `json:"lien_visio,omitempty" gorm:"type:varchar(500)"`
+	Jury            string    `json:"jury" gorm:"type:varchar(100);not null;index"`
+	CreePar         string    `json:"cree_par" gorm:"type:varchar(100);not null"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Reservees is the number of bookings, filled in by the repository.
+	Reservees int `json:"reservees" gorm:"-"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (CreneauEntretien) TableName() string {
+	return "creneaux_entretien"
+}
+
+// ReservationEntretien is a candidate's booking of an interview slot.
+// An inscription holds at most one booking; rescheduling moves it to another slot.
+type ReservationEntretien struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CreneauID     uint      `json:"creneau_id" gorm:"not null;index"`
+	InscriptionID uint      `json:"inscription_id" gorm:"not null;uniqueIndex"`
+	CandidatID    string    `json:"candidat_id" gorm:"type:varchar(100);not null;index"`
+	Sequence      int       `json:"sequence" gorm:"not null;default:0"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	Creneau *CreneauEntretien `json:"creneau,omitempty" gorm:"foreignKey:CreneauID"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (ReservationEntretien) TableName() string {
+	return "reservations_entretien"
+}
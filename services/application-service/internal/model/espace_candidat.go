@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+// Next actions shown on the candidate's home page: what the candidate has to do, or
+// what they are waiting for.
+const (
+	ActionCompleterDossier       = "COMPLETER_DOSSIER"
+	ActionSoumettreDossier       = "SOUMETTRE_DOSSIER"
+	ActionAttendreExamen         = "ATTENDRE_EXAMEN"
+	ActionReserverEntretien      = "RESERVER_ENTRETIEN"
+	ActionPasserEntretien        = "PASSER_ENTRETIEN"
+	ActionAttendreDecision       = "ATTENDRE_DECISION"
+	ActionConfirmerOffre         = "CONFIRMER_OFFRE"
+	ActionAttendreInscription    = "ATTENDRE_INSCRIPTION"
+	ActionDeposerRecours         = "DEPOSER_RECOURS"
+	ActionAttendreRecours        = "ATTENDRE_RECOURS"
+	ActionSuivreFormation        = "SUIVRE_FORMATION"
+	ActionTelechargerAttestation = "TELECHARGER_ATTESTATION"
+)
+
+// Deadlines shown on the candidate's home page.
+const (
+	EcheanceClotureInscriptions = "CLOTURE_INSCRIPTIONS"
+	EcheanceEntretien           = "ENTRETIEN"
+	EcheanceConfirmationOffre   = "CONFIRMATION_OFFRE"
+	EcheanceRecours             = "RECOURS"
+)
+
+// ActionAttendue is the next step of an inscription, with its deadline if it has one.
+type ActionAttendue struct {
+	Code     string     `json:"code"`
+	Libelle  string     `json:"libelle"`
+	Echeance *time.Time `json:"echeance,omitempty"`
+}
+
+// Echeance is an upcoming deadline of an inscription.
+type Echeance struct {
+	Type    string    `json:"type"`
+	Date    time.Time `json:"date"`
+	Libelle string    `json:"libelle"`
+}
+
+// FormationCandidat is what the candidate's home page shows of a formation.
+type FormationCandidat struct {
+	ID                   uint       `json:"id"`
+	Titre                string     `json:"titre"`
+	DateOuverture        *time.Time `json:"date_ouverture"`
+	DateFermeture        *time.Time `json:"date_fermeture"`
+	InscriptionsOuvertes bool       `json:"inscriptions_ouvertes"`
+}
+
+// PieceDossier is one required document of the dossier and whether it was uploaded.
+type PieceDossier struct {
+	Type       string     `json:"type"`
+	Fournie    bool       `json:"fournie"`
+	DocumentID *uint      `json:"document_id,omitempty"`
+	NomFichier string     `json:"nom_fichier,omitempty"`
+	DeposeLe   *time.Time `json:"depose_le,omitempty"`
+}
+
+// ChecklistDocuments is the status of the required documents of a dossier. Autres
+// counts the documents uploaded besides them, including those without a type.
+type ChecklistDocuments struct {
+	Complet bool           `json:"complet"`
+	Pieces  []PieceDossier `json:"pieces"`
+	Autres  int            `json:"autres"`
+}
+
+// DossierCandidat is an inscription as shown on the candidate's home page. Formation
+// and Documents are nil when the service holding them could not answer in time.
+type DossierCandidat struct {
+	Inscription     Inscription         `json:"inscription"`
+	Formation       *FormationCandidat  `json:"formation"`
+	Documents       *ChecklistDocuments `json:"documents"`
+	ProchaineAction *ActionAttendue     `json:"prochaine_action"`
+	Echeances       []Echeance          `json:"echeances"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// PriseEnCharge marks a dossier as being reviewed by a staff member, so that
+// colleagues leave it alone. A claim only holds while the dossier stays in the state
+// it was claimed in, and until ExpireLe, so that forgotten claims free themselves.
+type PriseEnCharge struct {
+	InscriptionID uint            `json:"inscription_id" gorm:"primaryKey;autoIncrement:false"`
+	Etat          EtatInscription `json:"etat" gorm:"type:varchar(20);not null"`
+	Par           string          `json:"par" gorm:"type:varchar(100);not null;index"`
+	Le            time.Time       `json:"le" gorm:"not null"`
+	ExpireLe      time.Time       `json:"expire_le" gorm:"not null"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (PriseEnCharge) TableName() string {
+	return "prises_en_charge"
+}
+
+// Active reports whether the claim still holds for a dossier in the given state.
+func (p *PriseEnCharge) Active(etat EtatInscription, maintenant time.Time) bool {
+	return p.Etat == etat && maintenant.Before(p.ExpireLe)
+}
+
+// ElementFile is one dossier of a coordinator's work queue. RisqueSla is the share of
+// the state's SLA already used: 1 or more means the SLA is breached.
+type ElementFile struct {
+	Inscription   Inscription    `json:"inscription"`
+	EntreeEtat    time.Time      `json:"entree_etat"`
+	AgeHeures     int            `json:"age_heures"`
+	DelaiSla      *int           `json:"delai_sla_heures,omitempty"`
+	Echeance      *time.Time     `json:"echeance,omitempty"`
+	RisqueSla     *float64       `json:"risque_sla,omitempty"`
+	PriseEnCharge *PriseEnCharge `json:"prise_en_charge,omitempty"`
+	ParMoi        bool           `json:"par_moi"`
+}
+
+// CompteFile sums up the work queue of one formation.
+type CompteFile struct {
+	FormationID  uint   `json:"formation_id"`
+	Titre        string `json:"titre"`
+	Total        int    `json:"total"`
+	EnRetard     int    `json:"en_retard"`
+	PrisEnCharge int    `json:"pris_en_charge"`
+}
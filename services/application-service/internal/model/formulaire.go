@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Formulaire is the application form a coordinator defines for a formation,
+// expressed as a JSON Schema (object with properties). Candidates' answers are
+// validated against it and stored on Inscription.Reponses.
+type Formulaire struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	FormationID uint      `json:"formation_id" gorm:"not null;uniqueIndex"`
+	Schema      JSON      `json:"schema" gorm:"type:jsonb;not null"`
+	Version     int       `json:"version" gorm:"not null;default:1"`
+	ModifiePar  string    `json:"modifie_par" gorm:"type:varchar(100);not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Groupe is a cohort of a formation's enrolled learners (e.g. "Groupe A", "Week-end").
+type Groupe struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	FormationID     uint      `json:"formation_id" gorm:"not null;uniqueIndex:idx_groupes_formation_nom"`
+	EtablissementID string    `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';index"`
+	Nom             string    `json:"nom" gorm:"type:varchar(100);not null;uniqueIndex:idx_groupes_formation_nom"`
+	Description     string    `json:"description,omitempty" gorm:"type:text"`
+	Capacite        int       `json:"capacite" gorm:"not null"`
+	CreePar         string    `json:"cree_par" gorm:"type:varchar(100);not null"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+
+	// Effectif is the number of assigned learners, filled in by the repository.
+	Effectif int `json:"effectif" gorm:"-"`
+}
+
+// MembreGroupe assigns an enrolled inscription to a group. An inscription belongs
+// to at most one group.
+type MembreGroupe struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	GroupeID      uint      `json:"groupe_id" gorm:"not null;index"`
+	InscriptionID uint      `json:"inscription_id" gorm:"not null;uniqueIndex"`
+	AffectePar    string    `json:"affecte_par" gorm:"type:varchar(100);not null"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	Inscription *Inscription `json:"inscription,omitempty" gorm:"foreignKey:InscriptionID"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (MembreGroupe) TableName() string {
+	return "membres_groupes"
+}
@@ -2,12 +2,27 @@ package model
 
 import "time"
 
+// Events recorded in the history besides plain state changes.
+const (
+	EvenementTransfert     = "TRANSFERT"
+	EvenementFusionDoublon = "FUSION_DOUBLON"
+	EvenementDoublonEcarte = "DOUBLON_ECARTE"
+	EvenementAnonymisation = "ANONYMISATION"
+	EvenementSuppression   = "SUPPRESSION"
+	EvenementRestauration  = "RESTAURATION"
+)
+
 // InscriptionHistorique records audit log entries for inscription state changes.
+// Evenement is empty for a plain state change; other events describe themselves
+// in Commentaire and Details.
 type InscriptionHistorique struct {
 	ID            uint            `json:"id" gorm:"primaryKey"`
 	InscriptionID uint            `json:"inscription_id" gorm:"not null;index"`
 	AncienEtat    EtatInscription `json:"ancien_etat" gorm:"type:varchar(20);not null"`
 	NouvelEtat    EtatInscription `json:"nouvel_etat" gorm:"type:varchar(20);not null"`
 	ModifiePar    string          `json:"modifie_par" gorm:"type:varchar(100);not null"`
+	Evenement     string          `json:"evenement,omitempty" gorm:"type:varchar(30);index"`
+	Commentaire   string          `json:"commentaire,omitempty" gorm:"type:text"`
+	Details       JSON            `json:"details,omitempty" gorm:"type:jsonb"`
 	CreatedAt     time.Time       `json:"created_at"`
 }
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// CleIdempotence records the outcome of a mutating request sent with an
+// Idempotency-Key header, so that a retry replays the first response instead of
+// applying the change twice. Keys are scoped to the caller.
+// Statut is 0 while the first request is still being processed.
+type CleIdempotence struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Cle           string    `json:"cle" gorm:"type:varchar(255);not null;uniqueIndex:idx_cles_idempotence_utilisateur_cle,priority:2"`
+	UtilisateurID string    `json:"utilisateur_id" gorm:"type:varchar(100);not null;uniqueIndex:idx_cles_idempotence_utilisateur_cle,priority:1"`
+	Methode       string    `json:"methode" gorm:"type:varchar(10);not null"`
+	Chemin        string    `json:"chemin" gorm:"type:text;not null"`
+	Empreinte     string    `json:"empreinte" gorm:"type:char(64);not null"`
+	Statut        int       `json:"statut" gorm:"not null;default:0"`
+	ContentType   string    `json:"content_type" gorm:"type:varchar(100)"`
+	Reponse       []byte    `json:"-" gorm:"type:bytea"`
+	ExpireLe      time.Time `json:"expire_le" gorm:"type:timestamptz;not null;index"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (CleIdempotence) TableName() string {
+	return "cles_idempotence"
+}
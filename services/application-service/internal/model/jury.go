@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// StatutSessionJury is the lifecycle of a jury deliberation session.
+type StatutSessionJury string
+
+const (
+	SessionJuryOuverte StatutSessionJury = "OUVERTE"
+	SessionJuryClose   StatutSessionJury = "CLOSE"
+)
+
+// EtatsDecisionJury are the admission decisions taken by a jury.
+var EtatsDecisionJury = []EtatInscription{EtatAccepte, EtatListeAttente, EtatRefuse}
+
+// EstDecisionJury reports whether moving a dossier to etat is an admission decision.
+func EstDecisionJury(etat EtatInscription) bool {
+	for _, e := range EtatsDecisionJury {
+		if e == etat {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionJury is a deliberation session of a formation's admission jury. The
+// admission decisions taken while it is open are attached to it; closing it locks
+// them and freezes the minutes (procès-verbal) in Proces. A formation has at most
+// one open session.
+type SessionJury struct {
+	ID              uint              `json:"id" gorm:"primaryKey"`
+	FormationID     uint              `json:"formation_id" gorm:"not null;index;uniqueIndex:idx_sessions_jury_ouverte,where:statut = 'OUVERTE'"`
+	EtablissementID string            `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';index"`
+	Intitule        string            `json:"intitule" gorm:"type:varchar(200);not null"`
+	TenueLe         time.Time         `json:"tenue_le" gorm:"not null"`
+	Lieu            string            `json:"lieu" gorm:"type:varchar(200)"`
+	President       string            `json:"president" gorm:"type:varchar(200);not null"`
+	Membres         JSON              `json:"membres" gorm:"type:jsonb;not null"`
+	Statut          StatutSessionJury `json:"statut" gorm:"type:varchar(10);not null;default:'OUVERTE'"`
+	OuvertePar      string            `json:"ouverte_par" gorm:"type:varchar(100);not null"`
+	CloturePar      *string           `json:"cloture_par" gorm:"type:varchar(100)"`
+	ClotureLe       *time.Time        `json:"cloture_le"`
+	Proces          JSON              `json:"proces,omitempty" gorm:"type:jsonb"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+
+	Decisions []Decision `json:"decisions,omitempty" gorm:"foreignKey:SessionJuryID"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (SessionJury) TableName() string {
+	return "sessions_jury"
+}
+
+// MembreJury is a jury member attending a session.
+type MembreJury struct {
+	Nom     string `json:"nom" binding:"required,max=200"`
+	Qualite string `json:"qualite" binding:"max=200"`
+}
+
+// LigneProces is one dossier of the minutes of a closed session: the last decision
+// the session took on it, with the jury score at closing time.
+type LigneProces struct {
+	InscriptionID uint            `json:"inscription_id"`
+	Reference     string          `json:"reference"`
+	NomComplet    string          `json:"nom_complet"`
+	Etat          EtatInscription `json:"etat"`
+	Score         *float64        `json:"score"`
+	Motifs        []string        `json:"motifs,omitempty"`
+	DecisionID    uint            `json:"decision_id"`
+}
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// StatutEnvoi is the delivery status of one recipient of a bulk message. Besides the
+// statuses reported by notification-service (PENDING, SENT, RETRYING, DEAD), ECHEC
+// means the message could not be handed over to it yet.
+type StatutEnvoi string
+
+const (
+	EnvoiEchec       StatutEnvoi = "ECHEC"
+	EnvoiEnAttente   StatutEnvoi = "PENDING"
+	EnvoiEnvoye      StatutEnvoi = "SENT"
+	EnvoiNouvelEssai StatutEnvoi = "RETRYING"
+	EnvoiAbandonne   StatutEnvoi = "DEAD"
+)
+
+// Final reports whether the status can no longer change.
+func (s StatutEnvoi) Final() bool {
+	return s == EnvoiEnvoye || s == EnvoiAbandonne
+}
+
+// MessageGroupe is an e-mail sent by staff to a segment of candidates, selected with
+// the filters of the inscription listing. Sujet and Corps may use the variables of
+// VariablesMessage, rendered for each recipient.
+type MessageGroupe struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	EtablissementID string    `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';index"`
+	Filtre          JSON      `json:"filtre" gorm:"type:jsonb;not null"`
+	Sujet           string    `json:"sujet" gorm:"type:varchar(255);not null"`
+	Corps           string    `json:"corps" gorm:"type:text;not null"`
+	Destinataires   int       `json:"destinataires" gorm:"not null"`
+	EnvoyePar       string    `json:"envoye_par" gorm:"type:varchar(100);not null"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	Envois []EnvoiMessage `json:"envois,omitempty" gorm:"foreignKey:MessageID"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (MessageGroupe) TableName() string {
+	return "messages_groupes"
+}
+
+// EnvoiMessage tracks the delivery of a bulk message to one candidate, with the
+// subject and body rendered for them.
+type EnvoiMessage struct {
+	ID             uint        `json:"id" gorm:"primaryKey"`
+	MessageID      uint        `json:"message_id" gorm:"not null;uniqueIndex:idx_envois_messages_message_inscription"`
+	InscriptionID  uint        `json:"inscription_id" gorm:"not null;uniqueIndex:idx_envois_messages_message_inscription"`
+	Destinataire   string      `json:"destinataire" gorm:"type:varchar(255);not null"`
+	Sujet          string      `json:"sujet" gorm:"type:text;not null"`
+	Corps          string      `json:"corps" gorm:"type:text;not null"`
+	NotificationID *string     `json:"notification_id" gorm:"type:varchar(64)"`
+	Statut         StatutEnvoi `json:"statut" gorm:"type:varchar(10);not null;index"`
+	Erreur         string      `json:"erreur,omitempty" gorm:"type:text"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (EnvoiMessage) TableName() string {
+	return "envois_messages"
+}
+
+// CleIdempotence is the key the delivery is queued with, so that retries never send
+// the message twice to the same candidate.
+func (e *EnvoiMessage) CleIdempotence() string {
+	return fmt.Sprintf("message-%d-%d", e.MessageID, e.InscriptionID)
+}
+
+// VariablesMessage are the per-candidate variables of bulk messages, written
+// {{nom_complet}} in the subject or body.
+var VariablesMessage = []string{"nom_complet", "email", "reference", "formation", "etat"}
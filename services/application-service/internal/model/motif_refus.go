@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// MotifRefus is a managed refusal reason code.
+// Codes with an empty EtablissementID form the global catalogue shared by all institutions.
+type MotifRefus struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	EtablissementID string    `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';uniqueIndex:idx_motifs_refus_etablissement_code"`
+	Code            string    `json:"code" gorm:"type:varchar(50);not null;uniqueIndex:idx_motifs_refus_etablissement_code"`
+	Libelle         string    `json:"libelle" gorm:"type:varchar(255);not null"`
+	MessageCandidat string    `json:"message_candidat" gorm:"type:text"`
+	Actif           bool      `json:"actif" gorm:"not null;default:true"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (MotifRefus) TableName() string {
+	return "motifs_refus"
+}
+
+// DefaultMotifsRefus is the global catalogue seeded at startup.
+var DefaultMotifsRefus = []MotifRefus{
+	{
+		Code:            "PREREQUIS_NON_SATISFAITS",
+		Libelle:         "Prérequis non satisfaits",
+		MessageCandidat: "Votre profil ne satisfait pas les prérequis d'accès à cette formation.",
+	},
+	{
+		Code:            "DOSSIER_INCOMPLET",
+		Libelle:         "Dossier incomplet",
+		MessageCandidat: "Votre dossier est incomplet : certaines pièces obligatoires sont manquantes.",
+	},
+	{
+		Code:            "DOCUMENTS_NON_CONFORMES",
+		Libelle:         "Documents non conformes",
+		MessageCandidat: "Certaines pièces fournies ne sont pas conformes ou sont illisibles.",
+	},
+	{
+		Code:            "CAPACITE_ATTEINTE",
+		Libelle:         "Capacité d'accueil atteinte",
+		MessageCandidat: "La capacité d'accueil de la formation est atteinte.",
+	},
+	{
+		Code:            "VOEU_PRIORITAIRE_OBTENU",
+		Libelle:         "Voeu mieux classé obtenu",
+		MessageCandidat: "Vous avez été admis dans une formation que vous avez classée avant celle-ci.",
+	},
+	{
+		Code:            "HORS_DELAI",
+		Libelle:         "Candidature hors délai",
+		MessageCandidat: "Votre dossier a été déposé en dehors de la période d'inscription.",
+	},
+	{
+		Code:            "AUTRE",
+		Libelle:         "Autre motif",
+		MessageCandidat: "Votre candidature n'a pas été retenue. Consultez le commentaire du jury.",
+	},
+}
+
+// StatMotifRefus is one row of the refusal breakdown by reason.
+type StatMotifRefus struct {
+	Code    string `json:"code"`
+	Libelle string `json:"libelle"`
+	Total   int64  `json:"total"`
+}
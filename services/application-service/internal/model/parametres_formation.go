@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// What happens to an application that fails its formation's eligibility rules.
+const (
+	ModeEligibiliteSignaler = "SIGNALER" // keep the dossier, flag it NON_ELIGIBLE
+	ModeEligibiliteRejeter  = "REJETER"  // refuse creation, auto-refuse on submission
+)
+
+// ParametresFormation holds the per-formation settings owned by this service.
+// A formation without a row uses the defaults from DefaultParametresFormation.
+// SeuilPresence is the minimum attendance rate (percent) required of learners;
+// nil falls back to the service-wide ATTENDANCE_THRESHOLD_PERCENT.
+// MoyenneValidation is the average (out of 20) a learner needs to validate the formation.
+// Capacite is the number of seats offered by the allocation run; nil means unlimited.
+// DelaiConfirmation is the number of days an accepted candidate has to confirm their
+// offer; nil falls back to OFFER_CONFIRMATION_DAYS.
+// DelaisSla gives, per state, the hours a dossier may stay in it before an SLA breach
+// (e.g. {"EN_VALIDATION": 240}); without it DECISION_SLA_HOURS applies. Breaches are
+// reported to EmailCoordinateur, then escalated to EmailEscalade.
+type ParametresFormation struct {
+	FormationID       uint      `json:"formation_id" gorm:"primaryKey;autoIncrement:false"`
+	ModeEligibilite   string    `json:"mode_eligibilite" gorm:"type:varchar(20);not null;default:'SIGNALER'"`
+	SeuilPresence     *int      `json:"seuil_presence"`
+	MoyenneValidation float64   `json:"moyenne_validation" gorm:"not null;default:10"`
+	Capacite          *int      `json:"capacite"`
+	DelaiConfirmation *int      `json:"delai_confirmation"`
+	DelaisSla         JSON      `json:"delais_sla,omitempty" gorm:"type:jsonb"`
+	EmailCoordinateur string    `json:"email_coordinateur,omitempty" gorm:"type:varchar(255)"`
+	EmailEscalade     string    `json:"email_escalade,omitempty" gorm:"type:varchar(255)"`
+	ModifiePar        string    `json:"modifie_par" gorm:"type:varchar(100)"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (ParametresFormation) TableName() string {
+	return "parametres_formations"
+}
+
+// DefaultParametresFormation returns the settings used when a formation has none.
+func DefaultParametresFormation(formationID uint) *ParametresFormation {
+	return &ParametresFormation{
+		FormationID:       formationID,
+		ModeEligibilite:   ModeEligibiliteSignaler,
+		MoyenneValidation: 10,
+	}
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// PublicationResultats is a frozen, public list of the admitted and waitlisted
+// candidates of a formation. Publishing again creates the next version, so that a
+// corrected list never silently replaces the one already seen; Rectificatif is the
+// public note explaining the correction.
+type PublicationResultats struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	FormationID     uint      `json:"formation_id" gorm:"not null;uniqueIndex:idx_publications_formation_version"`
+	Version         int       `json:"version" gorm:"not null;uniqueIndex:idx_publications_formation_version"`
+	EtablissementID string    `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';index"`
+	Formation       string    `json:"formation" gorm:"type:varchar(255);not null"`
+	Rectificatif    string    `json:"rectificatif,omitempty" gorm:"type:text"`
+	Admis           int       `json:"admis" gorm:"not null"`
+	ListeAttente    int       `json:"liste_attente" gorm:"not null"`
+	Lignes          JSON      `json:"lignes,omitempty" gorm:"type:jsonb;not null"`
+	PublieePar      string    `json:"publiee_par" gorm:"type:varchar(100);not null"`
+	PublieeLe       time.Time `json:"publiee_le" gorm:"not null"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (PublicationResultats) TableName() string {
+	return "publications_resultats"
+}
+
+// LignePublication is one candidate of a published list, identified only by their
+// dossier number and initials. Position is the rank on the waiting list.
+type LignePublication struct {
+	Reference string          `json:"reference"`
+	Initiales string          `json:"initiales"`
+	Etat      EtatInscription `json:"etat"`
+	Position  *int            `json:"position,omitempty"`
+}
+
+// Initiales masks a full name as its initials: "Fatima-Zahra El Idrissi" gives
+// "F.-Z. E. I.".
+func Initiales(nom string) string {
+	var b strings.Builder
+	debut := true
+	for _, r := range strings.TrimSpace(nom) {
+		switch {
+		case r == '-':
+			b.WriteRune('-')
+			debut = true
+		case unicode.IsSpace(r):
+			if !debut {
+				b.WriteRune(' ')
+			}
+			debut = true
+		case debut && unicode.IsLetter(r):
+			b.WriteRune(unicode.ToUpper(r))
+			b.WriteRune('.')
+			debut = false
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,15 @@
+package model
+
+// ResultatRecherche is one hit of the full-text candidate search.
+type ResultatRecherche struct {
+	Inscription Inscription `json:"inscription"`
+	Score       float64     `json:"score"`
+	Surlignage  Surlignage  `json:"surlignage"`
+}
+
+// Surlignage holds the matched fields with the hits wrapped in <mark> tags.
+type Surlignage struct {
+	NomComplet string `json:"nom_complet"`
+	Email      string `json:"email"`
+	Notes      string `json:"notes,omitempty"`
+}
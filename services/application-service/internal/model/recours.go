@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// StatutRecours represents the review state of an appeal.
+type StatutRecours string
+
+const (
+	RecoursEnAttente StatutRecours = "EN_ATTENTE"
+	RecoursAccepte   StatutRecours = "ACCEPTE"
+	RecoursRejete    StatutRecours = "REJETE"
+)
+
+// Recours represents an appeal filed by a candidate against a refusal.
+type Recours struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	InscriptionID uint          `json:"inscription_id" gorm:"not null;index"`
+	CandidatID    string        `json:"candidat_id" gorm:"type:varchar(100);not null;index"`
+	Justification string        `json:"justification" gorm:"type:text;not null"`
+	Statut        StatutRecours `json:"statut" gorm:"type:varchar(20);not null;default:'EN_ATTENTE';index"`
+	ExaminePar    string        `json:"examine_par,omitempty" gorm:"type:varchar(100)"`
+	Reponse       string        `json:"reponse,omitempty" gorm:"type:text"`
+	DateDecision  *time.Time    `json:"date_decision,omitempty" gorm:"type:timestamptz"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+
+	Documents []RecoursDocument `json:"documents,omitempty" gorm:"foreignKey:RecoursID"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (Recours) TableName() string {
+	return "recours"
+}
+
+// RecoursDocument links a supporting document (stored in document-service) to an appeal.
+type RecoursDocument struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	RecoursID  uint      `json:"recours_id" gorm:"not null;index"`
+	DocumentID uint      `json:"document_id" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// ModuleFormation is a graded unit of a formation. Grades are out of 20 and the
+// final average weighs each module by its coefficient. A grade below NoteEliminatoire
+// fails the formation whatever the average.
+type ModuleFormation struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	FormationID      uint      `json:"formation_id" gorm:"not null;uniqueIndex:idx_modules_formation_code"`
+	EtablissementID  string    `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';index"`
+	Code             string    `json:"code" gorm:"type:varchar(50);not null;uniqueIndex:idx_modules_formation_code"`
+	Intitule         string    `json:"intitule" gorm:"type:varchar(255);not null"`
+	Coefficient      float64   `json:"coefficient" gorm:"not null;default:1"`
+	NoteEliminatoire *float64  `json:"note_eliminatoire"`
+	Ordre            int       `json:"ordre" gorm:"not null;default:0"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (ModuleFormation) TableName() string {
+	return "modules_formation"
+}
+
+// Note is the grade of one learner in one module.
+type Note struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ModuleID      uint      `json:"module_id" gorm:"not null;uniqueIndex:idx_notes_module_inscription"`
+	InscriptionID uint      `json:"inscription_id" gorm:"not null;uniqueIndex:idx_notes_module_inscription;index"`
+	Valeur        float64   `json:"valeur" gorm:"not null"`
+	SaisiePar     string    `json:"saisie_par" gorm:"type:varchar(100);not null"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Attestation is the completion certificate issued to a learner whose formation
+// was validated. CodeVerification lets third parties check its authenticity.
+type Attestation struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	InscriptionID    uint      `json:"inscription_id" gorm:"not null;uniqueIndex"`
+	Numero           string    `json:"numero" gorm:"type:varchar(50);not null;uniqueIndex"`
+	CodeVerification string    `json:"code_verification" gorm:"type:varchar(32);not null;uniqueIndex"`
+	Moyenne          *float64  `json:"moyenne"`
+	DelivreePar      string    `json:"delivree_par" gorm:"type:varchar(100);not null"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ResultatDeliberation is the outcome of the validation rules for one learner.
+// Decision is empty while some module is still ungraded.
+type ResultatDeliberation struct {
+	InscriptionID    uint            `json:"inscription_id"`
+	NomComplet       string          `json:"nom_complet"`
+	Moyenne          *float64        `json:"moyenne"`
+	TauxPresence     *float64        `json:"taux_presence"`
+	Decision         EtatInscription `json:"decision,omitempty"`
+	Motifs           []string        `json:"motifs,omitempty"`
+	ModulesManquants []string        `json:"modules_manquants,omitempty"`
+}
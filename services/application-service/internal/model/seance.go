@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// StatutPresence is a learner's attendance at one session.
+type StatutPresence string
+
+const (
+	PresencePresent StatutPresence = "PRESENT"
+	PresenceAbsent  StatutPresence = "ABSENT"
+	PresenceExcuse  StatutPresence = "EXCUSE"
+)
+
+// Seance is a training session of a formation. A session with a GroupeID only
+// concerns that group's learners; otherwise it concerns every enrolled learner.
+type Seance struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	FormationID     uint      `json:"formation_id" gorm:"not null;index"`
+	GroupeID        *uint     `json:"groupe_id,omitempty" gorm:"index"`
+	EtablissementID string    `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';index"`
+	Titre           string    `json:"titre" gorm:"type:varchar(255);not null"`
+	Debut           time.Time `json:"debut" gorm:"type:timestamptz;not null"`
+	Fin             time.Time `json:"fin" gorm:"type:timestamptz;not null"`
+	CreePar         string    `json:"cree_par" gorm:"type:varchar(100);not null"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Presence records the attendance of one enrolled learner at one session.
+type Presence struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	SeanceID      uint           `json:"seance_id" gorm:"not null;uniqueIndex:idx_presences_seance_inscription"`
+	InscriptionID uint           `json:"inscription_id" gorm:"not null;uniqueIndex:idx_presences_seance_inscription;index"`
+	Statut        StatutPresence `json:"statut" gorm:"type:varchar(20);not null"`
+	Commentaire   string         `json:"commentaire,omitempty" gorm:"type:text"`
+	SaisiPar      string         `json:"saisi_par" gorm:"type:varchar(100);not null"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// TauxPresence summarises a learner's attendance. Excused absences are left out
+// of the rate; Taux is nil until some attendance has been recorded.
+type TauxPresence struct {
+	InscriptionID uint     `json:"inscription_id"`
+	NomComplet    string   `json:"nom_complet"`
+	Presents      int      `json:"presents"`
+	Absents       int      `json:"absents"`
+	Excuses       int      `json:"excuses"`
+	Taux          *float64 `json:"taux"`
+	Seuil         int      `json:"seuil"`
+	SousSeuil     bool     `json:"sous_seuil"`
+}
+
+// AlerteAssiduite records that a learner fell below the attendance threshold.
+// At most one alert is open per inscription; it is closed once the rate recovers.
+type AlerteAssiduite struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	InscriptionID uint       `json:"inscription_id" gorm:"not null;index;uniqueIndex:idx_alertes_assiduite_ouverte,where:active"`
+	FormationID   uint       `json:"formation_id" gorm:"not null;index"`
+	Taux          float64    `json:"taux" gorm:"not null"`
+	Seuil         int        `json:"seuil" gorm:"not null"`
+	Active        bool       `json:"active" gorm:"not null;default:true;index"`
+	LeveeLe       *time.Time `json:"levee_le,omitempty" gorm:"type:timestamptz"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (AlerteAssiduite) TableName() string {
+	return "alertes_assiduite"
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Escalation levels of an SLA breach.
+const (
+	NiveauSlaCoordinateur = 1 // the formation's coordinator was alerted
+	NiveauSlaAdmin        = 2 // escalated to the institution administrator
+)
+
+// DelaisSla maps an inscription state to the number of hours a dossier may stay in it.
+type DelaisSla map[EtatInscription]int
+
+// DelaisSlaDe converts durations keyed by state name, as read from the configuration.
+func DelaisSlaDe(heures map[string]int) DelaisSla {
+	delais := DelaisSla{}
+	for etat, h := range heures {
+		delais[EtatInscription(etat)] = h
+	}
+	return delais
+}
+
+// DepassementSla records a dossier that stayed in a state longer than its formation's
+// SLA allows. EntreeEtat is when the dossier entered the state, from its history, and
+// Echeance when the SLA ran out. The breach is resolved once the dossier leaves the
+// state; a dossier that re-enters the state may breach again.
+type DepassementSla struct {
+	ID                     uint            `json:"id" gorm:"primaryKey"`
+	InscriptionID          uint            `json:"inscription_id" gorm:"not null;uniqueIndex:idx_depassements_sla_entree"`
+	Etat                   EtatInscription `json:"etat" gorm:"type:varchar(20);not null;uniqueIndex:idx_depassements_sla_entree"`
+	EntreeEtat             time.Time       `json:"entree_etat" gorm:"not null;uniqueIndex:idx_depassements_sla_entree"`
+	FormationID            uint            `json:"formation_id" gorm:"not null;index"`
+	EtablissementID        string          `json:"etablissement_id" gorm:"type:varchar(100);not null;default:'';index"`
+	DelaiHeures            int             `json:"delai_heures" gorm:"not null"`
+	Echeance               time.Time       `json:"echeance" gorm:"not null"`
+	Niveau                 int             `json:"niveau" gorm:"not null;default:0"`
+	EscaladeCoordinateurLe *time.Time      `json:"escalade_coordinateur_le,omitempty"`
+	EscaladeAdminLe        *time.Time      `json:"escalade_admin_le,omitempty"`
+	ResoluLe               *time.Time      `json:"resolu_le,omitempty" gorm:"index"`
+	CreatedAt              time.Time       `json:"created_at"`
+	UpdatedAt              time.Time       `json:"updated_at"`
+
+	Inscription *Inscription `json:"inscription,omitempty" gorm:"foreignKey:InscriptionID"`
+}
+
+// TableName keeps the French plural consistent with the other tables.
+func (DepassementSla) TableName() string {
+	return "depassements_sla"
+}
+
+// RetardHeures returns how many whole hours past its deadline the dossier is, or was
+// when the breach was resolved.
+func (d *DepassementSla) RetardHeures(maintenant time.Time) int {
+	fin := maintenant
+	if d.ResoluLe != nil {
+		fin = *d.ResoluLe
+	}
+	return int(fin.Sub(d.Echeance).Hours())
+}
+
+// Sla returns the formation's per-state SLA, or defauts when it sets none.
+func (p *ParametresFormation) Sla(defauts DelaisSla) DelaisSla {
+	if len(p.DelaisSla) == 0 {
+		return defauts
+	}
+	var delais DelaisSla
+	if err := json.Unmarshal(p.DelaisSla, &delais); err != nil || len(delais) == 0 {
+		return defauts
+	}
+	return delais
+}
@@ -0,0 +1,192 @@
+// Package pdf writes simple A4 PDF documents (text, lines and rectangles) with the
+// standard Helvetica fonts, so that certificates and minutes can be produced
+// without an external dependency. Text is encoded in WinAnsi (cp1252), which
+// covers French.
+//
+// Coordinates are in points from the top-left corner of the page.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+// A4 page size in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Document is a PDF being built.
+type Document struct {
+	pages []*Page
+	title string
+}
+
+// Page is one page of a Document.
+type Page struct {
+	content bytes.Buffer
+}
+
+// New creates an empty document.
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// AddPage appends a blank A4 page and returns it.
+func (d *Document) AddPage() *Page {
+	p := &Page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Text writes s with its baseline at (x, y).
+func (p *Page) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&p.content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		font, size, x, PageHeight-y, escape(encode(s)))
+}
+
+// TextCentered writes s horizontally centred on the page.
+func (p *Page) TextCentered(y, size float64, bold bool, s string) {
+	p.Text((PageWidth-TextWidth(s, size, bold))/2, y, size, bold, s)
+}
+
+// TextRight writes s so that it ends at x.
+func (p *Page) TextRight(x, y, size float64, bold bool, s string) {
+	p.Text(x-TextWidth(s, size, bold), y, size, bold, s)
+}
+
+// Paragraph writes s wrapped to the given width and returns the y below the last line.
+func (p *Page) Paragraph(x, y, width, size float64, bold bool, s string) float64 {
+	for _, line := range Wrap(s, width, size, bold) {
+		p.Text(x, y, size, bold, line)
+		y += size * 1.4
+	}
+	return y
+}
+
+// Line draws a straight line.
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n",
+		width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// Rect draws the outline of a rectangle whose top-left corner is (x, y).
+func (p *Page) Rect(x, y, w, h, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f %.2f %.2f re S\n",
+		width, x, PageHeight-y-h, w, h)
+}
+
+// Wrap splits s into lines that fit in width.
+func Wrap(s string, width, size float64, bold bool) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if line != "" && TextWidth(candidate, size, bold) > width {
+				lines = append(lines, line)
+				line = word
+				continue
+			}
+			line = candidate
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Bytes renders the document.
+func (d *Document) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catalog, 2 page tree, 3-4 fonts, 5 info, then one page and one content stream per page
+	const firstPage = 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	obj(fmt.Sprintf("<< /Title (%s) /Producer (FST-CFC application-service) >>", escape(encode(d.title))))
+
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, firstPage+2*i+1))
+
+		var z bytes.Buffer
+		w := zlib.NewWriter(&z)
+		_, _ = w.Write(p.content.Bytes())
+		_ = w.Close()
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", len(offsets), z.Len())
+		buf.Write(z.Bytes())
+		buf.WriteString("\nendstream\nendobj\n")
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+func escape(b []byte) string {
+	var s strings.Builder
+	for _, c := range b {
+		switch c {
+		case '\\', '(', ')':
+			s.WriteByte('\\')
+		}
+		s.WriteByte(c)
+	}
+	return s.String()
+}
+
+// winAnsi maps the cp1252 characters outside Latin-1.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, 'Œ': 0x8C, 'œ': 0x9C, 'Ÿ': 0x9F,
+}
+
+// encode converts UTF-8 text to WinAnsi; unsupported characters become '?'.
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80 || (r >= 0xA0 && r <= 0xFF):
+			out = append(out, byte(r))
+		case winAnsi[r] != 0:
+			out = append(out, winAnsi[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
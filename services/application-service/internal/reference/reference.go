@@ -0,0 +1,69 @@
+// Package reference builds the human-readable dossier numbers printed on exports and
+// generated documents, e.g. CFC-2026-FST-000123: institution code, academic year and
+// a counter per institution and year.
+package reference
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Prefixe starts every dossier number.
+const Prefixe = "CFC"
+
+// Codes maps institution IDs to the short code printed in dossier numbers.
+type Codes map[string]string
+
+// ParseCodes reads a list such as "1=FST,2=ENSA". Malformed entries are ignored and
+// codes are upper-cased.
+func ParseCodes(s string) Codes {
+	codes := Codes{}
+	for _, entree := range strings.Split(s, ",") {
+		id, code, ok := strings.Cut(entree, "=")
+		id, code = strings.TrimSpace(id), normaliser(code)
+		if !ok || id == "" || code == "" {
+			continue
+		}
+		codes[id] = code
+	}
+	return codes
+}
+
+// Code returns the short code of an institution. Institutions without a configured
+// code use "E" followed by their ID; dossiers without an institution use "GEN".
+func (c Codes) Code(etablissementID string) string {
+	if code, ok := c[etablissementID]; ok {
+		return code
+	}
+	if code := normaliser(etablissementID); code != "" {
+		return "E" + code
+	}
+	return "GEN"
+}
+
+// AnneeAcademique returns the year in which the academic year containing t began.
+// Academic years start on 1 September.
+func AnneeAcademique(t time.Time) int {
+	if t.Month() >= time.September {
+		return t.Year()
+	}
+	return t.Year() - 1
+}
+
+// Format renders a dossier number.
+func Format(code string, annee int, numero int64) string {
+	return fmt.Sprintf("%s-%d-%s-%06d", Prefixe, annee, code, numero)
+}
+
+// normaliser keeps the letters and digits of a code, upper-cased, so that it
+// cannot break the dash-separated format.
+func normaliser(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, code)
+}
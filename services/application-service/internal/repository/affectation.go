@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// Inscription states that hold a seat in a formation.
+var etatsPlace = []model.EtatInscription{
+	model.EtatAccepte, model.EtatInscrit, model.EtatValide, model.EtatNonValide,
+}
+
+// Inscription states that can still take part in an allocation run.
+var etatsEnLice = []model.EtatInscription{model.EtatEnValidation, model.EtatListeAttente}
+
+// Inscription states whose choice rank the candidate may still change.
+var etatsOuverts = []model.EtatInscription{
+	model.EtatPreinscription, model.EtatDossierSoumis, model.EtatEnValidation,
+}
+
+// ErrAffectationPerimee is returned when an inscription changed state while an
+// allocation run was being applied.
+var ErrAffectationPerimee = errors.New("des dossiers ont changé pendant l'affectation, relancez-la")
+
+// ChangementEtat is a state change decided by an allocation run. OffreExpireLe is
+// the confirmation deadline of an ACCEPTE offer.
+type ChangementEtat struct {
+	Inscription   *model.Inscription
+	Etat          model.EtatInscription
+	Commentaire   string
+	Motifs        []model.MotifRefus
+	OffreExpireLe *time.Time
+	SessionJuryID *uint
+}
+
+// AffectationRepository handles database operations for ranked choices, jury
+// rankings and allocation runs.
+type AffectationRepository struct {
+	db *gorm.DB
+}
+
+// NewAffectationRepository creates a new AffectationRepository.
+func NewAffectationRepository(db *gorm.DB) *AffectationRepository {
+	return &AffectationRepository{db: db}
+}
+
+// FindVoeux returns the ranked applications of a candidate, most wanted first.
+func (r *AffectationRepository) FindVoeux(candidatID string) ([]model.Inscription, error) {
+	var inscriptions []model.Inscription
+	err := r.db.Where("candidat_id = ? AND rang_voeu IS NOT NULL", candidatID).
+		Order("rang_voeu").
+		Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// SetVoeux ranks the given applications of a candidate in order and clears the
+// rank of their other applications that are still open.
+func (r *AffectationRepository) SetVoeux(candidatID string, inscriptionIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Inscription{}).
+			Where("candidat_id = ? AND etat IN ?", candidatID, etatsOuverts).
+			Update("rang_voeu", nil).Error
+		if err != nil {
+			return err
+		}
+		for i, id := range inscriptionIDs {
+			err := tx.Model(&model.Inscription{}).
+				Where("id = ? AND candidat_id = ?", id, candidatID).
+				Update("rang_voeu", i+1).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindClassement returns the inscriptions of a formation still in the running,
+// best jury score first, unscored ones last.
+func (r *AffectationRepository) FindClassement(formationID uint) ([]model.Inscription, error) {
+	var inscriptions []model.Inscription
+	err := r.db.Where("formation_id = ? AND etat IN ?", formationID, etatsEnLice).
+		Order("score_jury DESC NULLS LAST, id").
+		Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// SetScores records the jury scores of inscriptions of a formation.
+func (r *AffectationRepository) SetScores(formationID uint, scores map[uint]float64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for id, score := range scores {
+			err := tx.Model(&model.Inscription{}).
+				Where("id = ? AND formation_id = ?", id, formationID).
+				Update("score_jury", score).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindEnLice returns the inscriptions of the given formations that can take part
+// in an allocation run, in candidate then preference order.
+func (r *AffectationRepository) FindEnLice(formationIDs []uint) ([]model.Inscription, error) {
+	var inscriptions []model.Inscription
+	err := r.db.Where("formation_id IN ? AND etat IN ?", formationIDs, etatsEnLice).
+		Order("candidat_id, rang_voeu NULLS LAST, id").
+		Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// FindPlaces returns the inscriptions already holding a seat in one of the given
+// formations, or held by one of the given candidates in any formation.
+func (r *AffectationRepository) FindPlaces(formationIDs []uint, candidatIDs []string) ([]model.Inscription, error) {
+	var inscriptions []model.Inscription
+	err := r.db.Where("etat IN ?", etatsPlace).
+		Where("formation_id IN ? OR candidat_id IN ?", formationIDs, candidatIDs).
+		Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// Appliquer records an allocation run and the state changes it decided, with their
+// decisions and history, in one transaction. The seats of the formations receiving
+// offers are counted again first, runs and transfers into the same formation waiting
+// for each other: ErrCapaciteAtteinte is returned when capacites, keyed by formation,
+// no longer allow them, and ErrAffectationPerimee when a candidate obtained a seat
+// meanwhile. Every change is then applied as a state change made by the run's author,
+// so every inscription must still be in the state it had when the run was computed
+// (ErrAffectationPerimee), unclaimed by a colleague (ErrDejaPrisEnCharge with their
+// claim), and every jury session the decisions are attached to still open
+// (ErrSessionJuryClose).
+func (r *AffectationRepository) Appliquer(a *model.Affectation, changements []ChangementEtat, capacites map[uint]*int) (*model.PriseEnCharge, error) {
+	offres := map[uint]int64{}
+	var candidats []string
+	for _, ch := range changements {
+		if ch.Etat == model.EtatAccepte {
+			offres[ch.Inscription.FormationID]++
+			candidats = append(candidats, ch.Inscription.CandidatID)
+		}
+	}
+	formationIDs := make([]uint, 0, len(offres))
+	for id := range offres {
+		formationIDs = append(formationIDs, id)
+	}
+	// A fixed order keeps concurrent runs over overlapping formations from deadlocking
+	sort.Slice(formationIDs, func(i, j int) bool { return formationIDs[i] < formationIDs[j] })
+
+	var prise *model.PriseEnCharge
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range formationIDs {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fmt.Sprintf("places/%d", id)).Error; err != nil {
+				return err
+			}
+			capacite := capacites[id]
+			if capacite == nil {
+				continue
+			}
+			var prises int64
+			err := tx.Model(&model.Inscription{}).
+				Where("formation_id = ? AND etat IN ?", id, etatsPlace).
+				Count(&prises).Error
+			if err != nil {
+				return err
+			}
+			if prises+offres[id] > int64(*capacite) {
+				return ErrCapaciteAtteinte
+			}
+		}
+		if len(candidats) > 0 {
+			var places int64
+			err := tx.Model(&model.Inscription{}).
+				Where("candidat_id IN ? AND etat IN ?", candidats, etatsPlace).
+				Count(&places).Error
+			if err != nil {
+				return err
+			}
+			if places > 0 {
+				return ErrAffectationPerimee
+			}
+		}
+
+		for _, ch := range changements {
+			ins := ch.Inscription
+			colonnes := map[string]interface{}{"etat": ch.Etat}
+			if ch.OffreExpireLe != nil {
+				colonnes["offre_expire_le"] = ch.OffreExpireLe
+				colonnes["offre_confirmee_le"] = nil
+				colonnes["offre_rappel_le"] = nil
+			}
+			decision := &model.Decision{
+				InscriptionID: ins.ID,
+				DecidePar:     a.LancePar,
+				Etat:          ch.Etat,
+				Commentaire:   ch.Commentaire,
+				Motifs:        ch.Motifs,
+				SessionJuryID: ch.SessionJuryID,
+			}
+			historique := &model.InscriptionHistorique{
+				InscriptionID: ins.ID,
+				AncienEtat:    ins.Etat,
+				NouvelEtat:    ch.Etat,
+				ModifiePar:    a.LancePar,
+			}
+			var err error
+			prise, err = changerEtatPar(tx, a.LancePar, ins, colonnes, decision, historique)
+			if errors.Is(err, ErrEtatModifie) {
+				return ErrAffectationPerimee
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Create(a).Error
+	})
+	return prise, err
+}
+
+// FindAll returns the allocation runs, newest first. An empty etablissementID means
+// global access.
+func (r *AffectationRepository) FindAll(etablissementID string) ([]model.Affectation, error) {
+	var runs []model.Affectation
+	q := r.db.Omit("rapport").Order("created_at DESC")
+	if etablissementID != "" {
+		q = q.Where("etablissement_id = ?", etablissementID)
+	}
+	err := q.Find(&runs).Error
+	return runs, err
+}
+
+// FindByID returns an allocation run with its report.
+func (r *AffectationRepository) FindByID(id uint) (*model.Affectation, error) {
+	var a model.Affectation
+	if err := r.db.First(&a, id).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
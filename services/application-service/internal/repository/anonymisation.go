@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// Anonymiser erases the personal data of an inscription — name, contact details,
+// identity fields, notes and form answers — and records it in the history. The
+// dossier number, formation, states and decisions are kept for statistics. The bulk
+// messages delivered to the candidate lose their address and rendered text; those
+// still pending are abandoned. The name is also replaced in the reports of the
+// allocation runs that ranked the dossier and in the minutes of closed jury sessions.
+func (r *InscriptionRepository) Anonymiser(ins *model.Inscription, par, commentaire string) error {
+	maintenant := time.Now()
+	champs := map[string]interface{}{
+		"nom_complet":     "Anonyme " + ins.NumeroDossier(),
+		"email":           fmt.Sprintf("anonyme-%d@anonyme.invalid", ins.ID),
+		"telephone":       "",
+		"cin":             nil,
+		"cne":             nil,
+		"date_naissance":  nil,
+		"dernier_diplome": "",
+		"annee_diplome":   nil,
+		"notes":           "",
+		"reponses":        nil,
+		"eligibilite":     nil,
+		"anonymise_le":    maintenant,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Inscription{}).
+			Where("id = ? AND anonymise_le IS NULL", ins.ID).
+			Updates(champs)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		err := tx.Model(&model.EnvoiMessage{}).
+			Where("inscription_id = ?", ins.ID).
+			Updates(map[string]interface{}{"destinataire": "", "sujet": "", "corps": ""}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&model.EnvoiMessage{}).
+			Where("inscription_id = ? AND statut NOT IN ?", ins.ID,
+				[]model.StatutEnvoi{model.EnvoiEnvoye, model.EnvoiAbandonne}).
+			Updates(map[string]interface{}{"statut": model.EnvoiAbandonne, "erreur": "dossier anonymisé"}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`
+			UPDATE affectations SET rapport = jsonb_set(rapport, '{candidats}', (
+				SELECT jsonb_agg(CASE
+					WHEN c->'voeux' @> jsonb_build_array(jsonb_build_object('inscription_id', ?::bigint))
+					THEN jsonb_set(c, '{nom_complet}', to_jsonb(?::text))
+					ELSE c END ORDER BY n)
+				FROM jsonb_array_elements(rapport->'candidats') WITH ORDINALITY AS t(c, n)))
+			WHERE rapport->'candidats' @> jsonb_build_array(jsonb_build_object('voeux',
+				jsonb_build_array(jsonb_build_object('inscription_id', ?::bigint))))`,
+			ins.ID, champs["nom_complet"], ins.ID).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`
+			UPDATE sessions_jury SET proces = (
+				SELECT jsonb_agg(CASE
+					WHEN (l->>'inscription_id')::bigint = ?
+					THEN jsonb_set(l, '{nom_complet}', to_jsonb(?::text))
+					ELSE l END ORDER BY n)
+				FROM jsonb_array_elements(proces) WITH ORDINALITY AS t(l, n))
+			WHERE proces @> jsonb_build_array(jsonb_build_object('inscription_id', ?::bigint))`,
+			ins.ID, champs["nom_complet"], ins.ID).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&model.InscriptionHistorique{
+			InscriptionID: ins.ID,
+			AncienEtat:    ins.Etat,
+			NouvelEtat:    ins.Etat,
+			ModifiePar:    par,
+			Evenement:     model.EvenementAnonymisation,
+			Commentaire:   commentaire,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	ins.AnonymiseLe = &maintenant
+	return nil
+}
@@ -1,10 +1,23 @@
 package repository
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/reference"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrEtatModifie is returned when an inscription changed state while a state change
+// was being applied to it.
+var ErrEtatModifie = errors.New("le dossier a changé d'état entre-temps, rechargez-le")
+
+// ErrAnonymise is returned when the personal data of an inscription were erased.
+var ErrAnonymise = errors.New("les données de ce dossier ont été anonymisées")
+
 // InscriptionRepository handles database operations for inscriptions.
 type InscriptionRepository struct {
 	db *gorm.DB
@@ -15,40 +28,149 @@ func NewInscriptionRepository(db *gorm.DB) *InscriptionRepository {
 	return &InscriptionRepository{db: db}
 }
 
-// FindAll returns all inscriptions.
-func (r *InscriptionRepository) FindAll() ([]model.Inscription, error) {
+// InscriptionFilter holds the optional criteria shared by listings and exports.
+// An empty EtablissementID means global access.
+type InscriptionFilter struct {
+	EtablissementID string
+	CandidatID      string
+	FormationID     uint
+	// FormationIDs restricts the selection to these formations when not nil.
+	FormationIDs []uint
+	Etats        []model.EtatInscription
+	Reference    string
+	CIN          string
+	CNE          string
+	// Reponses filters on application form answers: key → expected value.
+	Reponses map[string]string
+}
+
+// filtered applies an InscriptionFilter to a query on inscriptions.
+func (r *InscriptionRepository) filtered(f InscriptionFilter) *gorm.DB {
+	q := r.db.Model(&model.Inscription{})
+	if f.EtablissementID != "" {
+		q = q.Where("etablissement_id = ?", f.EtablissementID)
+	}
+	if f.CandidatID != "" {
+		q = q.Where("candidat_id = ?", f.CandidatID)
+	}
+	if f.FormationID != 0 {
+		q = q.Where("formation_id = ?", f.FormationID)
+	}
+	if f.FormationIDs != nil {
+		q = q.Where("formation_id IN ?", f.FormationIDs)
+	}
+	if len(f.Etats) > 0 {
+		q = q.Where("etat IN ?", f.Etats)
+	}
+	if f.CIN != "" {
+		q = q.Where("cin = ?", f.CIN)
+	}
+	if f.CNE != "" {
+		q = q.Where("cne = ?", f.CNE)
+	}
+	if f.Reference != "" {
+		q = q.Where("reference = ?", strings.ToUpper(strings.TrimSpace(f.Reference)))
+	}
+	for key, value := range f.Reponses {
+		q = q.Where("reponses ->> ? = ?", key, value)
+	}
+	return q
+}
+
+// FindAll returns the inscriptions matching the filter, oldest first.
+func (r *InscriptionRepository) FindAll(f InscriptionFilter) ([]model.Inscription, error) {
 	var inscriptions []model.Inscription
-	err := r.db.Find(&inscriptions).Error
+	err := r.filtered(f).Order("id").Find(&inscriptions).Error
 	return inscriptions, err
 }
 
 // FindByID returns an inscription by ID with its decisions and history.
 func (r *InscriptionRepository) FindByID(id uint) (*model.Inscription, error) {
 	var ins model.Inscription
-	err := r.db.Preload("Decisions").Preload("History").First(&ins, id).Error
+	err := r.db.Preload("Decisions.Motifs").Preload("History").First(&ins, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &ins, nil
 }
 
-// FindByCandidatID returns all inscriptions for a given candidate.
-func (r *InscriptionRepository) FindByCandidatID(candidatID string) ([]model.Inscription, error) {
-	var inscriptions []model.Inscription
-	err := r.db.Where("candidat_id = ?", candidatID).Find(&inscriptions).Error
-	return inscriptions, err
+// FindFormationsWithoutEtablissement returns the formations of inscriptions created
+// before the institution was recorded on them.
+func (r *InscriptionRepository) FindFormationsWithoutEtablissement() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.Inscription{}).
+		Where("etablissement_id = ''").
+		Distinct().
+		Pluck("formation_id", &ids).Error
+	return ids, err
+}
+
+// SetEtablissement records the institution on every inscription of a formation.
+func (r *InscriptionRepository) SetEtablissement(formationID uint, etablissementID string) (int64, error) {
+	res := r.db.Model(&model.Inscription{}).
+		Where("formation_id = ? AND etablissement_id = ''", formationID).
+		Update("etablissement_id", etablissementID)
+	return res.RowsAffected, res.Error
+}
+
+// Create inserts a new inscription, numbered in the sequence of the given
+// institution code for the current academic year.
+func (r *InscriptionRepository) Create(ins *model.Inscription, code string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		ref, err := prochaineReference(tx, code, reference.AnneeAcademique(time.Now()))
+		if err != nil {
+			return err
+		}
+		ins.Reference = &ref
+		return tx.Create(ins).Error
+	})
 }
 
-// FindByFormationID returns all inscriptions for a given formation.
-func (r *InscriptionRepository) FindByFormationID(formationID uint) ([]model.Inscription, error) {
+// FindSansReference returns up to limit inscriptions created before dossiers were
+// numbered, oldest first. Inscriptions whose institution is still unknown are left
+// out until ResolveEtablissements has run.
+func (r *InscriptionRepository) FindSansReference(limit int) ([]model.Inscription, error) {
 	var inscriptions []model.Inscription
-	err := r.db.Where("formation_id = ?", formationID).Find(&inscriptions).Error
+	err := r.db.Where("reference IS NULL AND etablissement_id <> ''").
+		Order("id").
+		Limit(limit).
+		Find(&inscriptions).Error
 	return inscriptions, err
 }
 
-// Create inserts a new inscription.
-func (r *InscriptionRepository) Create(ins *model.Inscription) error {
-	return r.db.Create(ins).Error
+// AttribuerReference numbers an existing inscription in the academic year of its
+// creation. It does nothing if the inscription was numbered meanwhile.
+func (r *InscriptionRepository) AttribuerReference(ins *model.Inscription, code string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		ref, err := prochaineReference(tx, code, reference.AnneeAcademique(ins.DateCreation))
+		if err != nil {
+			return err
+		}
+		res := tx.Model(&model.Inscription{}).
+			Where("id = ? AND reference IS NULL", ins.ID).
+			Update("reference", ref)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 1 {
+			ins.Reference = &ref
+		}
+		return nil
+	})
+}
+
+// prochaineReference allocates the next dossier number of an institution code and
+// academic year. The upsert locks the counter row until tx ends, so concurrent
+// creations wait for each other instead of reading the same value.
+func prochaineReference(tx *gorm.DB, code string, annee int) (string, error) {
+	var numero int64
+	err := tx.Raw(`INSERT INTO sequences_dossier (code, annee, valeur) VALUES (?, ?, 1)
+		ON CONFLICT (code, annee) DO UPDATE SET valeur = sequences_dossier.valeur + 1
+		RETURNING valeur`, code, annee).Scan(&numero).Error
+	if err != nil {
+		return "", err
+	}
+	return reference.Format(code, annee, numero), nil
 }
 
 // Update saves changes to an existing inscription.
@@ -56,6 +178,110 @@ func (r *InscriptionRepository) Update(ins *model.Inscription) error {
 	return r.db.Save(ins).Error
 }
 
+// UpdateIdentite writes the identity columns of an inscription, and only those, so
+// that a change committed meanwhile is kept. It returns ErrAnonymise when the
+// inscription was anonymised.
+func (r *InscriptionRepository) UpdateIdentite(id uint, colonnes map[string]interface{}) error {
+	if len(colonnes) == 0 {
+		return nil
+	}
+	res := r.db.Model(&model.Inscription{}).
+		Where("id = ? AND anonymise_le IS NULL", id).
+		Updates(colonnes)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAnonymise
+	}
+	return nil
+}
+
+// ChangerEtat applies a state change to an inscription in one transaction: colonnes,
+// which hold the new "etat" and the columns that change with it, the decision if there
+// is one, with its reason codes, and the history entry. ins must still hold the state
+// the change starts from: if the inscription left it meanwhile, ErrEtatModifie is
+// returned and nothing is written; ErrSessionJuryClose if the decision's jury session
+// was closed meanwhile.
+func (r *InscriptionRepository) ChangerEtat(ins *model.Inscription, colonnes map[string]interface{}, decision *model.Decision, historique *model.InscriptionHistorique) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return changerEtat(tx, ins, colonnes, decision, historique)
+	})
+}
+
+// ChangerEtatPar is ChangerEtat for a change made by hand by par. While a colleague
+// holds an active claim on the dossier, the change is refused with
+// ErrDejaPrisEnCharge and their claim.
+func (r *InscriptionRepository) ChangerEtatPar(par string, ins *model.Inscription, colonnes map[string]interface{}, decision *model.Decision, historique *model.InscriptionHistorique) (*model.PriseEnCharge, error) {
+	var prise *model.PriseEnCharge
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		prise, err = changerEtatPar(tx, par, ins, colonnes, decision, historique)
+		return err
+	})
+	return prise, err
+}
+
+// changerEtatPar is ChangerEtatPar within a transaction. The inscription row is
+// locked FOR UPDATE before the claim is read, and claims are taken under a lock on
+// the same row, so no claim can be taken until the change is committed.
+func changerEtatPar(tx *gorm.DB, par string, ins *model.Inscription, colonnes map[string]interface{}, decision *model.Decision, historique *model.InscriptionHistorique) (*model.PriseEnCharge, error) {
+	var verrou model.Inscription
+	err := tx.Select("id").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&verrou, ins.ID).Error
+	if err != nil {
+		return nil, err
+	}
+	var prises []model.PriseEnCharge
+	if err := tx.Where("inscription_id = ?", ins.ID).Find(&prises).Error; err != nil {
+		return nil, err
+	}
+	if len(prises) > 0 && prises[0].Par != par && prises[0].Active(ins.Etat, time.Now()) {
+		return &prises[0], ErrDejaPrisEnCharge
+	}
+	return nil, changerEtat(tx, ins, colonnes, decision, historique)
+}
+
+// changerEtat is ChangerEtat within a transaction.
+func changerEtat(tx *gorm.DB, ins *model.Inscription, colonnes map[string]interface{}, decision *model.Decision, historique *model.InscriptionHistorique) error {
+	res := tx.Model(&model.Inscription{}).
+		Where("id = ? AND etat = ?", ins.ID, ins.Etat).
+		Updates(colonnes)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrEtatModifie
+	}
+	if decision != nil {
+		if err := creerDecision(tx, decision); err != nil {
+			return err
+		}
+	}
+	return tx.Create(historique).Error
+}
+
+// creerDecision inserts a decision within tx. A decision attached to a jury session
+// first locks the session row FOR SHARE and is refused with ErrSessionJuryClose once
+// the session is no longer open: a closing in progress is waited for, and the next
+// one waits for tx, so no decision is added after the minutes are frozen.
+func creerDecision(tx *gorm.DB, decision *model.Decision) error {
+	if decision.SessionJuryID != nil {
+		var s model.SessionJury
+		err := tx.Select("id", "statut").
+			Clauses(clause.Locking{Strength: "SHARE"}).
+			First(&s, *decision.SessionJuryID).Error
+		if err != nil {
+			return err
+		}
+		if s.Statut != model.SessionJuryOuverte {
+			return ErrSessionJuryClose
+		}
+	}
+	return tx.Create(decision).Error
+}
+
 // CreateDecision inserts a new decision.
 func (r *InscriptionRepository) CreateDecision(decision *model.Decision) error {
 	return r.db.Create(decision).Error
@@ -65,3 +291,15 @@ func (r *InscriptionRepository) CreateDecision(decision *model.Decision) error {
 func (r *InscriptionRepository) CreateHistory(history *model.InscriptionHistorique) error {
 	return r.db.Create(history).Error
 }
+
+// FindLastDecision returns the most recent decision of the given state for an inscription.
+func (r *InscriptionRepository) FindLastDecision(inscriptionID uint, etat model.EtatInscription) (*model.Decision, error) {
+	var decision model.Decision
+	err := r.db.Where("inscription_id = ? AND etat = ?", inscriptionID, etat).
+		Order("created_at DESC").
+		First(&decision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &decision, nil
+}
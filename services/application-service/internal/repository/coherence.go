@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// FindAVerifier returns, with their decisions and history, the inscriptions matching
+// the filter whose ID is above apresID, by ID. It pages through a consistency check.
+func (r *InscriptionRepository) FindAVerifier(f InscriptionFilter, apresID uint, limit int) ([]model.Inscription, error) {
+	var inscriptions []model.Inscription
+	err := r.filtered(f).
+		Preload("Decisions").Preload("History").
+		Where("id > ?", apresID).
+		Order("id").
+		Limit(limit).
+		Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// Reconstituer adds the synthetic history entries of the consistency checker, dated
+// at the time they are estimated to have happened.
+func (r *InscriptionRepository) Reconstituer(entrees []model.InscriptionHistorique) error {
+	if len(entrees) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&entrees).Error
+	})
+}
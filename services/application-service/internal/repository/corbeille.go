@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Supprimer moves an inscription to the trash: it is soft-deleted with who deleted
+// it and why, and the deletion is recorded in the history. Its interview booking is
+// released so that the slot can go to another candidate.
+func (r *InscriptionRepository) Supprimer(ins *model.Inscription, par, motif string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Inscription{}).
+			Where("id = ?", ins.ID).
+			Updates(map[string]interface{}{
+				"supprimee_par":     par,
+				"motif_suppression": motif,
+				"deleted_at":        time.Now(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("inscription_id = ?", ins.ID).Delete(&model.ReservationEntretien{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.InscriptionHistorique{
+			InscriptionID: ins.ID,
+			AncienEtat:    ins.Etat,
+			NouvelEtat:    ins.Etat,
+			ModifiePar:    par,
+			Evenement:     model.EvenementSuppression,
+			Commentaire:   motif,
+		}).Error
+	})
+}
+
+// FindCorbeille returns the deleted inscriptions, most recently deleted first.
+// An empty etablissementID means global access.
+func (r *InscriptionRepository) FindCorbeille(etablissementID string) ([]model.Inscription, error) {
+	q := r.db.Unscoped().Where("deleted_at IS NOT NULL")
+	if etablissementID != "" {
+		q = q.Where("etablissement_id = ?", etablissementID)
+	}
+	var inscriptions []model.Inscription
+	err := q.Order("deleted_at DESC, id").Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// FindSupprimee returns a deleted inscription with its decisions and history.
+func (r *InscriptionRepository) FindSupprimee(id uint) (*model.Inscription, error) {
+	var ins model.Inscription
+	err := r.db.Unscoped().
+		Preload("Decisions.Motifs").Preload("History").
+		Where("deleted_at IS NOT NULL").
+		First(&ins, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &ins, nil
+}
+
+// Restaurer takes an inscription out of the trash, in the state it was deleted in,
+// and records it in the history.
+func (r *InscriptionRepository) Restaurer(ins *model.Inscription, par, motif string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Unscoped().Model(&model.Inscription{}).
+			Where("id = ? AND deleted_at IS NOT NULL", ins.ID).
+			Updates(map[string]interface{}{
+				"supprimee_par":     "",
+				"motif_suppression": "",
+				"deleted_at":        nil,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(&model.InscriptionHistorique{
+			InscriptionID: ins.ID,
+			AncienEtat:    ins.Etat,
+			NouvelEtat:    ins.Etat,
+			ModifiePar:    par,
+			Evenement:     model.EvenementRestauration,
+			Commentaire:   motif,
+		}).Error
+	})
+}
+
+// FindAPurger returns the inscriptions deleted before the given time, oldest first.
+func (r *InscriptionRepository) FindAPurger(avant time.Time, limit int) ([]model.Inscription, error) {
+	var inscriptions []model.Inscription
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", avant).
+		Order("deleted_at, id").
+		Limit(limit).
+		Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// Purger permanently deletes a trashed inscription and everything recorded about it.
+// Snapshots that outlive the dossier — published results lists, jury minutes, the
+// reports of allocation runs — are left as they were. fichiers is called once the rows
+// are deleted, before the transaction commits: if it fails, nothing is deleted. It
+// returns gorm.ErrRecordNotFound if the inscription was restored meanwhile.
+func (r *InscriptionRepository) Purger(id uint, fichiers func() error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var ins model.Inscription
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("deleted_at IS NOT NULL").
+			First(&ins, id).Error
+		if err != nil {
+			return err
+		}
+
+		// Dependent rows first: some of these tables reference inscriptions without a cascade
+		statements := []string{
+			`DELETE FROM decision_motifs WHERE decision_id IN (SELECT id FROM decisions WHERE inscription_id = ?)`,
+			`DELETE FROM decisions WHERE inscription_id = ?`,
+			`DELETE FROM inscription_historiques WHERE inscription_id = ?`,
+			`DELETE FROM recours_documents WHERE recours_id IN (SELECT id FROM recours WHERE inscription_id = ?)`,
+			`DELETE FROM recours WHERE inscription_id = ?`,
+			`DELETE FROM reservations_entretien WHERE inscription_id = ?`,
+			`DELETE FROM membres_groupes WHERE inscription_id = ?`,
+			`DELETE FROM presences WHERE inscription_id = ?`,
+			`DELETE FROM alertes_assiduite WHERE inscription_id = ?`,
+			`DELETE FROM notes WHERE inscription_id = ?`,
+			`DELETE FROM attestations WHERE inscription_id = ?`,
+			`DELETE FROM doublons_suspects WHERE ? IN (inscription_id, autre_inscription_id)`,
+			`DELETE FROM depassements_sla WHERE inscription_id = ?`,
+			`DELETE FROM prises_en_charge WHERE inscription_id = ?`,
+			`DELETE FROM envois_messages WHERE inscription_id = ?`,
+		}
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt, id).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Delete(&model.Inscription{}, id).Error; err != nil {
+			return err
+		}
+		return fichiers()
+	})
+}
@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/doublon"
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrDoublonResolu is returned when a suspected duplicate was already merged or dismissed.
+var ErrDoublonResolu = errors.New("ce doublon a déjà été traité")
+
+// ErrFusionConflit is returned when merging would delete a dossier that went past
+// PREINSCRIPTION: staff must first choose which of the two dossiers to delete.
+var ErrFusionConflit = errors.New("les deux comptes ont un dossier dans la même formation : supprimez celui à écarter avant de fusionner")
+
+// DoublonFilter holds the optional criteria of the review queue.
+// An empty EtablissementID means global access.
+type DoublonFilter struct {
+	EtablissementID string
+	Statut          string
+	InscriptionID   uint
+}
+
+// FusionDoublon reports what merging two candidate accounts did: the inscriptions
+// moved to the kept account, and those deleted because it already had a dossier in
+// the same formation.
+type FusionDoublon struct {
+	Doublon    *model.DoublonSuspect `json:"doublon"`
+	Deplacees  []uint                `json:"deplacees"`
+	Supprimees []uint                `json:"supprimees"`
+}
+
+// ConflitFusion is a formation where both accounts have a dossier and the one the
+// merge would delete is past PREINSCRIPTION.
+type ConflitFusion struct {
+	FormationID   uint                  `json:"formation_id"`
+	InscriptionID uint                  `json:"inscription_id"`
+	Etat          model.EtatInscription `json:"etat"`
+	ConserveeID   uint                  `json:"conservee_id"`
+	EtatConservee model.EtatInscription `json:"etat_conservee"`
+}
+
+// DoublonRepository handles database operations for suspected duplicates.
+type DoublonRepository struct {
+	db *gorm.DB
+}
+
+// NewDoublonRepository creates a new DoublonRepository.
+func NewDoublonRepository(db *gorm.DB) *DoublonRepository {
+	return &DoublonRepository{db: db}
+}
+
+// Detecter compares an inscription with those of other candidates in its institution
+// and records the suspicious pairs not seen before. It returns the pairs it added.
+// Anonymised inscriptions are left out.
+func (r *DoublonRepository) Detecter(ins *model.Inscription) ([]model.DoublonSuspect, error) {
+	if ins.AnonymiseLe != nil {
+		return nil, nil
+	}
+
+	var rows []struct {
+		ID            uint
+		CINEgal       bool
+		CNEEgal       bool
+		EmailEgal     bool
+		TelephoneEgal bool
+		SimilariteNom float64
+	}
+	err := r.db.Model(&model.Inscription{}).
+		Joins(`CROSS JOIN (SELECT
+			normaliser_email(?) AS e,
+			normaliser_telephone(?) AS t,
+			immutable_unaccent(lower(?)) AS n,
+			?::text AS cin,
+			?::text AS cne) AS s`, ins.Email, ins.Telephone, ins.NomComplet, ins.CIN, ins.CNE).
+		Select(`inscriptions.id,
+			coalesce(inscriptions.cin = s.cin, false) AS cin_egal,
+			coalesce(inscriptions.cne = s.cne, false) AS cne_egal,
+			normaliser_email(email) = s.e AS email_egal,
+			coalesce(normaliser_telephone(telephone) = s.t, false) AS telephone_egal,
+			similarity(immutable_unaccent(lower(nom_complet)), s.n) AS similarite_nom`).
+		Where("etablissement_id = ? AND candidat_id <> ? AND inscriptions.id <> ? AND anonymise_le IS NULL",
+			ins.EtablissementID, ins.CandidatID, ins.ID).
+		Where(`inscriptions.cin = s.cin
+			OR inscriptions.cne = s.cne
+			OR normaliser_email(email) = s.e
+			OR normaliser_telephone(telephone) = s.t
+			OR immutable_unaccent(lower(nom_complet)) % s.n`).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var ajoutes []model.DoublonSuspect
+	for _, row := range rows {
+		score, criteres, suspect := doublon.Evaluer(doublon.Correspondance{
+			CINEgal:       row.CINEgal,
+			CNEEgal:       row.CNEEgal,
+			EmailEgal:     row.EmailEgal,
+			TelephoneEgal: row.TelephoneEgal,
+			SimilariteNom: row.SimilariteNom,
+		})
+		if !suspect {
+			continue
+		}
+		c, _ := json.Marshal(criteres)
+		d := model.DoublonSuspect{
+			InscriptionID:      min(ins.ID, row.ID),
+			AutreInscriptionID: max(ins.ID, row.ID),
+			EtablissementID:    ins.EtablissementID,
+			Score:              score,
+			Criteres:           c,
+			SimilariteNom:      row.SimilariteNom,
+			Statut:             model.StatutDoublonEnAttente,
+		}
+		// A pair already raised keeps its status, so a dismissed one stays dismissed
+		res := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&d)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			ajoutes = append(ajoutes, d)
+		}
+	}
+	return ajoutes, nil
+}
+
+// FindInscriptionsApres returns up to limit inscriptions with an ID greater than
+// apres, in order, for the detection job to walk through every inscription.
+func (r *DoublonRepository) FindInscriptionsApres(apres uint, limit int) ([]model.Inscription, error) {
+	var inscriptions []model.Inscription
+	err := r.db.Where("id > ? AND etablissement_id <> '' AND anonymise_le IS NULL", apres).
+		Order("id").
+		Limit(limit).
+		Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// FindAll returns the suspected duplicates matching the filter with both
+// inscriptions, most likely first.
+func (r *DoublonRepository) FindAll(f DoublonFilter) ([]model.DoublonSuspect, error) {
+	q := r.db.Preload("Inscription").Preload("AutreInscription")
+	if f.EtablissementID != "" {
+		q = q.Where("etablissement_id = ?", f.EtablissementID)
+	}
+	if f.Statut != "" {
+		q = q.Where("statut = ?", f.Statut)
+	}
+	if f.InscriptionID != 0 {
+		q = q.Where("inscription_id = ? OR autre_inscription_id = ?", f.InscriptionID, f.InscriptionID)
+	}
+	var doublons []model.DoublonSuspect
+	err := q.Order("score DESC, id").Find(&doublons).Error
+	return doublons, err
+}
+
+// FindByID returns a suspected duplicate with both inscriptions.
+func (r *DoublonRepository) FindByID(id uint) (*model.DoublonSuspect, error) {
+	var d model.DoublonSuspect
+	err := r.db.Preload("Inscription").Preload("AutreInscription").First(&d, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Fusionner merges the candidate account of the other inscription of the pair into
+// the account of the kept one, within the pair's institution: each of its inscriptions
+// moves to the kept account, or is deleted when that account already has a dossier in
+// the same formation and it is still in PREINSCRIPTION. A dossier past that state is
+// never deleted by a merge: nothing is changed and ErrFusionConflit is returned with
+// the conflicts. Every change is recorded in the history, and the pending pairs the
+// merge made moot are closed with it.
+func (r *DoublonRepository) Fusionner(d *model.DoublonSuspect, conserveeID uint, par, commentaire string) ([]ConflitFusion, *FusionDoublon, error) {
+	fusion := &FusionDoublon{Doublon: d, Deplacees: []uint{}, Supprimees: []uint{}}
+	var conflits []ConflitFusion
+	maintenant := time.Now()
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := resoudre(tx, d, model.StatutDoublonFusionne, &conserveeID, par, commentaire, maintenant); err != nil {
+			return err
+		}
+
+		var conservee model.Inscription
+		if err := tx.First(&conservee, conserveeID).Error; err != nil {
+			return err
+		}
+		autreID := d.InscriptionID
+		if autreID == conserveeID {
+			autreID = d.AutreInscriptionID
+		}
+		// It may have been deleted to settle a conflict: its account is still merged
+		var autre model.Inscription
+		if err := tx.Unscoped().First(&autre, autreID).Error; err != nil {
+			return err
+		}
+		if autre.CandidatID == conservee.CandidatID {
+			// Already merged through another pair
+			return nil
+		}
+
+		var aFusionner []model.Inscription
+		err := tx.Where("candidat_id = ? AND etablissement_id = ?", autre.CandidatID, d.EtablissementID).
+			Order("id").
+			Find(&aFusionner).Error
+		if err != nil {
+			return err
+		}
+
+		details, _ := json.Marshal(map[string]interface{}{
+			"doublon_id":        d.ID,
+			"candidat_fusionne": autre.CandidatID,
+			"candidat_conserve": conservee.CandidatID,
+		})
+		existantes := make([]*model.Inscription, len(aFusionner))
+		for i := range aFusionner {
+			ins := &aFusionner[i]
+			var meme []model.Inscription
+			err := tx.Where("candidat_id = ? AND formation_id = ?", conservee.CandidatID, ins.FormationID).
+				Order("id").
+				Limit(1).
+				Find(&meme).Error
+			if err != nil {
+				return err
+			}
+			if len(meme) == 0 {
+				continue
+			}
+			existantes[i] = &meme[0]
+			if ins.Etat != model.EtatPreinscription {
+				conflits = append(conflits, ConflitFusion{
+					FormationID:   ins.FormationID,
+					InscriptionID: ins.ID,
+					Etat:          ins.Etat,
+					ConserveeID:   meme[0].ID,
+					EtatConservee: meme[0].Etat,
+				})
+			}
+		}
+		if len(conflits) > 0 {
+			return ErrFusionConflit
+		}
+
+		for i := range aFusionner {
+			ins := &aFusionner[i]
+			historique := model.InscriptionHistorique{
+				InscriptionID: ins.ID,
+				AncienEtat:    ins.Etat,
+				NouvelEtat:    ins.Etat,
+				ModifiePar:    par,
+				Evenement:     model.EvenementFusionDoublon,
+				Details:       details,
+			}
+			if existantes[i] != nil {
+				if err := tx.Where("inscription_id = ?", ins.ID).Delete(&model.ReservationEntretien{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Delete(ins).Error; err != nil {
+					return err
+				}
+				historique.Commentaire = fmt.Sprintf("Préinscription en double supprimée, le dossier du compte conservé est gardé : %s", commentaire)
+				fusion.Supprimees = append(fusion.Supprimees, ins.ID)
+			} else {
+				// The choice rank belonged to the other account's ranking
+				err := tx.Model(ins).Updates(map[string]interface{}{
+					"candidat_id": conservee.CandidatID,
+					"rang_voeu":   nil,
+				}).Error
+				if err != nil {
+					return err
+				}
+				historique.Commentaire = fmt.Sprintf("Dossier rattaché au compte conservé : %s", commentaire)
+				fusion.Deplacees = append(fusion.Deplacees, ins.ID)
+			}
+			if err := tx.Create(&historique).Error; err != nil {
+				return err
+			}
+		}
+
+		// Pairs now within one account, or with a deleted dossier, need no review
+		return tx.Exec(`UPDATE doublons_suspects AS d
+			SET statut = ?, resolu_par = ?, resolu_le = ?, commentaire = ?, updated_at = ?
+			FROM inscriptions AS a, inscriptions AS b
+			WHERE d.statut = ? AND d.id <> ?
+				AND a.id = d.inscription_id AND b.id = d.autre_inscription_id
+				AND (a.deleted_at IS NOT NULL OR b.deleted_at IS NOT NULL OR a.candidat_id = b.candidat_id)`,
+			model.StatutDoublonFusionne, par, maintenant,
+			fmt.Sprintf("Résolu par la fusion du doublon #%d", d.ID), maintenant,
+			model.StatutDoublonEnAttente, d.ID).Error
+	})
+	if err != nil {
+		return conflits, nil, err
+	}
+	return nil, fusion, nil
+}
+
+// Ecarter dismisses a suspected duplicate and records it in the history of both
+// inscriptions.
+func (r *DoublonRepository) Ecarter(d *model.DoublonSuspect, par, commentaire string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := resoudre(tx, d, model.StatutDoublonEcarte, nil, par, commentaire, time.Now()); err != nil {
+			return err
+		}
+
+		details, _ := json.Marshal(map[string]interface{}{"doublon_id": d.ID})
+		for _, ins := range []*model.Inscription{d.Inscription, d.AutreInscription} {
+			if ins == nil {
+				continue
+			}
+			historique := model.InscriptionHistorique{
+				InscriptionID: ins.ID,
+				AncienEtat:    ins.Etat,
+				NouvelEtat:    ins.Etat,
+				ModifiePar:    par,
+				Evenement:     model.EvenementDoublonEcarte,
+				Commentaire:   commentaire,
+				Details:       details,
+			}
+			if err := tx.Create(&historique).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// resoudre closes a pending pair, failing with ErrDoublonResolu if it was
+// resolved concurrently.
+func resoudre(tx *gorm.DB, d *model.DoublonSuspect, statut string, conserveeID *uint, par, commentaire string, le time.Time) error {
+	res := tx.Model(&model.DoublonSuspect{}).
+		Where("id = ? AND statut = ?", d.ID, model.StatutDoublonEnAttente).
+		Updates(map[string]interface{}{
+			"statut":       statut,
+			"conservee_id": conserveeID,
+			"resolu_par":   par,
+			"resolu_le":    le,
+			"commentaire":  commentaire,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrDoublonResolu
+	}
+	d.Statut = statut
+	d.ConserveeID = conserveeID
+	d.ResoluPar = par
+	d.ResoluLe = &le
+	d.Commentaire = commentaire
+	return nil
+}
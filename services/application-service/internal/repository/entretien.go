@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrCreneauComplet is returned when every place of a slot is already booked.
+	ErrCreneauComplet = errors.New("créneau complet")
+	// ErrChevauchement is returned when a booking or a slot overlaps an existing one.
+	ErrChevauchement = errors.New("chevauchement avec un autre entretien")
+)
+
+// EntretienRepository handles database operations for interview slots and bookings.
+type EntretienRepository struct {
+	db *gorm.DB
+}
+
+// NewEntretienRepository creates a new EntretienRepository.
+func NewEntretienRepository(db *gorm.DB) *EntretienRepository {
+	return &EntretienRepository{db: db}
+}
+
+// FindCreneaux returns the slots of a formation in chronological order with their
+// booking count. When aVenir is set, past slots are left out.
+func (r *EntretienRepository) FindCreneaux(formationID uint, aVenir bool) ([]model.CreneauEntretien, error) {
+	var creneaux []model.CreneauEntretien
+	q := r.db.Where("formation_id = ?", formationID).Order("debut, id")
+	if aVenir {
+		q = q.Where("debut > ?", time.Now())
+	}
+	if err := q.Find(&creneaux).Error; err != nil {
+		return nil, err
+	}
+	if len(creneaux) == 0 {
+		return creneaux, nil
+	}
+
+	ids := make([]uint, len(creneaux))
+	for i, c := range creneaux {
+		ids[i] = c.ID
+	}
+	var counts []struct {
+		CreneauID uint
+		N         int
+	}
+	err := r.db.Model(&model.ReservationEntretien{}).
+		Select("creneau_id, COUNT(*) AS n").
+		Where("creneau_id IN ?", ids).
+		Group("creneau_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	byID := map[uint]int{}
+	for _, c := range counts {
+		byID[c.CreneauID] = c.N
+	}
+	for i := range creneaux {
+		creneaux[i].Reservees = byID[creneaux[i].ID]
+	}
+	return creneaux, nil
+}
+
+// FindCreneauByID returns a slot by ID.
+func (r *EntretienRepository) FindCreneauByID(id uint) (*model.CreneauEntretien, error) {
+	var c model.CreneauEntretien
+	if err := r.db.First(&c, id).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CreateCreneau inserts a slot unless its panel already sits in an overlapping slot.
+// Slots of the same panel are serialised with an advisory lock so that two
+// concurrent publications cannot both pass the overlap check.
+func (r *EntretienRepository) CreateCreneau(c *model.CreneauEntretien) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", c.EtablissementID+"/"+c.Jury).Error; err != nil {
+			return err
+		}
+		var n int64
+		err := tx.Model(&model.CreneauEntretien{}).
+			Where("etablissement_id = ? AND jury = ?", c.EtablissementID, c.Jury).
+			Where("debut < ? AND fin > ?", c.Fin, c.Debut).
+			Count(&n).Error
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrChevauchement
+		}
+		return tx.Create(c).Error
+	})
+}
+
+// DeleteCreneau removes a slot that has no booking.
+func (r *EntretienRepository) DeleteCreneau(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&model.ReservationEntretien{}).Where("creneau_id = ?", id).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrCreneauComplet
+		}
+		return tx.Delete(&model.CreneauEntretien{}, id).Error
+	})
+}
+
+// FindReservation returns the booking of an inscription with its slot.
+func (r *EntretienRepository) FindReservation(inscriptionID uint) (*model.ReservationEntretien, error) {
+	var res model.ReservationEntretien
+	err := r.db.Preload("Creneau").Where("inscription_id = ?", inscriptionID).First(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Reserver books a slot for an inscription, or moves its existing booking to the slot.
+// The slot row is locked for the duration of the transaction so that concurrent
+// bookings cannot exceed its capacity. It fails with ErrCreneauComplet when the slot
+// is full and ErrChevauchement when the candidate already has an overlapping interview.
+func (r *EntretienRepository) Reserver(inscriptionID uint, candidatID string, creneauID uint) (*model.ReservationEntretien, error) {
+	var res model.ReservationEntretien
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var creneau model.CreneauEntretien
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&creneau, creneauID).Error; err != nil {
+			return err
+		}
+
+		existing := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("inscription_id = ?", inscriptionID).
+			Limit(1).Find(&res)
+		if existing.Error != nil {
+			return existing.Error
+		}
+		if existing.RowsAffected > 0 && res.CreneauID == creneauID {
+			res.Creneau = &creneau
+			return nil
+		}
+
+		var n int64
+		if err := tx.Model(&model.ReservationEntretien{}).Where("creneau_id = ?", creneauID).Count(&n).Error; err != nil {
+			return err
+		}
+		if int(n) >= creneau.Capacite {
+			return ErrCreneauComplet
+		}
+
+		// The candidate may have interviews for other formations
+		var overlap int64
+		err := tx.Model(&model.ReservationEntretien{}).
+			Joins("JOIN creneaux_entretien c ON c.id = reservations_entretien.creneau_id").
+			Where("reservations_entretien.candidat_id = ? AND reservations_entretien.inscription_id <> ?", candidatID, inscriptionID).
+			Where("c.debut < ? AND c.fin > ?", creneau.Fin, creneau.Debut).
+			Count(&overlap).Error
+		if err != nil {
+			return err
+		}
+		if overlap > 0 {
+			return ErrChevauchement
+		}
+
+		if existing.RowsAffected > 0 {
+			res.CreneauID = creneauID
+			res.Sequence++
+			if err := tx.Omit("Creneau").Save(&res).Error; err != nil {
+				return err
+			}
+		} else {
+			res = model.ReservationEntretien{
+				CreneauID:     creneauID,
+				InscriptionID: inscriptionID,
+				CandidatID:    candidatID,
+			}
+			if err := tx.Omit("Creneau").Create(&res).Error; err != nil {
+				return err
+			}
+		}
+		res.Creneau = &creneau
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrDejaPrisEnCharge is returned when a colleague holds an active claim on the dossier.
+var ErrDejaPrisEnCharge = errors.New("ce dossier est déjà pris en charge")
+
+// InscriptionEnAttente is an inscription with the time it entered its current state.
+type InscriptionEnAttente struct {
+	model.Inscription
+	EntreeEtat time.Time
+}
+
+// FileRepository handles database operations for the coordinators' work queue.
+type FileRepository struct {
+	db *gorm.DB
+}
+
+// NewFileRepository creates a new FileRepository.
+func NewFileRepository(db *gorm.DB) *FileRepository {
+	return &FileRepository{db: db}
+}
+
+// FindEnAttente returns the inscriptions of the formations in one of the given
+// states, with the time they entered it.
+func (r *FileRepository) FindEnAttente(etats []model.EtatInscription, formationIDs []uint) ([]InscriptionEnAttente, error) {
+	var inscriptions []InscriptionEnAttente
+	err := r.db.Model(&model.Inscription{}).
+		Select("inscriptions.*, "+entreeEtat).
+		Where("inscriptions.etat IN ? AND inscriptions.formation_id IN ?", etats, formationIDs).
+		Order("inscriptions.id").
+		Scan(&inscriptions).Error
+	return inscriptions, err
+}
+
+// FindPrises returns the claims recorded on the inscriptions, keyed by inscription.
+// Claims are returned whether active or not.
+func (r *FileRepository) FindPrises(inscriptionIDs []uint) (map[uint]model.PriseEnCharge, error) {
+	prises := map[uint]model.PriseEnCharge{}
+	if len(inscriptionIDs) == 0 {
+		return prises, nil
+	}
+	var liste []model.PriseEnCharge
+	if err := r.db.Where("inscription_id IN ?", inscriptionIDs).Find(&liste).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range liste {
+		prises[p.InscriptionID] = p
+	}
+	return prises, nil
+}
+
+// Prendre claims a dossier, or extends the caller's own claim. The claim replaces a
+// colleague's only once it has lapsed: expired, or taken in a state the dossier has
+// left. Otherwise ErrDejaPrisEnCharge is returned and p holds the colleague's claim.
+// The inscription row is locked FOR SHARE meanwhile, so a claim waits for a state
+// change in progress and the other way round.
+func (r *FileRepository) Prendre(p *model.PriseEnCharge) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var verrou model.Inscription
+		err := tx.Select("id").
+			Clauses(clause.Locking{Strength: "SHARE"}).
+			First(&verrou, p.InscriptionID).Error
+		if err != nil {
+			return err
+		}
+		res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "inscription_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"etat", "par", "le", "expire_le"}),
+			Where: clause.Where{Exprs: []clause.Expression{clause.Or(
+				clause.Expr{SQL: "prises_en_charge.par = excluded.par"},
+				clause.Expr{SQL: "prises_en_charge.expire_le <= excluded.le"},
+				clause.Expr{SQL: "prises_en_charge.etat <> excluded.etat"},
+			)}},
+		}).Create(p)
+		if res.Error != nil || res.RowsAffected > 0 {
+			return res.Error
+		}
+		if err := tx.First(p, "inscription_id = ?", p.InscriptionID).Error; err != nil {
+			return err
+		}
+		return ErrDejaPrisEnCharge
+	})
+}
+
+// Liberer releases the claim on a dossier. An empty par releases it whoever holds it.
+func (r *FileRepository) Liberer(inscriptionID uint, par string) (bool, error) {
+	q := r.db.Where("inscription_id = ?", inscriptionID)
+	if par != "" {
+		q = q.Where("par = ?", par)
+	}
+	res := q.Delete(&model.PriseEnCharge{})
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FormulaireRepository handles database operations for per-formation application forms.
+type FormulaireRepository struct {
+	db *gorm.DB
+}
+
+// NewFormulaireRepository creates a new FormulaireRepository.
+func NewFormulaireRepository(db *gorm.DB) *FormulaireRepository {
+	return &FormulaireRepository{db: db}
+}
+
+// FindByFormationID returns the form defined for a formation.
+func (r *FormulaireRepository) FindByFormationID(formationID uint) (*model.Formulaire, error) {
+	var f model.Formulaire
+	err := r.db.Where("formation_id = ?", formationID).First(&f).Error
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Save defines the form of a formation at version 1, or replaces it with the next
+// version, in one statement so that concurrent changes get distinct versions. f is
+// filled with the stored form.
+func (r *FormulaireRepository) Save(f *model.Formulaire) error {
+	return r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "formation_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"schema":      gorm.Expr("excluded.schema"),
+				"version":     gorm.Expr("formulaires.version + 1"),
+				"modifie_par": gorm.Expr("excluded.modifie_par"),
+				"updated_at":  gorm.Expr("excluded.updated_at"),
+			}),
+		},
+		clause.Returning{},
+	).Create(f).Error
+}
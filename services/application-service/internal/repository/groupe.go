@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrGroupeComplet is returned when a group has no free place left.
+	ErrGroupeComplet = errors.New("groupe complet")
+	// ErrGroupeNonVide is returned when removing a group, or shrinking it below
+	// its headcount, would drop assigned learners.
+	ErrGroupeNonVide = errors.New("le groupe compte des membres")
+)
+
+// GroupeRepository handles database operations for groups and their members.
+type GroupeRepository struct {
+	db *gorm.DB
+}
+
+// NewGroupeRepository creates a new GroupeRepository.
+func NewGroupeRepository(db *gorm.DB) *GroupeRepository {
+	return &GroupeRepository{db: db}
+}
+
+// FindByFormationID returns the groups of a formation with their headcount.
+func (r *GroupeRepository) FindByFormationID(formationID uint) ([]model.Groupe, error) {
+	var groupes []model.Groupe
+	if err := r.db.Where("formation_id = ?", formationID).Order("nom, id").Find(&groupes).Error; err != nil {
+		return nil, err
+	}
+	if err := r.fillEffectifs(r.db, groupes); err != nil {
+		return nil, err
+	}
+	return groupes, nil
+}
+
+// FindByID returns a group with its headcount.
+func (r *GroupeRepository) FindByID(id uint) (*model.Groupe, error) {
+	var g model.Groupe
+	if err := r.db.First(&g, id).Error; err != nil {
+		return nil, err
+	}
+	groupes := []model.Groupe{g}
+	if err := r.fillEffectifs(r.db, groupes); err != nil {
+		return nil, err
+	}
+	return &groupes[0], nil
+}
+
+func (r *GroupeRepository) fillEffectifs(tx *gorm.DB, groupes []model.Groupe) error {
+	if len(groupes) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(groupes))
+	for i, g := range groupes {
+		ids[i] = g.ID
+	}
+	var counts []struct {
+		GroupeID uint
+		N        int
+	}
+	err := tx.Model(&model.MembreGroupe{}).
+		Select("groupe_id, COUNT(*) AS n").
+		Where("groupe_id IN ?", ids).
+		Group("groupe_id").
+		Scan(&counts).Error
+	if err != nil {
+		return err
+	}
+	byID := map[uint]int{}
+	for _, c := range counts {
+		byID[c.GroupeID] = c.N
+	}
+	for i := range groupes {
+		groupes[i].Effectif = byID[groupes[i].ID]
+	}
+	return nil
+}
+
+// Create inserts a new group.
+func (r *GroupeRepository) Create(g *model.Groupe) error {
+	return r.db.Create(g).Error
+}
+
+// Update saves a group; the capacity cannot drop below its current headcount.
+func (r *GroupeRepository) Update(g *model.Groupe) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&model.MembreGroupe{}).Where("groupe_id = ?", g.ID).Count(&n).Error; err != nil {
+			return err
+		}
+		if int(n) > g.Capacite {
+			return ErrGroupeNonVide
+		}
+		return tx.Save(g).Error
+	})
+}
+
+// Delete removes a group that has no member.
+func (r *GroupeRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&model.MembreGroupe{}).Where("groupe_id = ?", id).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrGroupeNonVide
+		}
+		return tx.Delete(&model.Groupe{}, id).Error
+	})
+}
+
+// FindMembres returns the roster of a group, ordered by learner name. Dossiers in the
+// trash or anonymised are left out.
+func (r *GroupeRepository) FindMembres(groupeID uint) ([]model.MembreGroupe, error) {
+	var membres []model.MembreGroupe
+	err := r.db.Preload("Inscription").
+		Joins("JOIN inscriptions ON inscriptions.id = membres_groupes.inscription_id").
+		Where("membres_groupes.groupe_id = ?", groupeID).
+		Where("inscriptions.deleted_at IS NULL AND inscriptions.anonymise_le IS NULL").
+		Order("inscriptions.nom_complet, membres_groupes.id").
+		Find(&membres).Error
+	return membres, err
+}
+
+// FindByInscriptionID returns the group membership of an inscription.
+func (r *GroupeRepository) FindByInscriptionID(inscriptionID uint) (*model.MembreGroupe, error) {
+	var m model.MembreGroupe
+	if err := r.db.Where("inscription_id = ?", inscriptionID).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Affecter assigns an inscription to a group, moving it out of its previous group.
+// The group row is locked so that concurrent assignments cannot exceed its capacity.
+func (r *GroupeRepository) Affecter(inscriptionID, groupeID uint, par string) (*model.MembreGroupe, error) {
+	var m model.MembreGroupe
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var g model.Groupe
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&g, groupeID).Error; err != nil {
+			return err
+		}
+
+		existing := tx.Where("inscription_id = ?", inscriptionID).Limit(1).Find(&m)
+		if existing.Error != nil {
+			return existing.Error
+		}
+		if existing.RowsAffected > 0 && m.GroupeID == groupeID {
+			return nil
+		}
+
+		var n int64
+		if err := tx.Model(&model.MembreGroupe{}).Where("groupe_id = ?", groupeID).Count(&n).Error; err != nil {
+			return err
+		}
+		if int(n) >= g.Capacite {
+			return ErrGroupeComplet
+		}
+
+		if existing.RowsAffected > 0 {
+			m.GroupeID = groupeID
+			m.AffectePar = par
+			return tx.Omit("Inscription").Save(&m).Error
+		}
+		m = model.MembreGroupe{GroupeID: groupeID, InscriptionID: inscriptionID, AffectePar: par}
+		return tx.Omit("Inscription").Create(&m).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Retirer removes an inscription from its group.
+func (r *GroupeRepository) Retirer(inscriptionID uint) (int64, error) {
+	res := r.db.Where("inscription_id = ?", inscriptionID).Delete(&model.MembreGroupe{})
+	return res.RowsAffected, res.Error
+}
+
+// Repartir distributes the formation's enrolled (INSCRIT) learners that have no group
+// yet over its groups, each learner going to the group with the lowest fill rate.
+// It returns how many were assigned and how many were left out for lack of room.
+func (r *GroupeRepository) Repartir(formationID uint, par string) (affectes, restants int, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var groupes []model.Groupe
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("formation_id = ?", formationID).
+			Order("id").
+			Find(&groupes).Error
+		if err != nil {
+			return err
+		}
+		if err := r.fillEffectifs(tx, groupes); err != nil {
+			return err
+		}
+
+		var ids []uint
+		err = tx.Model(&model.Inscription{}).
+			Where("formation_id = ? AND etat = ?", formationID, model.EtatInscrit).
+			Where("NOT EXISTS (SELECT 1 FROM membres_groupes m WHERE m.inscription_id = inscriptions.id)").
+			Order("id").
+			Pluck("id", &ids).Error
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			best := -1
+			for i, g := range groupes {
+				if g.Effectif >= g.Capacite {
+					continue
+				}
+				if best < 0 || fillRate(g) < fillRate(groupes[best]) {
+					best = i
+				}
+			}
+			if best < 0 {
+				restants++
+				continue
+			}
+			m := model.MembreGroupe{GroupeID: groupes[best].ID, InscriptionID: id, AffectePar: par}
+			if err := tx.Omit("Inscription").Create(&m).Error; err != nil {
+				return err
+			}
+			groupes[best].Effectif++
+			affectes++
+		}
+		return nil
+	})
+	return affectes, restants, err
+}
+
+func fillRate(g model.Groupe) float64 {
+	return float64(g.Effectif) / float64(g.Capacite)
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotenceRepository handles database operations for idempotency keys.
+type IdempotenceRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotenceRepository creates a new IdempotenceRepository.
+func NewIdempotenceRepository(db *gorm.DB) *IdempotenceRepository {
+	return &IdempotenceRepository{db: db}
+}
+
+// Reserver claims a key for a new request. When the caller already used the key
+// and it has not expired, nothing is inserted and the stored record is returned instead.
+func (r *IdempotenceRepository) Reserver(cle *model.CleIdempotence) (*model.CleIdempotence, error) {
+	var existing *model.CleIdempotence
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("utilisateur_id = ? AND cle = ? AND expire_le <= ?", cle.UtilisateurID, cle.Cle, time.Now()).
+			Delete(&model.CleIdempotence{}).Error
+		if err != nil {
+			return err
+		}
+
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(cle)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			return nil
+		}
+
+		var found model.CleIdempotence
+		if err := tx.Where("utilisateur_id = ? AND cle = ?", cle.UtilisateurID, cle.Cle).First(&found).Error; err != nil {
+			return err
+		}
+		existing = &found
+		return nil
+	})
+	return existing, err
+}
+
+// Terminer stores the response of the request that claimed the key.
+func (r *IdempotenceRepository) Terminer(id uint, statut int, contentType string, reponse []byte) error {
+	return r.db.Model(&model.CleIdempotence{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"statut":       statut,
+		"content_type": contentType,
+		"reponse":      reponse,
+	}).Error
+}
+
+// Liberer drops a claimed key so that the request can be retried.
+func (r *IdempotenceRepository) Liberer(id uint) error {
+	return r.db.Delete(&model.CleIdempotence{}, id).Error
+}
+
+// PurgeExpired deletes expired keys and returns how many were removed.
+func (r *IdempotenceRepository) PurgeExpired() (int64, error) {
+	res := r.db.Where("expire_le <= ?", time.Now()).Delete(&model.CleIdempotence{})
+	return res.RowsAffected, res.Error
+}
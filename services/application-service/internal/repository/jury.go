@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSessionJuryOuverte is returned when the formation already has an open session.
+	ErrSessionJuryOuverte = errors.New("une session de jury est déjà ouverte pour cette formation")
+	// ErrSessionJuryClose is returned when changing a session that has been closed.
+	ErrSessionJuryClose = errors.New("la session de jury est close")
+	// ErrSessionJuryVide is returned when closing a session that took no decision.
+	ErrSessionJuryVide = errors.New("la session de jury n'a pris aucune décision")
+)
+
+// JuryRepository handles database operations for jury deliberation sessions.
+type JuryRepository struct {
+	db *gorm.DB
+}
+
+// NewJuryRepository creates a new JuryRepository.
+func NewJuryRepository(db *gorm.DB) *JuryRepository {
+	return &JuryRepository{db: db}
+}
+
+// Create opens a session.
+func (r *JuryRepository) Create(s *model.SessionJury) error {
+	err := r.db.Create(s).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrSessionJuryOuverte
+	}
+	return err
+}
+
+// Update saves the details of a session while it is open.
+func (r *JuryRepository) Update(s *model.SessionJury) error {
+	res := r.db.Model(&model.SessionJury{}).
+		Where("id = ? AND statut = ?", s.ID, model.SessionJuryOuverte).
+		Updates(map[string]interface{}{
+			"intitule":  s.Intitule,
+			"tenue_le":  s.TenueLe,
+			"lieu":      s.Lieu,
+			"president": s.President,
+			"membres":   s.Membres,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrSessionJuryClose
+	}
+	return nil
+}
+
+// FindByFormation returns the sessions of a formation, newest first, without their
+// minutes.
+func (r *JuryRepository) FindByFormation(formationID uint) ([]model.SessionJury, error) {
+	var sessions []model.SessionJury
+	err := r.db.Omit("proces").
+		Where("formation_id = ?", formationID).
+		Order("tenue_le DESC, id DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// FindByID returns a session with the decisions attached to it.
+func (r *JuryRepository) FindByID(id uint) (*model.SessionJury, error) {
+	var s model.SessionJury
+	err := r.db.Preload("Decisions", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Decisions.Motifs").
+		First(&s, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// FindOuverte returns the open session of a formation, or nil when there is none.
+func (r *JuryRepository) FindOuverte(formationID uint) (*model.SessionJury, error) {
+	var sessions []model.SessionJury
+	err := r.db.Omit("proces").
+		Where("formation_id = ? AND statut = ?", formationID, model.SessionJuryOuverte).
+		Limit(1).
+		Find(&sessions).Error
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return &sessions[0], nil
+}
+
+// FindVerrous returns, among the given inscriptions, those whose current state was
+// decided by a closed session, with that session.
+func (r *JuryRepository) FindVerrous(inscriptionIDs []uint) (map[uint]uint, error) {
+	verrous := map[uint]uint{}
+	if len(inscriptionIDs) == 0 {
+		return verrous, nil
+	}
+	var lignes []struct {
+		InscriptionID uint
+		SessionJuryID uint
+	}
+	err := r.db.Table("decisions AS d").
+		Select("d.inscription_id, d.session_jury_id").
+		Joins("JOIN sessions_jury AS s ON s.id = d.session_jury_id").
+		Joins("JOIN inscriptions AS i ON i.id = d.inscription_id AND i.etat = d.etat").
+		Where("d.inscription_id IN ? AND s.statut = ?", inscriptionIDs, model.SessionJuryClose).
+		Where("d.id = (SELECT max(id) FROM decisions WHERE inscription_id = d.inscription_id)").
+		Scan(&lignes).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lignes {
+		verrous[l.InscriptionID] = l.SessionJuryID
+	}
+	return verrous, nil
+}
+
+// Cloturer closes an open session and freezes its minutes: for every dossier it
+// decided, its last decision in the session with the jury score at closing time.
+func (r *JuryRepository) Cloturer(s *model.SessionJury, par string, le time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// The update waits for the transactions attaching a decision to the session, which
+		// hold its row FOR SHARE, and the later ones find it closed: the decisions read
+		// below are all it will ever have
+		res := tx.Model(&model.SessionJury{}).
+			Where("id = ? AND statut = ?", s.ID, model.SessionJuryOuverte).
+			Updates(map[string]interface{}{
+				"statut":      model.SessionJuryClose,
+				"cloture_par": par,
+				"cloture_le":  le,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrSessionJuryClose
+		}
+
+		var decisions []model.Decision
+		err := tx.Preload("Motifs").
+			Where("session_jury_id = ?", s.ID).
+			Order("id").
+			Find(&decisions).Error
+		if err != nil {
+			return err
+		}
+		if len(decisions) == 0 {
+			return ErrSessionJuryVide
+		}
+
+		derniere := map[uint]model.Decision{}
+		var ids []uint
+		for _, d := range decisions {
+			if _, ok := derniere[d.InscriptionID]; !ok {
+				ids = append(ids, d.InscriptionID)
+			}
+			derniere[d.InscriptionID] = d
+		}
+		var inscriptions []model.Inscription
+		if err := tx.Unscoped().Where("id IN ?", ids).Order("id").Find(&inscriptions).Error; err != nil {
+			return err
+		}
+
+		lignes := make([]model.LigneProces, 0, len(inscriptions))
+		for _, ins := range inscriptions {
+			d := derniere[ins.ID]
+			ligne := model.LigneProces{
+				InscriptionID: ins.ID,
+				Reference:     ins.NumeroDossier(),
+				NomComplet:    ins.NomComplet,
+				Etat:          d.Etat,
+				Score:         ins.ScoreJury,
+				DecisionID:    d.ID,
+			}
+			for _, m := range d.Motifs {
+				ligne.Motifs = append(ligne.Motifs, m.Libelle)
+			}
+			lignes = append(lignes, ligne)
+		}
+		proces, err := json.Marshal(lignes)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&model.SessionJury{}).Where("id = ?", s.ID).Update("proces", model.JSON(proces)).Error; err != nil {
+			return err
+		}
+
+		s.Statut = model.SessionJuryClose
+		s.CloturePar = &par
+		s.ClotureLe = &le
+		s.Proces = model.JSON(proces)
+		return nil
+	})
+}
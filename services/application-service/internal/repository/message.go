@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// MessageRepository handles database operations for bulk messages.
+type MessageRepository struct {
+	db *gorm.DB
+}
+
+// NewMessageRepository creates a new MessageRepository.
+func NewMessageRepository(db *gorm.DB) *MessageRepository {
+	return &MessageRepository{db: db}
+}
+
+// Create records a message with one delivery per recipient.
+func (r *MessageRepository) Create(m *model.MessageGroupe) error {
+	return r.db.Create(m).Error
+}
+
+// MarquerEnvoi records the outcome of handing a delivery over to notification-service,
+// or its latest status.
+func (r *MessageRepository) MarquerEnvoi(e *model.EnvoiMessage) error {
+	return r.db.Model(e).Updates(map[string]interface{}{
+		"notification_id": e.NotificationID,
+		"statut":          e.Statut,
+		"erreur":          e.Erreur,
+	}).Error
+}
+
+// FindAll returns the messages, newest first, without their deliveries. An empty
+// etablissementID means global access.
+func (r *MessageRepository) FindAll(etablissementID string) ([]model.MessageGroupe, error) {
+	var messages []model.MessageGroupe
+	q := r.db.Order("created_at DESC, id DESC")
+	if etablissementID != "" {
+		q = q.Where("etablissement_id = ?", etablissementID)
+	}
+	err := q.Find(&messages).Error
+	return messages, err
+}
+
+// FindByID returns a message with its deliveries.
+func (r *MessageRepository) FindByID(id uint) (*model.MessageGroupe, error) {
+	var m model.MessageGroupe
+	err := r.db.Preload("Envois", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&m, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// SyntheseEnvois counts the deliveries of a message per status.
+func (r *MessageRepository) SyntheseEnvois(messageID uint) (map[model.StatutEnvoi]int, error) {
+	var lignes []struct {
+		Statut model.StatutEnvoi
+		N      int
+	}
+	err := r.db.Model(&model.EnvoiMessage{}).
+		Select("statut, count(*) AS n").
+		Where("message_id = ?", messageID).
+		Group("statut").
+		Scan(&lignes).Error
+	if err != nil {
+		return nil, err
+	}
+	synthese := map[model.StatutEnvoi]int{}
+	for _, l := range lignes {
+		synthese[l.Statut] = l.N
+	}
+	return synthese, nil
+}
+
+// FindEnvoisEnCours returns up to limit deliveries whose status may still change,
+// after the given ID, in ID order.
+func (r *MessageRepository) FindEnvoisEnCours(apres uint, limit int) ([]model.EnvoiMessage, error) {
+	var envois []model.EnvoiMessage
+	err := r.db.Where("id > ? AND statut NOT IN ?", apres,
+		[]model.StatutEnvoi{model.EnvoiEnvoye, model.EnvoiAbandonne}).
+		Order("id").
+		Limit(limit).
+		Find(&envois).Error
+	return envois, err
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MotifRefusRepository handles database operations for refusal reason codes.
+type MotifRefusRepository struct {
+	db *gorm.DB
+}
+
+// NewMotifRefusRepository creates a new MotifRefusRepository.
+func NewMotifRefusRepository(db *gorm.DB) *MotifRefusRepository {
+	return &MotifRefusRepository{db: db}
+}
+
+// SeedDefaults inserts the global catalogue, leaving existing codes untouched.
+func (r *MotifRefusRepository) SeedDefaults(motifs []model.MotifRefus) error {
+	for _, m := range motifs {
+		m.Actif = true
+		if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&m).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindAvailable returns the global codes plus those of the given institution.
+func (r *MotifRefusRepository) FindAvailable(etablissementID string, actifOnly bool) ([]model.MotifRefus, error) {
+	var motifs []model.MotifRefus
+	q := r.db.Where("etablissement_id IN ?", []string{"", etablissementID}).Order("etablissement_id, code")
+	if actifOnly {
+		q = q.Where("actif = ?", true)
+	}
+	err := q.Find(&motifs).Error
+	return motifs, err
+}
+
+// FindByID returns a refusal reason by ID.
+func (r *MotifRefusRepository) FindByID(id uint) (*model.MotifRefus, error) {
+	var m model.MotifRefus
+	err := r.db.First(&m, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// FindActiveByCodes resolves active codes available to an institution.
+func (r *MotifRefusRepository) FindActiveByCodes(etablissementID string, codes []string) ([]model.MotifRefus, error) {
+	var motifs []model.MotifRefus
+	err := r.db.Where("etablissement_id IN ? AND code IN ? AND actif = ?", []string{"", etablissementID}, codes, true).
+		Find(&motifs).Error
+	return motifs, err
+}
+
+// Create inserts a new refusal reason.
+func (r *MotifRefusRepository) Create(m *model.MotifRefus) error {
+	return r.db.Create(m).Error
+}
+
+// Update saves changes to an existing refusal reason.
+func (r *MotifRefusRepository) Update(m *model.MotifRefus) error {
+	return r.db.Save(m).Error
+}
+
+// Stats returns the number of refused inscriptions per reason code,
+// optionally restricted to one formation and/or one institution.
+func (r *MotifRefusRepository) Stats(formationID uint, etablissementID string) ([]model.StatMotifRefus, error) {
+	var stats []model.StatMotifRefus
+	q := r.db.Table("decision_motifs dm").
+		Select("m.code, m.libelle, COUNT(DISTINCT d.inscription_id) AS total").
+		Joins("JOIN decisions d ON d.id = dm.decision_id").
+		Joins("JOIN motifs_refus m ON m.id = dm.motif_refus_id").
+		Joins("JOIN inscriptions i ON i.id = d.inscription_id AND i.deleted_at IS NULL").
+		Where("d.etat = ?", model.EtatRefuse)
+	if formationID != 0 {
+		q = q.Where("i.formation_id = ?", formationID)
+	}
+	if etablissementID != "" {
+		q = q.Where("i.etablissement_id = ?", etablissementID)
+	}
+	err := q.Group("m.code, m.libelle").Order("total DESC").Scan(&stats).Error
+	return stats, err
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FindOffresARappeler returns the unconfirmed offers expiring before the given time
+// that have not been reminded yet.
+func (r *InscriptionRepository) FindOffresARappeler(avant time.Time) ([]model.Inscription, error) {
+	var inscriptions []model.Inscription
+	err := r.db.Where("etat = ? AND offre_confirmee_le IS NULL AND offre_rappel_le IS NULL", model.EtatAccepte).
+		Where("offre_expire_le > ? AND offre_expire_le <= ?", time.Now(), avant).
+		Order("offre_expire_le").
+		Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// MarquerRappelOffre records that the offer reminder was sent.
+func (r *InscriptionRepository) MarquerRappelOffre(id uint) error {
+	return r.db.Model(&model.Inscription{}).Where("id = ?", id).Update("offre_rappel_le", time.Now()).Error
+}
+
+// FindOffresExpirees returns the unconfirmed offers whose deadline has passed.
+func (r *InscriptionRepository) FindOffresExpirees() ([]model.Inscription, error) {
+	var inscriptions []model.Inscription
+	err := r.db.Where("etat = ? AND offre_confirmee_le IS NULL AND offre_expire_le <= ?", model.EtatAccepte, time.Now()).
+		Order("offre_expire_le").
+		Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// ConfirmerOffre records the candidate's confirmation of an offer still open.
+// It reports false when the offer was no longer open.
+func (r *InscriptionRepository) ConfirmerOffre(id uint) (bool, error) {
+	res := r.db.Model(&model.Inscription{}).
+		Where("id = ? AND etat = ? AND offre_confirmee_le IS NULL AND (offre_expire_le IS NULL OR offre_expire_le > ?)",
+			id, model.EtatAccepte, time.Now()).
+		Update("offre_confirmee_le", time.Now())
+	return res.RowsAffected > 0, res.Error
+}
+
+// Desister moves an ACCEPTE inscription to DESISTE with its decision and history,
+// freeing its seat. It reports false when the inscription was no longer ACCEPTE, and
+// returns ErrDejaPrisEnCharge with their claim while a colleague of decidePar holds
+// one on the dossier.
+func (r *InscriptionRepository) Desister(ins *model.Inscription, decidePar, commentaire string) (bool, *model.PriseEnCharge, error) {
+	return r.desister(ins, decidePar, commentaire, "id = ? AND etat = ?", ins.ID, model.EtatAccepte)
+}
+
+// ExpirerOffre is Desister for an offer whose deadline has passed. It reports false
+// when the offer was confirmed or extended in the meantime.
+func (r *InscriptionRepository) ExpirerOffre(ins *model.Inscription, commentaire string) (bool, *model.PriseEnCharge, error) {
+	return r.desister(ins, "SYSTEM", commentaire,
+		"id = ? AND etat = ? AND offre_confirmee_le IS NULL AND offre_expire_le <= ?",
+		ins.ID, model.EtatAccepte, time.Now())
+}
+
+// desister moves the inscription to DESISTE through changerEtatPar if it still
+// matches the condition, which is checked with the row locked FOR UPDATE.
+func (r *InscriptionRepository) desister(ins *model.Inscription, decidePar, commentaire, cond string, args ...interface{}) (bool, *model.PriseEnCharge, error) {
+	desiste := false
+	var prise *model.PriseEnCharge
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var lignes []model.Inscription
+		err := tx.Select("id").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(cond, args...).
+			Find(&lignes).Error
+		if err != nil || len(lignes) == 0 {
+			return err
+		}
+
+		accepte := *ins
+		accepte.Etat = model.EtatAccepte
+		prise, err = changerEtatPar(tx, decidePar, &accepte, map[string]interface{}{"etat": model.EtatDesiste},
+			&model.Decision{
+				InscriptionID: ins.ID,
+				DecidePar:     decidePar,
+				Etat:          model.EtatDesiste,
+				Commentaire:   commentaire,
+			},
+			&model.InscriptionHistorique{
+				InscriptionID: ins.ID,
+				AncienEtat:    model.EtatAccepte,
+				NouvelEtat:    model.EtatDesiste,
+				ModifiePar:    decidePar,
+				Commentaire:   commentaire,
+			})
+		if err != nil {
+			return err
+		}
+		desiste = true
+		return nil
+	})
+	if err != nil {
+		return false, prise, err
+	}
+	if desiste {
+		ins.Etat = model.EtatDesiste
+	}
+	return desiste, nil, nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// ParametresFormationRepository handles database operations for per-formation settings.
+type ParametresFormationRepository struct {
+	db *gorm.DB
+}
+
+// NewParametresFormationRepository creates a new ParametresFormationRepository.
+func NewParametresFormationRepository(db *gorm.DB) *ParametresFormationRepository {
+	return &ParametresFormationRepository{db: db}
+}
+
+// Get returns the settings of a formation, or the defaults when none were saved.
+func (r *ParametresFormationRepository) Get(formationID uint) (*model.ParametresFormation, error) {
+	var p model.ParametresFormation
+	err := r.db.First(&p, formationID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.DefaultParametresFormation(formationID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Save inserts or updates the settings of a formation.
+func (r *ParametresFormationRepository) Save(p *model.ParametresFormation) error {
+	return r.db.Save(p).Error
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrPublicationConcurrente is returned when another version of the list was
+// published at the same time.
+var ErrPublicationConcurrente = errors.New("une autre version de la liste vient d'être publiée, réessayez")
+
+// PublicationRepository handles database operations for published results lists.
+type PublicationRepository struct {
+	db *gorm.DB
+}
+
+// NewPublicationRepository creates a new PublicationRepository.
+func NewPublicationRepository(db *gorm.DB) *PublicationRepository {
+	return &PublicationRepository{db: db}
+}
+
+// FindAdmissions returns the admitted and waitlisted inscriptions of a formation,
+// best jury score first.
+func (r *PublicationRepository) FindAdmissions(formationID uint) ([]model.Inscription, error) {
+	var inscriptions []model.Inscription
+	err := r.db.Where("formation_id = ? AND etat IN ?", formationID,
+		[]model.EtatInscription{model.EtatAccepte, model.EtatListeAttente}).
+		Order("score_jury DESC NULLS LAST, id").
+		Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// Publier records p as the next version of the formation's list.
+func (r *PublicationRepository) Publier(p *model.PublicationResultats) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var derniere int
+		err := tx.Model(&model.PublicationResultats{}).
+			Where("formation_id = ?", p.FormationID).
+			Select("coalesce(max(version), 0)").
+			Scan(&derniere).Error
+		if err != nil {
+			return err
+		}
+		p.Version = derniere + 1
+		return tx.Create(p).Error
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrPublicationConcurrente
+	}
+	return err
+}
+
+// FindByFormation returns the published versions of a formation's list, newest
+// first, without their content.
+func (r *PublicationRepository) FindByFormation(formationID uint) ([]model.PublicationResultats, error) {
+	var publications []model.PublicationResultats
+	err := r.db.Omit("lignes").
+		Where("formation_id = ?", formationID).
+		Order("version DESC").
+		Find(&publications).Error
+	return publications, err
+}
+
+// FindVersion returns a version of a formation's list; version 0 is the latest.
+func (r *PublicationRepository) FindVersion(formationID uint, version int) (*model.PublicationResultats, error) {
+	q := r.db.Where("formation_id = ?", formationID)
+	if version > 0 {
+		q = q.Where("version = ?", version)
+	}
+	var p model.PublicationResultats
+	if err := q.Order("version DESC").First(&p).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
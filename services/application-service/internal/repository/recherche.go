@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+)
+
+// Search finds the inscriptions matching a free-text term among those selected by the
+// filter, best matches first. Words are matched by prefix, accents are ignored, French
+// words are stemmed in notes and names, and names and e-mails tolerate typos (pg_trgm).
+// A dossier number (CFC-2026-FST-000123) finds its inscription first.
+func (r *InscriptionRepository) Search(terme string, f InscriptionFilter, limit int) ([]model.ResultatRecherche, error) {
+	prefixe := prefixQuery(terme)
+	if prefixe == "" {
+		return []model.ResultatRecherche{}, nil
+	}
+
+	var rows []struct {
+		ID         uint
+		Score      float64
+		NomComplet string
+		Email      string
+		Notes      string
+	}
+	err := r.filtered(f).
+		Joins(`CROSS JOIN (SELECT
+			to_tsquery('simple_unaccent', ?) || plainto_tsquery('fr_unaccent', ?) AS q,
+			immutable_unaccent(lower(?)) AS t,
+			upper(trim(?)) AS r) AS s`, prefixe, terme, terme, terme).
+		Select(`inscriptions.id,
+			ts_rank_cd(recherche, s.q)
+				+ word_similarity(s.t, immutable_unaccent(lower(nom_complet)))
+				+ word_similarity(s.t, lower(email))
+				+ CASE WHEN reference = s.r THEN 10 ELSE 0 END AS score,
+			ts_headline('simple_unaccent', nom_complet, s.q, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>') AS nom_complet,
+			ts_headline('simple_unaccent', email, s.q, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>') AS email,
+			ts_headline('fr_unaccent', coalesce(notes, ''), s.q, 'MaxFragments=2, MinWords=5, MaxWords=20, StartSel=<mark>, StopSel=</mark>') AS notes`).
+		Where(`reference = s.r
+			OR recherche @@ s.q
+			OR s.t <% immutable_unaccent(lower(nom_complet))
+			OR s.t <% lower(email)`).
+		Order("score DESC, inscriptions.id").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []model.ResultatRecherche{}, nil
+	}
+
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	var inscriptions []model.Inscription
+	if err := r.db.Where("id IN ?", ids).Find(&inscriptions).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]model.Inscription, len(inscriptions))
+	for _, ins := range inscriptions {
+		byID[ins.ID] = ins
+	}
+
+	resultats := make([]model.ResultatRecherche, 0, len(rows))
+	for _, row := range rows {
+		ins, ok := byID[row.ID]
+		if !ok {
+			continue
+		}
+		resultats = append(resultats, model.ResultatRecherche{
+			Inscription: ins,
+			Score:       row.Score,
+			Surlignage: model.Surlignage{
+				NomComplet: row.NomComplet,
+				Email:      row.Email,
+				Notes:      row.Notes,
+			},
+		})
+	}
+	return resultats, nil
+}
+
+// prefixQuery turns free text into a tsquery matching every word by prefix,
+// e.g. "Jean Dup" → "jean:* & dup:*". Anything but letters and digits is dropped,
+// so the result is always valid tsquery syntax.
+func prefixQuery(terme string) string {
+	words := strings.FieldsFunc(terme, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	parts := make([]string, len(words))
+	for i, w := range words {
+		parts[i] = fmt.Sprintf("%s:*", strings.ToLower(w))
+	}
+	return strings.Join(parts, " & ")
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrRecoursDepose is returned when an appeal was already filed against the refusal.
+var ErrRecoursDepose = errors.New("un recours a déjà été déposé pour ce refus")
+
+// ErrRecoursExamine is returned when an appeal was reviewed concurrently.
+var ErrRecoursExamine = errors.New("ce recours a déjà été examiné")
+
+// RecoursRepository handles database operations for appeals.
+type RecoursRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoursRepository creates a new RecoursRepository.
+func NewRecoursRepository(db *gorm.DB) *RecoursRepository {
+	return &RecoursRepository{db: db}
+}
+
+// FindAll returns all appeals, optionally filtered by status,
+// restricted to the inscriptions of one institution when etablissementID is set.
+func (r *RecoursRepository) FindAll(statut, etablissementID string) ([]model.Recours, error) {
+	var recours []model.Recours
+	q := r.db.Preload("Documents").Order("recours.created_at")
+	if statut != "" {
+		q = q.Where("recours.statut = ?", statut)
+	}
+	if etablissementID != "" {
+		q = q.Joins("JOIN inscriptions ON inscriptions.id = recours.inscription_id").
+			Where("inscriptions.etablissement_id = ?", etablissementID)
+	}
+	err := q.Find(&recours).Error
+	return recours, err
+}
+
+// FindByID returns an appeal by ID with its supporting documents.
+func (r *RecoursRepository) FindByID(id uint) (*model.Recours, error) {
+	var rec model.Recours
+	err := r.db.Preload("Documents").First(&rec, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// FindByInscriptionID returns all appeals filed for an inscription, oldest first.
+func (r *RecoursRepository) FindByInscriptionID(inscriptionID uint) ([]model.Recours, error) {
+	var recours []model.Recours
+	err := r.db.Preload("Documents").
+		Where("inscription_id = ?", inscriptionID).
+		Order("created_at").
+		Find(&recours).Error
+	return recours, err
+}
+
+// CountSince returns how many appeals were filed for an inscription after the given refusal.
+func (r *RecoursRepository) CountSince(inscriptionID uint, refusal *model.Decision) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Recours{}).
+		Where("inscription_id = ? AND created_at >= ?", inscriptionID, refusal.CreatedAt).
+		Count(&count).Error
+	return count, err
+}
+
+// Create inserts a new appeal against the given refusal together with its supporting
+// documents. The inscription row is locked FOR UPDATE while the appeals filed since
+// the refusal are counted, so that concurrent requests file one appeal at most: the
+// others get ErrRecoursDepose.
+func (r *RecoursRepository) Create(rec *model.Recours, refus *model.Decision) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var verrou model.Inscription
+		err := tx.Select("id").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&verrou, rec.InscriptionID).Error
+		if err != nil {
+			return err
+		}
+		var n int64
+		err = tx.Model(&model.Recours{}).
+			Where("inscription_id = ? AND created_at >= ?", rec.InscriptionID, refus.CreatedAt).
+			Count(&n).Error
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrRecoursDepose
+		}
+		return tx.Create(rec).Error
+	})
+}
+
+// Examiner records the review outcome of a pending appeal and, for an upheld one, the
+// reopening of the inscription with its decision and history entry, in one
+// transaction. decision and historique are nil for a rejected appeal. It returns
+// ErrRecoursExamine if the appeal was reviewed meanwhile, and ErrEtatModifie if the
+// inscription left the state it was refused in.
+func (r *RecoursRepository) Examiner(rec *model.Recours, ins *model.Inscription, decision *model.Decision, historique *model.InscriptionHistorique) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.Recours{}).
+			Where("id = ? AND statut = ?", rec.ID, model.RecoursEnAttente).
+			Updates(map[string]interface{}{
+				"statut":        rec.Statut,
+				"reponse":       rec.Reponse,
+				"examine_par":   rec.ExaminePar,
+				"date_decision": rec.DateDecision,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRecoursExamine
+		}
+		if decision == nil {
+			return nil
+		}
+		return changerEtat(tx, ins, map[string]interface{}{"etat": decision.Etat}, decision, historique)
+	})
+}
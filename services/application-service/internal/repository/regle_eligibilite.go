@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// RegleEligibiliteRepository handles database operations for eligibility rules.
+type RegleEligibiliteRepository struct {
+	db *gorm.DB
+}
+
+// NewRegleEligibiliteRepository creates a new RegleEligibiliteRepository.
+func NewRegleEligibiliteRepository(db *gorm.DB) *RegleEligibiliteRepository {
+	return &RegleEligibiliteRepository{db: db}
+}
+
+// FindByFormationID returns the rules of a formation in evaluation order.
+func (r *RegleEligibiliteRepository) FindByFormationID(formationID uint) ([]model.RegleEligibilite, error) {
+	var regles []model.RegleEligibilite
+	err := r.db.Where("formation_id = ?", formationID).Order("ordre, id").Find(&regles).Error
+	return regles, err
+}
+
+// Replace swaps the whole rule set of a formation in one transaction.
+func (r *RegleEligibiliteRepository) Replace(formationID uint, regles []model.RegleEligibilite) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("formation_id = ?", formationID).Delete(&model.RegleEligibilite{}).Error; err != nil {
+			return err
+		}
+		if len(regles) == 0 {
+			return nil
+		}
+		return tx.Create(&regles).Error
+	})
+}
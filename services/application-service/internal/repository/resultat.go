@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrModuleNote is returned when deleting a module that already has grades.
+var ErrModuleNote = errors.New("le module a déjà des notes")
+
+// ResultatRepository handles database operations for modules, grades and certificates.
+type ResultatRepository struct {
+	db *gorm.DB
+}
+
+// NewResultatRepository creates a new ResultatRepository.
+func NewResultatRepository(db *gorm.DB) *ResultatRepository {
+	return &ResultatRepository{db: db}
+}
+
+// FindModules returns the modules of a formation in display order.
+func (r *ResultatRepository) FindModules(formationID uint) ([]model.ModuleFormation, error) {
+	var modules []model.ModuleFormation
+	err := r.db.Where("formation_id = ?", formationID).Order("ordre, id").Find(&modules).Error
+	return modules, err
+}
+
+// FindModuleByID returns a module by ID.
+func (r *ResultatRepository) FindModuleByID(id uint) (*model.ModuleFormation, error) {
+	var m model.ModuleFormation
+	if err := r.db.First(&m, id).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// CreateModule inserts a new module.
+func (r *ResultatRepository) CreateModule(m *model.ModuleFormation) error {
+	return r.db.Create(m).Error
+}
+
+// UpdateModule saves changes to a module.
+func (r *ResultatRepository) UpdateModule(m *model.ModuleFormation) error {
+	return r.db.Save(m).Error
+}
+
+// DeleteModule removes a module that has no grade.
+func (r *ResultatRepository) DeleteModule(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&model.Note{}).Where("module_id = ?", id).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrModuleNote
+		}
+		return tx.Delete(&model.ModuleFormation{}, id).Error
+	})
+}
+
+// FindNotesByModule returns the grades entered for a module.
+func (r *ResultatRepository) FindNotesByModule(moduleID uint) ([]model.Note, error) {
+	var notes []model.Note
+	err := r.db.Where("module_id = ?", moduleID).Find(&notes).Error
+	return notes, err
+}
+
+// FindNotesByFormation returns every grade of a formation's modules.
+func (r *ResultatRepository) FindNotesByFormation(formationID uint) ([]model.Note, error) {
+	var notes []model.Note
+	err := r.db.Joins("JOIN modules_formation m ON m.id = notes.module_id").
+		Where("m.formation_id = ?", formationID).
+		Find(&notes).Error
+	return notes, err
+}
+
+// FindNotesByInscription returns the grades of one learner.
+func (r *ResultatRepository) FindNotesByInscription(inscriptionID uint) ([]model.Note, error) {
+	var notes []model.Note
+	err := r.db.Where("inscription_id = ?", inscriptionID).Find(&notes).Error
+	return notes, err
+}
+
+// SaveNotes records or corrects grades of a module.
+func (r *ResultatRepository) SaveNotes(notes []model.Note) error {
+	if len(notes) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "module_id"}, {Name: "inscription_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"valeur", "saisie_par", "updated_at"}),
+	}).Create(&notes).Error
+}
+
+// FindAttestation returns the certificate of an inscription.
+func (r *ResultatRepository) FindAttestation(inscriptionID uint) (*model.Attestation, error) {
+	var a model.Attestation
+	if err := r.db.Where("inscription_id = ?", inscriptionID).First(&a).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// FindAttestationByCode returns a certificate by its verification code.
+func (r *ResultatRepository) FindAttestationByCode(code string) (*model.Attestation, error) {
+	var a model.Attestation
+	if err := r.db.Where("code_verification = ?", code).First(&a).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Deliberer records the deliberation outcome of an enrolled learner in one
+// transaction: the state change with its decision and history entry and, for a
+// validated learner, the completion certificate a. It returns ErrEtatModifie if the
+// learner left INSCRIT meanwhile.
+func (r *ResultatRepository) Deliberer(ins *model.Inscription, decision *model.Decision, historique *model.InscriptionHistorique, a *model.Attestation, numero func(id uint) string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := changerEtat(tx, ins, map[string]interface{}{"etat": decision.Etat}, decision, historique); err != nil {
+			return err
+		}
+		if a == nil {
+			return nil
+		}
+		// The certificate number is derived from the ID, so it is set once the row exists
+		a.Numero = "EN_COURS-" + a.CodeVerification
+		if err := tx.Create(a).Error; err != nil {
+			return err
+		}
+		a.Numero = numero(a.ID)
+		return tx.Model(a).Update("numero", a.Numero).Error
+	})
+}
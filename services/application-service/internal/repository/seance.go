@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeanceRepository handles database operations for sessions, attendance and attendance alerts.
+type SeanceRepository struct {
+	db *gorm.DB
+}
+
+// NewSeanceRepository creates a new SeanceRepository.
+func NewSeanceRepository(db *gorm.DB) *SeanceRepository {
+	return &SeanceRepository{db: db}
+}
+
+// FindByFormationID returns the sessions of a formation in chronological order,
+// optionally restricted to one group (its own sessions plus formation-wide ones).
+func (r *SeanceRepository) FindByFormationID(formationID uint, groupeID uint) ([]model.Seance, error) {
+	var seances []model.Seance
+	q := r.db.Where("formation_id = ?", formationID).Order("debut, id")
+	if groupeID != 0 {
+		q = q.Where("groupe_id IS NULL OR groupe_id = ?", groupeID)
+	}
+	err := q.Find(&seances).Error
+	return seances, err
+}
+
+// FindByID returns a session by ID.
+func (r *SeanceRepository) FindByID(id uint) (*model.Seance, error) {
+	var s model.Seance
+	if err := r.db.First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Create inserts a new session.
+func (r *SeanceRepository) Create(s *model.Seance) error {
+	return r.db.Create(s).Error
+}
+
+// Delete removes a session together with its attendance records.
+func (r *SeanceRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("seance_id = ?", id).Delete(&model.Presence{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Seance{}, id).Error
+	})
+}
+
+// Concernes returns the enrolled learners a session is for, ordered by name.
+func (r *SeanceRepository) Concernes(s *model.Seance) ([]model.Inscription, error) {
+	var inscriptions []model.Inscription
+	q := r.db.Where("formation_id = ? AND etat = ?", s.FormationID, model.EtatInscrit)
+	if s.GroupeID != nil {
+		q = q.Where("id IN (SELECT inscription_id FROM membres_groupes WHERE groupe_id = ?)", *s.GroupeID)
+	}
+	err := q.Order("nom_complet, id").Find(&inscriptions).Error
+	return inscriptions, err
+}
+
+// FindPresences returns the attendance recorded for a session.
+func (r *SeanceRepository) FindPresences(seanceID uint) ([]model.Presence, error) {
+	var presences []model.Presence
+	err := r.db.Where("seance_id = ?", seanceID).Find(&presences).Error
+	return presences, err
+}
+
+// SavePresences records or corrects the attendance of several learners at a session.
+func (r *SeanceRepository) SavePresences(presences []model.Presence) error {
+	if len(presences) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "seance_id"}, {Name: "inscription_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"statut", "commentaire", "saisi_par", "updated_at"}),
+	}).Create(&presences).Error
+}
+
+// Taux computes the attendance of enrolled learners of a formation. When inscriptionIDs
+// is not empty only those learners are returned. Seuil and SousSeuil are left to the caller.
+func (r *SeanceRepository) Taux(formationID uint, inscriptionIDs []uint) ([]model.TauxPresence, error) {
+	var rows []model.TauxPresence
+	q := r.db.Table("inscriptions").
+		Select(`inscriptions.id AS inscription_id, inscriptions.nom_complet,
+			COUNT(p.id) FILTER (WHERE p.statut = ?) AS presents,
+			COUNT(p.id) FILTER (WHERE p.statut = ?) AS absents,
+			COUNT(p.id) FILTER (WHERE p.statut = ?) AS excuses`,
+			model.PresencePresent, model.PresenceAbsent, model.PresenceExcuse).
+		Joins("LEFT JOIN presences p ON p.inscription_id = inscriptions.id").
+		Where("inscriptions.formation_id = ? AND inscriptions.etat = ? AND inscriptions.deleted_at IS NULL",
+			formationID, model.EtatInscrit).
+		Group("inscriptions.id, inscriptions.nom_complet").
+		Order("inscriptions.nom_complet, inscriptions.id")
+	if len(inscriptionIDs) > 0 {
+		q = q.Where("inscriptions.id IN ?", inscriptionIDs)
+	}
+	if err := q.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if n := rows[i].Presents + rows[i].Absents; n > 0 {
+			taux := float64(rows[i].Presents) * 100 / float64(n)
+			rows[i].Taux = &taux
+		}
+	}
+	return rows, nil
+}
+
+// FindAlerteActive returns the open attendance alert of an inscription, if any.
+func (r *SeanceRepository) FindAlerteActive(inscriptionID uint) (*model.AlerteAssiduite, error) {
+	var a model.AlerteAssiduite
+	res := r.db.Where("inscription_id = ? AND active", inscriptionID).Limit(1).Find(&a)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return nil, res.Error
+	}
+	return &a, nil
+}
+
+// FindAlertes returns the attendance alerts of a formation, newest first.
+func (r *SeanceRepository) FindAlertes(formationID uint, activesOnly bool) ([]model.AlerteAssiduite, error) {
+	var alertes []model.AlerteAssiduite
+	q := r.db.Where("formation_id = ?", formationID).Order("created_at DESC")
+	if activesOnly {
+		q = q.Where("active")
+	}
+	err := q.Find(&alertes).Error
+	return alertes, err
+}
+
+// CreateAlerte opens an attendance alert. It reports false when another one was
+// opened for the inscription meanwhile.
+func (r *SeanceRepository) CreateAlerte(a *model.AlerteAssiduite) (bool, error) {
+	err := r.db.Create(a).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// LeverAlerte closes an attendance alert.
+func (r *SeanceRepository) LeverAlerte(a *model.AlerteAssiduite) error {
+	now := time.Now()
+	a.Active = false
+	a.LeveeLe = &now
+	return r.db.Save(a).Error
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DossierEnCours is an open dossier with the time it entered its current state.
+type DossierEnCours struct {
+	ID              uint
+	Reference       *string
+	FormationID     uint
+	EtablissementID string
+	Etat            model.EtatInscription
+	EntreeEtat      time.Time
+}
+
+// SlaFilter holds the optional criteria of the breach dashboard.
+// An empty EtablissementID means global access.
+type SlaFilter struct {
+	EtablissementID string
+	FormationID     uint
+	Etat            string
+	Niveau          int
+	// Resolus includes the breaches already resolved.
+	Resolus bool
+}
+
+// SlaRepository handles database operations for SLA breaches.
+type SlaRepository struct {
+	db *gorm.DB
+}
+
+// NewSlaRepository creates a new SlaRepository.
+func NewSlaRepository(db *gorm.DB) *SlaRepository {
+	return &SlaRepository{db: db}
+}
+
+// entreeEtat selects when an inscription entered its current state: the last history
+// entry that moved it there, or its creation. History events that kept the state
+// (transfers, merges) do not restart the clock.
+const entreeEtat = `coalesce((SELECT max(h.created_at) FROM inscription_historiques AS h
+	WHERE h.inscription_id = inscriptions.id
+		AND h.nouvel_etat = inscriptions.etat
+		AND h.ancien_etat <> h.nouvel_etat), inscriptions.date_creation) AS entree_etat`
+
+// FindEnCours returns the dossiers in one of the given states with the time they
+// entered it. formationIDs, when given, restricts them to those formations.
+func (r *SlaRepository) FindEnCours(etats []model.EtatInscription, formationIDs ...uint) ([]DossierEnCours, error) {
+	q := r.db.Model(&model.Inscription{}).
+		Select(`inscriptions.id, inscriptions.reference, inscriptions.formation_id,
+			inscriptions.etablissement_id, inscriptions.etat, `+entreeEtat).
+		Where("inscriptions.etat IN ?", etats)
+	if len(formationIDs) > 0 {
+		q = q.Where("inscriptions.formation_id IN ?", formationIDs)
+	}
+	var dossiers []DossierEnCours
+	err := q.Order("inscriptions.id").Scan(&dossiers).Error
+	return dossiers, err
+}
+
+// Enregistrer records a breach unless it is already known, and returns the stored
+// breach with its escalation progress.
+func (r *SlaRepository) Enregistrer(d *model.DepassementSla) (*model.DepassementSla, error) {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(d).Error
+	if err != nil {
+		return nil, err
+	}
+	var stocke model.DepassementSla
+	err = r.db.Where("inscription_id = ? AND etat = ? AND entree_etat = ?", d.InscriptionID, d.Etat, d.EntreeEtat).
+		First(&stocke).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stocke, nil
+}
+
+// ResoudreAutres resolves the open breaches not in enCours: their dossier left the
+// state, was deleted, or is no longer subject to an SLA. The breaches of the
+// inscriptions in exclues, which could not be evaluated, are left open.
+func (r *SlaRepository) ResoudreAutres(enCours, exclues []uint, le time.Time) (int64, error) {
+	q := r.db.Model(&model.DepassementSla{}).Where("resolu_le IS NULL")
+	if len(enCours) > 0 {
+		q = q.Where("id NOT IN ?", enCours)
+	}
+	if len(exclues) > 0 {
+		q = q.Where("inscription_id NOT IN ?", exclues)
+	}
+	res := q.Update("resolu_le", le)
+	return res.RowsAffected, res.Error
+}
+
+// Escalader records that a breach reached an escalation level.
+func (r *SlaRepository) Escalader(d *model.DepassementSla, niveau int, le time.Time) error {
+	champs := map[string]interface{}{"niveau": niveau}
+	switch niveau {
+	case model.NiveauSlaCoordinateur:
+		champs["escalade_coordinateur_le"] = le
+		d.EscaladeCoordinateurLe = &le
+	case model.NiveauSlaAdmin:
+		champs["escalade_admin_le"] = le
+		d.EscaladeAdminLe = &le
+	}
+	d.Niveau = niveau
+	return r.db.Model(&model.DepassementSla{}).Where("id = ?", d.ID).Updates(champs).Error
+}
+
+// FindAll returns the breaches matching the filter with their inscription, longest
+// overdue first.
+func (r *SlaRepository) FindAll(f SlaFilter) ([]model.DepassementSla, error) {
+	q := r.db.Preload("Inscription")
+	if f.EtablissementID != "" {
+		q = q.Where("etablissement_id = ?", f.EtablissementID)
+	}
+	if f.FormationID != 0 {
+		q = q.Where("formation_id = ?", f.FormationID)
+	}
+	if f.Etat != "" {
+		q = q.Where("etat = ?", f.Etat)
+	}
+	if f.Niveau != 0 {
+		q = q.Where("niveau = ?", f.Niveau)
+	}
+	if !f.Resolus {
+		q = q.Where("resolu_le IS NULL")
+	}
+	var depassements []model.DepassementSla
+	err := q.Order("echeance, id").Find(&depassements).Error
+	return depassements, err
+}
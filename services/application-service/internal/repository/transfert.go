@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aminemanssouri/FST-CFC/services/application-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// ErrCapaciteAtteinte is returned when the target formation of a transfer has no seat left.
+var ErrCapaciteAtteinte = errors.New("la formation cible a atteint sa capacité")
+
+// CountPlaces returns the number of seats already held in a formation.
+func (r *InscriptionRepository) CountPlaces(formationID uint) (int64, error) {
+	var n int64
+	err := r.db.Model(&model.Inscription{}).
+		Where("formation_id = ? AND etat IN ?", formationID, etatsPlace).
+		Count(&n).Error
+	return n, err
+}
+
+// ExistsInFormation reports whether a candidate already has an inscription in a formation.
+func (r *InscriptionRepository) ExistsInFormation(candidatID string, formationID uint) (bool, error) {
+	var n int64
+	err := r.db.Model(&model.Inscription{}).
+		Where("candidat_id = ? AND formation_id = ?", candidatID, formationID).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// Transferer moves an inscription to another formation, as set on ins, releases its
+// interview booking in the former one and records the transfer in its history. Only
+// the columns a transfer changes are written, and only while the inscription is still
+// in ancienEtat and in the source formation (ErrEtatModifie otherwise). When capacite
+// is set, the seats of the target formation are counted again within the transaction,
+// transfers into the same formation waiting for each other, and ErrCapaciteAtteinte is
+// returned if none is left.
+func (r *InscriptionRepository) Transferer(ins *model.Inscription, ancienEtat model.EtatInscription, source uint, capacite *int, historique *model.InscriptionHistorique) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if capacite != nil {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fmt.Sprintf("places/%d", ins.FormationID)).Error; err != nil {
+				return err
+			}
+			var prises int64
+			err := tx.Model(&model.Inscription{}).
+				Where("formation_id = ? AND etat IN ?", ins.FormationID, etatsPlace).
+				Count(&prises).Error
+			if err != nil {
+				return err
+			}
+			if prises >= int64(*capacite) {
+				return ErrCapaciteAtteinte
+			}
+		}
+
+		res := tx.Model(&model.Inscription{}).
+			Where("id = ? AND etat = ? AND formation_id = ?", ins.ID, ancienEtat, source).
+			Updates(map[string]interface{}{
+				"formation_id":       ins.FormationID,
+				"etablissement_id":   ins.EtablissementID,
+				"etat":               ins.Etat,
+				"score_jury":         ins.ScoreJury,
+				"eligibilite_statut": ins.EligibiliteStatut,
+				"eligibilite":        ins.Eligibilite,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrEtatModifie
+		}
+		err := tx.Where("inscription_id = ?", ins.ID).Delete(&model.ReservationEntretien{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(historique).Error
+	})
+}
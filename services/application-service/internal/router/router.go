@@ -6,27 +6,316 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Handlers groups the HTTP handlers of the application service.
+type Handlers struct {
+	Inscription         *handler.InscriptionHandler
+	Recours             *handler.RecoursHandler
+	MotifRefus          *handler.MotifRefusHandler
+	Formulaire          *handler.FormulaireHandler
+	ParametresFormation *handler.ParametresFormationHandler
+	Entretien           *handler.EntretienHandler
+	Idempotence         *handler.IdempotenceHandler
+	Groupe              *handler.GroupeHandler
+	Seance              *handler.SeanceHandler
+	Resultat            *handler.ResultatHandler
+	Affectation         *handler.AffectationHandler
+	Offre               *handler.OffreHandler
+	Doublon             *handler.DoublonHandler
+	Sla                 *handler.SlaHandler
+	File                *handler.FileHandler
+	Jury                *handler.JuryHandler
+	Publication         *handler.PublicationHandler
+	Message             *handler.MessageHandler
+	Corbeille           *handler.CorbeilleHandler
+	EspaceCandidat      *handler.EspaceCandidatHandler
+}
+
 // Setup configures all routes for the application service.
-func Setup(r *gin.Engine, ih *handler.InscriptionHandler, jwtSecret string) {
+// idempotency guards every mutating route of the authenticated groups.
+func Setup(r *gin.Engine, h Handlers, idempotency gin.HandlerFunc, jwtSecret string) {
+	ih := h.Inscription
+	rh := h.Recours
+	mh := h.MotifRefus
+	fh := h.Formulaire
+	ph := h.ParametresFormation
+	eh := h.Entretien
+	gh := h.Groupe
+	sh := h.Seance
+	rsh := h.Resultat
+	ah := h.Affectation
+	oh := h.Offre
+	dh := h.Doublon
+	slh := h.Sla
+	qh := h.File
+	jh := h.Jury
+	pbh := h.Publication
+	msh := h.Message
+	cbh := h.Corbeille
+	ech := h.EspaceCandidat
+
 	// Health check — public
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Certificate verification — public, by the code printed on the certificate
+	r.GET("/attestations/:code", rsh.Verifier)
+
+	// Published admission results — public, for the faculty website
+	r.GET("/resultats/:formation_id", pbh.Consulter)
+	r.GET("/resultats/:formation_id/pdf", pbh.PDF)
+
 	// Protected routes — require valid JWT
 	auth := r.Group("/inscriptions")
-	auth.Use(middleware.AuthMiddleware(jwtSecret))
+	auth.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
 	{
 		// List inscriptions (Admin sees all; candidat filters by ?candidat_id=)
 		auth.GET("", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR", "CANDIDAT"), ih.List)
 
+		// Eligibility check against the formation's prerequisites (owner or admin)
+		auth.GET("/:id/eligibilite", ih.Eligibilite)
+
+		// Trash of deleted inscriptions (Admin Établissement)
+		auth.GET("/corbeille", middleware.RequireRole("ADMIN_ETABLISSEMENT"), cbh.List)
+
+		// History consistency check and repair (Admin Établissement)
+		auth.GET("/coherence", middleware.RequireRole("ADMIN_ETABLISSEMENT"), ih.Coherence)
+		auth.POST("/coherence/reparation", middleware.RequireRole("ADMIN_ETABLISSEMENT"), ih.ReparerCoherence)
+
+		// Full-text candidate search with the same filters as the listing (Admin / Coordinateur)
+		auth.GET("/recherche", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), ih.Search)
+
+		// CSV export with the same filters as the listing (Admin / Coordinateur)
+		auth.GET("/export", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), ih.Export)
+
+		// Ranked choices across formations (Candidate)
+		auth.GET("/voeux", middleware.RequireRole("CANDIDAT"), ah.GetVoeux)
+		auth.PUT("/voeux", middleware.RequireRole("CANDIDAT"), ah.PutVoeux)
+
 		// Get single inscription (owner or admin)
 		auth.GET("/:id", ih.Get)
 
+		// State of the inscription at a past date, from its history (owner or admin)
+		auth.GET("/:id/etat", ih.EtatAu)
+
 		// Create inscription (Candidate — Sequence Diagram B)
 		auth.POST("", middleware.RequireRole("CANDIDAT"), ih.Create)
 
+		// Identity fields: CIN, CNE / Massar, phone, birth date, diploma (owner or staff)
+		auth.PUT("/:id/identite", middleware.RequireRole("CANDIDAT", "ADMIN_ETABLISSEMENT", "COORDINATEUR"), ih.UpdateIdentite)
+
+		// Erasure of the personal data of a closed dossier (Admin Établissement)
+		auth.POST("/:id/anonymisation", middleware.RequireRole("ADMIN_ETABLISSEMENT"), ih.Anonymiser)
+
+		// Deletion to the trash, with a reason, and restoration (Admin Établissement)
+		auth.DELETE("/:id", middleware.RequireRole("ADMIN_ETABLISSEMENT"), cbh.Supprimer)
+		auth.POST("/:id/restauration", middleware.RequireRole("ADMIN_ETABLISSEMENT"), cbh.Restaurer)
+
 		// State transition (Admin / Coordinateur — Sequence Diagram C)
 		auth.PATCH("/:id/transition", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), ih.Transition)
+
+		// Answer to an offer: confirm or decline (Candidate)
+		auth.POST("/:id/offre", middleware.RequireRole("CANDIDAT"), oh.Repondre)
+
+		// Transfer to another formation (Admin / Coordinateur)
+		auth.POST("/:id/transfert", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), ih.Transferer)
+
+		// Appeals on a refused inscription (owner or admin)
+		auth.GET("/:id/recours", rh.ListByInscription)
+		auth.POST("/:id/recours", middleware.RequireRole("CANDIDAT"), rh.Create)
+
+		// Interview booking (Candidate books or reschedules; owner or admin reads)
+		auth.GET("/:id/entretien", eh.GetReservation)
+		auth.PUT("/:id/entretien", middleware.RequireRole("CANDIDAT"), eh.Reserver)
+
+		// Group assignment of enrolled learners (Admin / Coordinateur)
+		auth.PUT("/:id/groupe", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), gh.Affecter)
+		auth.DELETE("/:id/groupe", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), gh.Retirer)
+
+		// Attendance of an enrolled learner (owner or staff)
+		auth.GET("/:id/assiduite", sh.AssiduiteInscription)
+
+		// Grades and completion certificate (owner or staff)
+		auth.GET("/:id/notes", rsh.NotesInscription)
+		auth.GET("/:id/attestation", rsh.Attestation)
+	}
+
+	// Appeal review — decided above the coordinator level
+	recours := r.Group("/recours")
+	recours.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	{
+		recours.GET("", middleware.RequireRole("ADMIN_ETABLISSEMENT"), rh.List)
+		recours.GET("/:id", rh.Get)
+		recours.PATCH("/:id/decision", middleware.RequireRole("ADMIN_ETABLISSEMENT"), rh.Decide)
+	}
+
+	// Refusal reason catalogue (Admin Établissement) and refusal breakdown
+	motifs := r.Group("/motifs-refus")
+	motifs.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	{
+		motifs.GET("", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), mh.List)
+		motifs.POST("", middleware.RequireRole("ADMIN_ETABLISSEMENT"), mh.Create)
+		motifs.PUT("/:id", middleware.RequireRole("ADMIN_ETABLISSEMENT"), mh.Update)
+		motifs.GET("/statistiques", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), mh.Stats)
+	}
+
+	// Per-formation configuration owned by this service
+	formations := r.Group("/formations")
+	formations.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	{
+		// Application form (JSON Schema) — read by candidates, defined by the coordinator
+		formations.GET("/:id/formulaire", fh.Get)
+		formations.PUT("/:id/formulaire", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), fh.Put)
+
+		// Settings (eligibility mode, ...) and prerequisite rules
+		formations.GET("/:id/parametres", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), ph.Get)
+		formations.PUT("/:id/parametres", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), ph.Update)
+		formations.GET("/:id/regles", ph.ListRegles)
+		formations.PUT("/:id/regles", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), ph.PutRegles)
+
+		// Interview slots — published by the coordinator, browsed by candidates
+		formations.GET("/:id/creneaux", eh.ListCreneaux)
+		formations.POST("/:id/creneaux", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), eh.CreateCreneau)
+
+		// Cohorts of enrolled learners
+		formations.GET("/:id/groupes", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), gh.List)
+		formations.POST("/:id/groupes", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), gh.Create)
+		formations.POST("/:id/groupes/repartition", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), gh.Repartir)
+
+		// Training sessions and attendance (trainers record, coordinators schedule)
+		formations.GET("/:id/seances", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR", "FORMATEUR"), sh.List)
+		formations.POST("/:id/seances", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), sh.Create)
+		formations.GET("/:id/assiduite", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR", "FORMATEUR"), sh.Assiduite)
+		formations.GET("/:id/alertes-assiduite", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), sh.Alertes)
+
+		// Jury ranking used by the allocation runs
+		formations.GET("/:id/classement", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), ah.GetClassement)
+		formations.PUT("/:id/classement", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), ah.PutClassement)
+
+		// Graded modules and deliberation of final results
+		formations.GET("/:id/modules", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR", "FORMATEUR"), rsh.ListModules)
+		formations.POST("/:id/modules", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), rsh.CreateModule)
+		formations.POST("/:id/deliberation", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), rsh.Deliberer)
+
+		// Admission jury sessions
+		formations.GET("/:id/sessions-jury", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), jh.List)
+		formations.POST("/:id/sessions-jury", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), jh.Create)
+
+		// Publication of the admission results lists
+		formations.GET("/:id/publications", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), pbh.List)
+		formations.POST("/:id/publications", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), pbh.Publier)
+	}
+
+	// Allocation of candidates over formations from their ranked choices (Admin / Coordinateur)
+	affectations := r.Group("/affectations")
+	affectations.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	affectations.Use(middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"))
+	{
+		affectations.POST("", ah.Lancer)
+		affectations.GET("", ah.List)
+		affectations.GET("/:id", ah.Get)
+	}
+
+	// Review queue of suspected duplicate candidates (Admin / Coordinateur)
+	doublons := r.Group("/doublons")
+	doublons.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	doublons.Use(middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"))
+	{
+		doublons.GET("", dh.List)
+		doublons.GET("/:id", dh.Get)
+		doublons.POST("/:id/fusion", dh.Fusionner)
+		doublons.POST("/:id/ecarter", dh.Ecarter)
+	}
+
+	// Decision SLA breaches dashboard (Admin / Coordinateur)
+	sla := r.Group("/sla")
+	sla.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	sla.Use(middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"))
+	{
+		sla.GET("/depassements", slh.Depassements)
+	}
+
+	// Bulk e-mails to the candidates selected by the listing filters (Admin / Coordinateur)
+	messages := r.Group("/messages")
+	messages.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	messages.Use(middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"))
+	{
+		messages.POST("", msh.Envoyer)
+		messages.GET("", msh.List)
+		messages.GET("/:id", msh.Get)
+	}
+
+	// Admission jury sessions and their minutes (Admin / Coordinateur)
+	sessionsJury := r.Group("/sessions-jury")
+	sessionsJury.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	sessionsJury.Use(middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"))
+	{
+		sessionsJury.GET("/:id", jh.Get)
+		sessionsJury.PUT("/:id", jh.Update)
+		sessionsJury.POST("/:id/cloture", jh.Cloturer)
+		sessionsJury.GET("/:id/pv", jh.Proces)
+	}
+
+	// The caller's own space
+	me := r.Group("/me")
+	me.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	{
+		// Home page of the candidate: inscriptions, documents, next steps (Candidate)
+		me.GET("/inscriptions", middleware.RequireRole("CANDIDAT"), ech.MesInscriptions)
+
+		// Work queue of the caller's formations and dossier claims (Admin / Coordinateur)
+		me.GET("/queue", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), qh.List)
+		me.POST("/queue/:id/claim", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), qh.Prendre)
+		me.DELETE("/queue/:id/claim", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), qh.Liberer)
+	}
+
+	creneaux := r.Group("/creneaux")
+	creneaux.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	{
+		creneaux.DELETE("/:id", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), eh.DeleteCreneau)
+	}
+
+	// Groups (Admin / Coordinateur) and their rosters, also read by trainers
+	groupes := r.Group("/groupes")
+	groupes.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	{
+		groupes.PUT("/:id", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), gh.Update)
+		groupes.DELETE("/:id", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), gh.Delete)
+		groupes.GET("/:id/membres", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR", "FORMATEUR"), gh.Membres)
+		groupes.GET("/:id/membres/export", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR", "FORMATEUR"), gh.ExportMembres)
+	}
+
+	seances := r.Group("/seances")
+	seances.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	{
+		seances.DELETE("/:id", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), sh.Delete)
+		seances.GET("/:id/presences", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR", "FORMATEUR"), sh.Presences)
+		seances.PUT("/:id/presences", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR", "FORMATEUR"), sh.SavePresences)
+	}
+
+	// Modules and grade entry (trainers grade, coordinators define modules)
+	modules := r.Group("/modules")
+	modules.Use(middleware.AuthMiddleware(jwtSecret), middleware.RequireInstitution(), idempotency)
+	{
+		modules.PUT("/:id", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), rsh.UpdateModule)
+		modules.DELETE("/:id", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR"), rsh.DeleteModule)
+		modules.GET("/:id/notes", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR", "FORMATEUR"), rsh.Notes)
+		modules.PUT("/:id/notes", middleware.RequireRole("ADMIN_ETABLISSEMENT", "COORDINATEUR", "FORMATEUR"), rsh.SaveNotes)
+	}
+
+	// Internal endpoints for the scheduler — SYSTEM role only
+	internal := r.Group("/internal")
+	internal.Use(middleware.AuthMiddleware(jwtSecret))
+	internal.Use(middleware.RequireRole("SYSTEM"))
+	{
+		internal.POST("/jobs/resolve-etablissements", ih.ResolveEtablissements)
+		internal.POST("/jobs/attribuer-references", ih.AttribuerReferences)
+		internal.POST("/jobs/purge-idempotency-keys", h.Idempotence.PurgeExpired)
+		internal.POST("/jobs/rappels-offres", oh.RappelerOffres)
+		internal.POST("/jobs/expirer-offres", oh.ExpirerOffres)
+		internal.POST("/jobs/detecter-doublons", dh.Detecter)
+		internal.POST("/jobs/evaluer-sla", slh.Evaluer)
+		internal.POST("/jobs/suivre-messages", msh.Suivre)
+		internal.POST("/jobs/purger-corbeille", cbh.Purger)
 	}
 }
@@ -0,0 +1,27 @@
+-- recours table (appeals filed by refused candidates)
+CREATE TABLE IF NOT EXISTS recours (
+    id              SERIAL PRIMARY KEY,
+    inscription_id  INTEGER NOT NULL REFERENCES inscriptions(id) ON DELETE CASCADE,
+    candidat_id     VARCHAR(100) NOT NULL,
+    justification   TEXT NOT NULL,
+    statut          VARCHAR(20) NOT NULL DEFAULT 'EN_ATTENTE',
+    examine_par     VARCHAR(100),
+    reponse         TEXT,
+    date_decision   TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recours_inscription_id ON recours(inscription_id);
+CREATE INDEX IF NOT EXISTS idx_recours_candidat_id ON recours(candidat_id);
+CREATE INDEX IF NOT EXISTS idx_recours_statut ON recours(statut);
+
+-- recours_documents table (supporting documents stored in document-service)
+CREATE TABLE IF NOT EXISTS recours_documents (
+    id              SERIAL PRIMARY KEY,
+    recours_id      INTEGER NOT NULL REFERENCES recours(id) ON DELETE CASCADE,
+    document_id     INTEGER NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recours_documents_recours_id ON recours_documents(recours_id);
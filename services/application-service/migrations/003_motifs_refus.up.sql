@@ -0,0 +1,30 @@
+-- motifs_refus table (refusal reason catalogue, global when etablissement_id = '')
+CREATE TABLE IF NOT EXISTS motifs_refus (
+    id                SERIAL PRIMARY KEY,
+    etablissement_id  VARCHAR(100) NOT NULL DEFAULT '',
+    code              VARCHAR(50) NOT NULL,
+    libelle           VARCHAR(255) NOT NULL,
+    message_candidat  TEXT,
+    actif             BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_motifs_refus_etablissement_code ON motifs_refus(etablissement_id, code);
+
+-- decision_motifs join table (reasons attached to a REFUSE decision)
+CREATE TABLE IF NOT EXISTS decision_motifs (
+    decision_id     INTEGER NOT NULL REFERENCES decisions(id) ON DELETE CASCADE,
+    motif_refus_id  INTEGER NOT NULL REFERENCES motifs_refus(id),
+    PRIMARY KEY (decision_id, motif_refus_id)
+);
+
+-- Global catalogue
+INSERT INTO motifs_refus (etablissement_id, code, libelle, message_candidat) VALUES
+    ('', 'PREREQUIS_NON_SATISFAITS', 'Prérequis non satisfaits', 'Votre profil ne satisfait pas les prérequis d''accès à cette formation.'),
+    ('', 'DOSSIER_INCOMPLET', 'Dossier incomplet', 'Votre dossier est incomplet : certaines pièces obligatoires sont manquantes.'),
+    ('', 'DOCUMENTS_NON_CONFORMES', 'Documents non conformes', 'Certaines pièces fournies ne sont pas conformes ou sont illisibles.'),
+    ('', 'CAPACITE_ATTEINTE', 'Capacité d''accueil atteinte', 'La capacité d''accueil de la formation est atteinte.'),
+    ('', 'HORS_DELAI', 'Candidature hors délai', 'Votre dossier a été déposé en dehors de la période d''inscription.'),
+    ('', 'AUTRE', 'Autre motif', 'Votre candidature n''a pas été retenue. Consultez le commentaire du jury.')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+-- Institution of the formation, used to scope staff access to inscriptions.
+-- Existing rows are back-filled by POST /internal/jobs/resolve-etablissements.
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS etablissement_id VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_inscriptions_etablissement_id ON inscriptions(etablissement_id);
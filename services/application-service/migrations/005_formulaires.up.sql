@@ -0,0 +1,18 @@
+-- formulaires table (per-formation application form, JSON Schema)
+CREATE TABLE IF NOT EXISTS formulaires (
+    id              SERIAL PRIMARY KEY,
+    formation_id    INTEGER NOT NULL,
+    schema          JSONB NOT NULL,
+    version         INTEGER NOT NULL DEFAULT 1,
+    modifie_par     VARCHAR(100) NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_formulaires_formation_id ON formulaires(formation_id);
+
+-- Candidate answers, validated against the formation's form
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS reponses JSONB;
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS formulaire_version INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_inscriptions_reponses ON inscriptions USING GIN (reponses jsonb_path_ops);
@@ -0,0 +1,30 @@
+-- regles_eligibilite table (entry requirements evaluated over the form answers)
+CREATE TABLE IF NOT EXISTS regles_eligibilite (
+    id              SERIAL PRIMARY KEY,
+    formation_id    INTEGER NOT NULL,
+    code            VARCHAR(50) NOT NULL,
+    libelle         VARCHAR(255) NOT NULL,
+    champ           VARCHAR(100) NOT NULL,
+    operateur       VARCHAR(20) NOT NULL,
+    valeur          JSONB,
+    ordre           INTEGER NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_regles_eligibilite_formation_id ON regles_eligibilite(formation_id);
+
+-- parametres_formations table (per-formation settings)
+CREATE TABLE IF NOT EXISTS parametres_formations (
+    formation_id        INTEGER PRIMARY KEY,
+    mode_eligibilite    VARCHAR(20) NOT NULL DEFAULT 'SIGNALER',
+    modifie_par         VARCHAR(100),
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Eligibility result computed at submission
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS eligibilite_statut VARCHAR(20);
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS eligibilite JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_inscriptions_eligibilite_statut ON inscriptions(eligibilite_statut);
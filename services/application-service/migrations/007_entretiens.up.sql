@@ -0,0 +1,36 @@
+-- creneaux_entretien table (interview slots published per formation)
+CREATE TABLE IF NOT EXISTS creneaux_entretien (
+    id                  SERIAL PRIMARY KEY,
+    formation_id        INTEGER NOT NULL,
+    etablissement_id    VARCHAR(100) NOT NULL DEFAULT '',
+    debut               TIMESTAMPTZ NOT NULL,
+    fin                 TIMESTAMPTZ NOT NULL,
+    capacite            INTEGER NOT NULL DEFAULT 1,
+    lieu                VARCHAR(255),
+    lien_visio          VARCHAR(500),
+    jury                VARCHAR(100) NOT NULL,
+    cree_par            VARCHAR(100) NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (fin > debut),
+    CHECK (capacite > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_creneaux_entretien_formation_id ON creneaux_entretien(formation_id);
+CREATE INDEX IF NOT EXISTS idx_creneaux_entretien_etablissement_id ON creneaux_entretien(etablissement_id);
+CREATE INDEX IF NOT EXISTS idx_creneaux_entretien_jury ON creneaux_entretien(jury);
+
+-- reservations_entretien table (one booking per inscription)
+CREATE TABLE IF NOT EXISTS reservations_entretien (
+    id              SERIAL PRIMARY KEY,
+    creneau_id      INTEGER NOT NULL REFERENCES creneaux_entretien(id),
+    inscription_id  INTEGER NOT NULL REFERENCES inscriptions(id) ON DELETE CASCADE,
+    candidat_id     VARCHAR(100) NOT NULL,
+    sequence        INTEGER NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservations_entretien_creneau_id ON reservations_entretien(creneau_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reservations_entretien_inscription_id ON reservations_entretien(inscription_id);
+CREATE INDEX IF NOT EXISTS idx_reservations_entretien_candidat_id ON reservations_entretien(candidat_id);
@@ -0,0 +1,18 @@
+-- cles_idempotence table (stored responses of requests sent with an Idempotency-Key)
+CREATE TABLE IF NOT EXISTS cles_idempotence (
+    id              SERIAL PRIMARY KEY,
+    cle             VARCHAR(255) NOT NULL,
+    utilisateur_id  VARCHAR(100) NOT NULL,
+    methode         VARCHAR(10) NOT NULL,
+    chemin          VARCHAR(255) NOT NULL,
+    empreinte       CHAR(64) NOT NULL,
+    statut          INTEGER NOT NULL DEFAULT 0,
+    content_type    VARCHAR(100),
+    reponse         BYTEA,
+    expire_le       TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cles_idempotence_utilisateur_cle ON cles_idempotence(utilisateur_id, cle);
+CREATE INDEX IF NOT EXISTS idx_cles_idempotence_expire_le ON cles_idempotence(expire_le);
@@ -0,0 +1,37 @@
+-- Full-text candidate search: accent-insensitive French and simple configurations,
+-- a generated tsvector over the candidate's details and trigram indexes for typos.
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'fr_unaccent') THEN
+        CREATE TEXT SEARCH CONFIGURATION fr_unaccent (COPY = french);
+        ALTER TEXT SEARCH CONFIGURATION fr_unaccent
+            ALTER MAPPING FOR hword, hword_part, word WITH unaccent, french_stem;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'simple_unaccent') THEN
+        CREATE TEXT SEARCH CONFIGURATION simple_unaccent (COPY = simple);
+        ALTER TEXT SEARCH CONFIGURATION simple_unaccent
+            ALTER MAPPING FOR hword, hword_part, word WITH unaccent, simple;
+    END IF;
+END
+$$;
+
+-- unaccent() is only STABLE; indexes need an IMMUTABLE wrapper
+CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+    AS $$ SELECT public.unaccent('public.unaccent'::regdictionary, $1) $$;
+
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS recherche tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple_unaccent', coalesce(nom_complet, '')), 'A') ||
+    setweight(to_tsvector('fr_unaccent', coalesce(nom_complet, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(email, '')), 'A') ||
+    setweight(to_tsvector('simple_unaccent', regexp_replace(coalesce(email, ''), '[@._+-]', ' ', 'g')), 'B') ||
+    setweight(to_tsvector('simple', regexp_replace(coalesce(telephone, ''), '[^0-9+]', '', 'g')), 'B') ||
+    setweight(to_tsvector('fr_unaccent', coalesce(notes, '')), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_inscriptions_recherche ON inscriptions USING GIN (recherche);
+CREATE INDEX IF NOT EXISTS idx_inscriptions_nom_trgm ON inscriptions USING GIN (immutable_unaccent(lower(nom_complet)) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_inscriptions_email_trgm ON inscriptions USING GIN (lower(email) gin_trgm_ops);
@@ -0,0 +1,28 @@
+-- groupes table (cohorts of a formation's enrolled learners)
+CREATE TABLE IF NOT EXISTS groupes (
+    id                  SERIAL PRIMARY KEY,
+    formation_id        INTEGER NOT NULL,
+    etablissement_id    VARCHAR(100) NOT NULL DEFAULT '',
+    nom                 VARCHAR(100) NOT NULL,
+    description         TEXT,
+    capacite            INTEGER NOT NULL CHECK (capacite > 0),
+    cree_par            VARCHAR(100) NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_groupes_formation_nom ON groupes(formation_id, nom);
+CREATE INDEX IF NOT EXISTS idx_groupes_etablissement_id ON groupes(etablissement_id);
+
+-- membres_groupes table (one group per inscription)
+CREATE TABLE IF NOT EXISTS membres_groupes (
+    id              SERIAL PRIMARY KEY,
+    groupe_id       INTEGER NOT NULL REFERENCES groupes(id),
+    inscription_id  INTEGER NOT NULL REFERENCES inscriptions(id) ON DELETE CASCADE,
+    affecte_par     VARCHAR(100) NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_membres_groupes_groupe_id ON membres_groupes(groupe_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_membres_groupes_inscription_id ON membres_groupes(inscription_id);
@@ -0,0 +1,53 @@
+-- seances table (training sessions of a formation or of one of its groups)
+CREATE TABLE IF NOT EXISTS seances (
+    id                  SERIAL PRIMARY KEY,
+    formation_id        INTEGER NOT NULL,
+    groupe_id           INTEGER REFERENCES groupes(id),
+    etablissement_id    VARCHAR(100) NOT NULL DEFAULT '',
+    titre               VARCHAR(255) NOT NULL,
+    debut               TIMESTAMPTZ NOT NULL,
+    fin                 TIMESTAMPTZ NOT NULL,
+    cree_par            VARCHAR(100) NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (fin > debut)
+);
+
+CREATE INDEX IF NOT EXISTS idx_seances_formation_id ON seances(formation_id);
+CREATE INDEX IF NOT EXISTS idx_seances_groupe_id ON seances(groupe_id);
+CREATE INDEX IF NOT EXISTS idx_seances_etablissement_id ON seances(etablissement_id);
+
+-- presences table (attendance of one learner at one session)
+CREATE TABLE IF NOT EXISTS presences (
+    id              SERIAL PRIMARY KEY,
+    seance_id       INTEGER NOT NULL REFERENCES seances(id) ON DELETE CASCADE,
+    inscription_id  INTEGER NOT NULL REFERENCES inscriptions(id) ON DELETE CASCADE,
+    statut          VARCHAR(20) NOT NULL CHECK (statut IN ('PRESENT', 'ABSENT', 'EXCUSE')),
+    commentaire     TEXT,
+    saisi_par       VARCHAR(100) NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_presences_seance_inscription ON presences(seance_id, inscription_id);
+CREATE INDEX IF NOT EXISTS idx_presences_inscription_id ON presences(inscription_id);
+
+-- alertes_assiduite table (learners who fell below the attendance threshold)
+CREATE TABLE IF NOT EXISTS alertes_assiduite (
+    id              SERIAL PRIMARY KEY,
+    inscription_id  INTEGER NOT NULL REFERENCES inscriptions(id) ON DELETE CASCADE,
+    formation_id    INTEGER NOT NULL,
+    taux            DOUBLE PRECISION NOT NULL,
+    seuil           INTEGER NOT NULL,
+    active          BOOLEAN NOT NULL DEFAULT TRUE,
+    levee_le        TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alertes_assiduite_inscription_id ON alertes_assiduite(inscription_id);
+CREATE INDEX IF NOT EXISTS idx_alertes_assiduite_formation_id ON alertes_assiduite(formation_id);
+CREATE INDEX IF NOT EXISTS idx_alertes_assiduite_active ON alertes_assiduite(active);
+
+-- Per-formation attendance threshold (percent); NULL uses ATTENDANCE_THRESHOLD_PERCENT
+ALTER TABLE parametres_formations ADD COLUMN IF NOT EXISTS seuil_presence INTEGER;
@@ -0,0 +1,44 @@
+-- modules_formation table (graded units of a formation)
+CREATE TABLE IF NOT EXISTS modules_formation (
+    id                  SERIAL PRIMARY KEY,
+    formation_id        INTEGER NOT NULL,
+    etablissement_id    VARCHAR(100) NOT NULL DEFAULT '',
+    code                VARCHAR(50) NOT NULL,
+    intitule            VARCHAR(255) NOT NULL,
+    coefficient         DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (coefficient > 0),
+    note_eliminatoire   DOUBLE PRECISION CHECK (note_eliminatoire BETWEEN 0 AND 20),
+    ordre               INTEGER NOT NULL DEFAULT 0,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_modules_formation_code ON modules_formation(formation_id, code);
+CREATE INDEX IF NOT EXISTS idx_modules_formation_etablissement_id ON modules_formation(etablissement_id);
+
+-- notes table (grade of one learner in one module, out of 20)
+CREATE TABLE IF NOT EXISTS notes (
+    id              SERIAL PRIMARY KEY,
+    module_id       INTEGER NOT NULL REFERENCES modules_formation(id),
+    inscription_id  INTEGER NOT NULL REFERENCES inscriptions(id) ON DELETE CASCADE,
+    valeur          DOUBLE PRECISION NOT NULL CHECK (valeur BETWEEN 0 AND 20),
+    saisie_par      VARCHAR(100) NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notes_module_inscription ON notes(module_id, inscription_id);
+CREATE INDEX IF NOT EXISTS idx_notes_inscription_id ON notes(inscription_id);
+
+-- attestations table (completion certificates of validated learners)
+CREATE TABLE IF NOT EXISTS attestations (
+    id                  SERIAL PRIMARY KEY,
+    inscription_id      INTEGER NOT NULL UNIQUE REFERENCES inscriptions(id) ON DELETE CASCADE,
+    numero              VARCHAR(50) NOT NULL UNIQUE,
+    code_verification   VARCHAR(32) NOT NULL UNIQUE,
+    moyenne             DOUBLE PRECISION,
+    delivree_par        VARCHAR(100) NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Average (out of 20) required to validate a formation
+ALTER TABLE parametres_formations ADD COLUMN IF NOT EXISTS moyenne_validation DOUBLE PRECISION NOT NULL DEFAULT 10;
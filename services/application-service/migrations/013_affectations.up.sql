@@ -0,0 +1,25 @@
+-- Ranked choices (voeux) and jury ranking of inscriptions
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS rang_voeu INTEGER CHECK (rang_voeu > 0);
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS score_jury DOUBLE PRECISION;
+
+CREATE INDEX IF NOT EXISTS idx_inscriptions_candidat_rang_voeu ON inscriptions(candidat_id, rang_voeu);
+
+-- Seats offered by a formation in allocation runs; NULL means unlimited
+ALTER TABLE parametres_formations ADD COLUMN IF NOT EXISTS capacite INTEGER CHECK (capacite >= 0);
+
+-- affectations table (applied allocation runs with their explanatory report)
+CREATE TABLE IF NOT EXISTS affectations (
+    id                  SERIAL PRIMARY KEY,
+    etablissement_id    VARCHAR(100) NOT NULL DEFAULT '',
+    formation_ids       JSONB NOT NULL,
+    rapport             JSONB NOT NULL,
+    lance_par           VARCHAR(100) NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_affectations_etablissement_id ON affectations(etablissement_id);
+
+-- Reason code for choices refused because a better-ranked one was obtained
+INSERT INTO motifs_refus (etablissement_id, code, libelle, message_candidat) VALUES
+    ('', 'VOEU_PRIORITAIRE_OBTENU', 'Voeu mieux classé obtenu', 'Vous avez été admis dans une formation que vous avez classée avant celle-ci.')
+ON CONFLICT DO NOTHING;
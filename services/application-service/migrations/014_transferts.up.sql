@@ -0,0 +1,6 @@
+-- History events other than plain state changes (e.g. TRANSFERT between formations)
+ALTER TABLE inscription_historiques ADD COLUMN IF NOT EXISTS evenement VARCHAR(30);
+ALTER TABLE inscription_historiques ADD COLUMN IF NOT EXISTS commentaire TEXT;
+ALTER TABLE inscription_historiques ADD COLUMN IF NOT EXISTS details JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_inscription_historiques_evenement ON inscription_historiques(evenement);
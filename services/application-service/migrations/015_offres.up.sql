@@ -0,0 +1,9 @@
+-- Confirmation of offers made to accepted candidates
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS offre_expire_le TIMESTAMPTZ;
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS offre_confirmee_le TIMESTAMPTZ;
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS offre_rappel_le TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_inscriptions_offre_expire_le ON inscriptions(offre_expire_le);
+
+-- Days an accepted candidate has to confirm; NULL uses OFFER_CONFIRMATION_DAYS
+ALTER TABLE parametres_formations ADD COLUMN IF NOT EXISTS delai_confirmation INTEGER CHECK (delai_confirmation > 0);
@@ -0,0 +1,13 @@
+-- Human-readable dossier numbers: CFC-<academic year>-<institution code>-<counter>
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS reference VARCHAR(40);
+
+-- NULL until older dossiers are numbered by /internal/jobs/attribuer-references
+CREATE UNIQUE INDEX IF NOT EXISTS idx_inscriptions_reference ON inscriptions(reference);
+
+-- Last number handed out per institution code and academic year
+CREATE TABLE IF NOT EXISTS sequences_dossier (
+    code   VARCHAR(20) NOT NULL,
+    annee  INTEGER     NOT NULL,
+    valeur BIGINT      NOT NULL,
+    PRIMARY KEY (code, annee)
+);
@@ -0,0 +1,51 @@
+-- Duplicate and fraud detection across candidate accounts.
+-- Relies on immutable_unaccent() and pg_trgm from migration 009.
+
+-- Lower-case, drop the +tag, and the dots of Gmail addresses, which Gmail ignores
+CREATE OR REPLACE FUNCTION normaliser_email(text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+    AS $$
+    SELECT CASE
+        WHEN domaine IN ('gmail.com', 'googlemail.com') THEN replace(locale, '.', '') || '@gmail.com'
+        ELSE locale || '@' || domaine
+    END
+    FROM (SELECT regexp_replace(split_part(e, '@', 1), '\+.*$', '') AS locale,
+                 split_part(e, '@', 2) AS domaine
+          FROM (SELECT lower(trim($1)) AS e) AS m) AS p
+    $$;
+
+-- Last nine digits, so that 0612345678 and +212 6 12 34 56 78 match; NULL if too short
+CREATE OR REPLACE FUNCTION normaliser_telephone(text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+    AS $$
+    SELECT CASE WHEN length(d) >= 8 THEN right(d, 9) END
+    FROM (SELECT regexp_replace($1, '[^0-9]', '', 'g') AS d) AS n
+    $$;
+
+CREATE INDEX IF NOT EXISTS idx_inscriptions_email_normalise ON inscriptions (normaliser_email(email));
+CREATE INDEX IF NOT EXISTS idx_inscriptions_telephone_normalise ON inscriptions (normaliser_telephone(telephone));
+
+-- Review queue: one row per pair of inscriptions, the older one first
+CREATE TABLE IF NOT EXISTS doublons_suspects (
+    id                   SERIAL PRIMARY KEY,
+    inscription_id       INTEGER      NOT NULL REFERENCES inscriptions(id),
+    autre_inscription_id INTEGER      NOT NULL REFERENCES inscriptions(id),
+    etablissement_id     VARCHAR(100) NOT NULL DEFAULT '',
+    score                DOUBLE PRECISION NOT NULL,
+    criteres             JSONB        NOT NULL,
+    similarite_nom       DOUBLE PRECISION,
+    statut               VARCHAR(20)  NOT NULL DEFAULT 'EN_ATTENTE'
+                         CHECK (statut IN ('EN_ATTENTE', 'FUSIONNE', 'ECARTE')),
+    conservee_id         INTEGER,
+    resolu_par           VARCHAR(100),
+    resolu_le            TIMESTAMPTZ,
+    commentaire          TEXT,
+    created_at           TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CHECK (inscription_id < autre_inscription_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_doublons_paire ON doublons_suspects(inscription_id, autre_inscription_id);
+CREATE INDEX IF NOT EXISTS idx_doublons_suspects_autre_inscription_id ON doublons_suspects(autre_inscription_id);
+CREATE INDEX IF NOT EXISTS idx_doublons_suspects_etablissement_id ON doublons_suspects(etablissement_id);
+CREATE INDEX IF NOT EXISTS idx_doublons_suspects_statut ON doublons_suspects(statut);
@@ -0,0 +1,12 @@
+-- Structured identity fields of candidates, normalised by the service
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS cin VARCHAR(10);
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS cne VARCHAR(10);
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS date_naissance DATE;
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS dernier_diplome VARCHAR(255);
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS annee_diplome INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_inscriptions_cin ON inscriptions(cin);
+CREATE INDEX IF NOT EXISTS idx_inscriptions_cne ON inscriptions(cne);
+
+-- Set once the personal data of a closed dossier has been erased
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS anonymise_le TIMESTAMPTZ;
@@ -0,0 +1,27 @@
+-- Per-formation decision SLA and escalation contacts
+ALTER TABLE parametres_formations ADD COLUMN IF NOT EXISTS delais_sla JSONB;
+ALTER TABLE parametres_formations ADD COLUMN IF NOT EXISTS email_coordinateur VARCHAR(255);
+ALTER TABLE parametres_formations ADD COLUMN IF NOT EXISTS email_escalade VARCHAR(255);
+
+-- SLA breaches: one per stay of a dossier in a state
+CREATE TABLE IF NOT EXISTS depassements_sla (
+    id                       SERIAL PRIMARY KEY,
+    inscription_id           INTEGER      NOT NULL REFERENCES inscriptions(id),
+    etat                     VARCHAR(20)  NOT NULL,
+    entree_etat              TIMESTAMPTZ  NOT NULL,
+    formation_id             INTEGER      NOT NULL,
+    etablissement_id         VARCHAR(100) NOT NULL DEFAULT '',
+    delai_heures             INTEGER      NOT NULL,
+    echeance                 TIMESTAMPTZ  NOT NULL,
+    niveau                   INTEGER      NOT NULL DEFAULT 0,
+    escalade_coordinateur_le TIMESTAMPTZ,
+    escalade_admin_le        TIMESTAMPTZ,
+    resolu_le                TIMESTAMPTZ,
+    created_at               TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at               TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_depassements_sla_entree ON depassements_sla(inscription_id, etat, entree_etat);
+CREATE INDEX IF NOT EXISTS idx_depassements_sla_formation_id ON depassements_sla(formation_id);
+CREATE INDEX IF NOT EXISTS idx_depassements_sla_etablissement_id ON depassements_sla(etablissement_id);
+CREATE INDEX IF NOT EXISTS idx_depassements_sla_resolu_le ON depassements_sla(resolu_le);
@@ -0,0 +1,11 @@
+-- Claims on dossiers of the coordinators' work queue: one per dossier, lapsing
+-- when it expires or the dossier leaves the state it was claimed in
+CREATE TABLE IF NOT EXISTS prises_en_charge (
+    inscription_id INTEGER      PRIMARY KEY REFERENCES inscriptions(id),
+    etat           VARCHAR(20)  NOT NULL,
+    par            VARCHAR(100) NOT NULL,
+    le             TIMESTAMPTZ  NOT NULL,
+    expire_le      TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_prises_en_charge_par ON prises_en_charge(par);
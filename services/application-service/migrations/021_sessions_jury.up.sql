@@ -0,0 +1,27 @@
+-- Admission jury sessions: decisions taken while one is open are attached to it,
+-- and closing it freezes the minutes (procès-verbal) in proces
+CREATE TABLE IF NOT EXISTS sessions_jury (
+    id               SERIAL PRIMARY KEY,
+    formation_id     INTEGER      NOT NULL,
+    etablissement_id VARCHAR(100) NOT NULL DEFAULT '',
+    intitule         VARCHAR(200) NOT NULL,
+    tenue_le         TIMESTAMPTZ  NOT NULL,
+    lieu             VARCHAR(200),
+    president        VARCHAR(200) NOT NULL,
+    membres          JSONB        NOT NULL,
+    statut           VARCHAR(10)  NOT NULL DEFAULT 'OUVERTE',
+    ouverte_par      VARCHAR(100) NOT NULL,
+    cloture_par      VARCHAR(100),
+    cloture_le       TIMESTAMPTZ,
+    proces           JSONB,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_jury_formation_id ON sessions_jury(formation_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_jury_etablissement_id ON sessions_jury(etablissement_id);
+-- At most one open session per formation
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_jury_ouverte ON sessions_jury(formation_id) WHERE statut = 'OUVERTE';
+
+ALTER TABLE decisions ADD COLUMN IF NOT EXISTS session_jury_id INTEGER REFERENCES sessions_jury(id);
+CREATE INDEX IF NOT EXISTS idx_decisions_session_jury_id ON decisions(session_jury_id);
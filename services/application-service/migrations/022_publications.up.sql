@@ -0,0 +1,18 @@
+-- Published admission results: frozen, versioned lists of admitted and waitlisted
+-- candidates, identified by dossier number and initials only
+CREATE TABLE IF NOT EXISTS publications_resultats (
+    id               SERIAL PRIMARY KEY,
+    formation_id     INTEGER      NOT NULL,
+    version          INTEGER      NOT NULL,
+    etablissement_id VARCHAR(100) NOT NULL DEFAULT '',
+    formation        VARCHAR(255) NOT NULL,
+    rectificatif     TEXT,
+    admis            INTEGER      NOT NULL,
+    liste_attente    INTEGER      NOT NULL,
+    lignes           JSONB        NOT NULL,
+    publiee_par      VARCHAR(100) NOT NULL,
+    publiee_le       TIMESTAMPTZ  NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_publications_formation_version ON publications_resultats(formation_id, version);
+CREATE INDEX IF NOT EXISTS idx_publications_resultats_etablissement_id ON publications_resultats(etablissement_id);
@@ -0,0 +1,30 @@
+-- Bulk e-mails to segments of candidates, with one tracked delivery per recipient
+CREATE TABLE IF NOT EXISTS messages_groupes (
+    id               SERIAL PRIMARY KEY,
+    etablissement_id VARCHAR(100) NOT NULL DEFAULT '',
+    filtre           JSONB        NOT NULL,
+    sujet            VARCHAR(255) NOT NULL,
+    corps            TEXT         NOT NULL,
+    destinataires    INTEGER      NOT NULL,
+    envoye_par       VARCHAR(100) NOT NULL,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_groupes_etablissement_id ON messages_groupes(etablissement_id);
+
+CREATE TABLE IF NOT EXISTS envois_messages (
+    id              SERIAL PRIMARY KEY,
+    message_id      INTEGER      NOT NULL REFERENCES messages_groupes(id),
+    inscription_id  INTEGER      NOT NULL REFERENCES inscriptions(id),
+    destinataire    VARCHAR(255) NOT NULL,
+    sujet           TEXT         NOT NULL,
+    corps           TEXT         NOT NULL,
+    notification_id VARCHAR(64),
+    statut          VARCHAR(10)  NOT NULL,
+    erreur          TEXT,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_envois_messages_message_inscription ON envois_messages(message_id, inscription_id);
+CREATE INDEX IF NOT EXISTS idx_envois_messages_statut ON envois_messages(statut);
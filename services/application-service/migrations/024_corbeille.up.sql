@@ -0,0 +1,3 @@
+-- Who deleted an inscription sent to the trash, and why (deleted_at already exists)
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS supprimee_par VARCHAR(100);
+ALTER TABLE inscriptions ADD COLUMN IF NOT EXISTS motif_suppression TEXT;
@@ -0,0 +1,2 @@
+-- The stored path of an idempotency key now includes the query string
+ALTER TABLE cles_idempotence ALTER COLUMN chemin TYPE TEXT;
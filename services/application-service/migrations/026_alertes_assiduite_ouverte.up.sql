@@ -0,0 +1,9 @@
+-- At most one open attendance alert per inscription: close the duplicates opened
+-- concurrently, keeping the oldest, before enforcing it
+UPDATE alertes_assiduite a SET active = FALSE, levee_le = NOW()
+WHERE a.active AND EXISTS (
+    SELECT 1 FROM alertes_assiduite b
+    WHERE b.inscription_id = a.inscription_id AND b.active AND b.id < a.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alertes_assiduite_ouverte ON alertes_assiduite(inscription_id) WHERE active;
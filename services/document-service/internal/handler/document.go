@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/aminemanssouri/FST-CFC/services/document-service/internal/model"
 	"github.com/aminemanssouri/FST-CFC/services/document-service/internal/repository"
@@ -102,6 +103,7 @@ func (h *DocumentHandler) Upload(c *gin.Context) {
 	// Save metadata to DB
 	doc := model.Document{
 		InscriptionID: uint(inscriptionID),
+		TypeDocument:  strings.ToLower(strings.TrimSpace(c.PostForm("type_document"))),
 		NomFichier:    header.Filename,
 		ContentType:   contentType,
 		Size:          header.Size,
@@ -179,3 +181,52 @@ func (h *DocumentHandler) Delete(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "document deleted"})
 }
+
+// ListInscription returns the documents of an inscription, for other services.
+func (h *DocumentHandler) ListInscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	docs, err := h.repo.FindByInscriptionID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": docs})
+}
+
+// PurgeInscription permanently deletes every document of an inscription, from S3 and
+// the database. application-service calls it before purging a deleted inscription;
+// calling it again once done is harmless.
+func (h *DocumentHandler) PurgeInscription(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	docs, err := h.repo.FindByInscriptionID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Delete from S3 first, so that a failure leaves the records to retry with
+	for _, doc := range docs {
+		if err := h.s3.Delete(c.Request.Context(), doc.URLStockage); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	n, err := h.repo.DeleteByInscriptionID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "documents purged", "deleted": n})
+}
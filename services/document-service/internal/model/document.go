@@ -7,9 +7,12 @@ import (
 )
 
 // Document represents metadata for an uploaded file (linked to an inscription).
+// TypeDocument is the piece of the dossier it stands for (cv, diplom, photo, ...),
+// empty for files uploaded without one.
 type Document struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
 	InscriptionID uint           `json:"inscription_id" gorm:"not null;index"`
+	TypeDocument  string         `json:"type_document" gorm:"type:varchar(50);not null;default:''"`
 	NomFichier    string         `json:"nom_fichier" gorm:"type:varchar(500);not null"`
 	ContentType   string         `json:"content_type" gorm:"type:varchar(100);not null"`
 	Size          int64          `json:"size" gorm:"not null"`
@@ -53,3 +53,10 @@ func (r *DocumentRepository) Update(doc *model.Document) error {
 func (r *DocumentRepository) Delete(id uint) error {
 	return r.db.Delete(&model.Document{}, id).Error
 }
+
+// DeleteByInscriptionID permanently deletes the document records of an inscription,
+// including those already soft-deleted, and returns how many were removed.
+func (r *DocumentRepository) DeleteByInscriptionID(inscriptionID uint) (int64, error) {
+	res := r.db.Unscoped().Where("inscription_id = ?", inscriptionID).Delete(&model.Document{})
+	return res.RowsAffected, res.Error
+}
@@ -32,4 +32,16 @@ func Setup(r *gin.Engine, dh *handler.DocumentHandler, jwtSecret string) {
 		// Delete document (Admin only)
 		auth.DELETE("/:id", middleware.RequireRole("ADMIN_ETABLISSEMENT"), dh.Delete)
 	}
+
+	// Internal endpoints for other services — SYSTEM role only
+	internal := r.Group("/internal")
+	internal.Use(middleware.AuthMiddleware(jwtSecret))
+	internal.Use(middleware.RequireRole("SYSTEM"))
+	{
+		// Documents of an inscription, for the candidate dashboard of application-service
+		internal.GET("/inscriptions/:id/documents", dh.ListInscription)
+
+		// Documents of an inscription purged by application-service
+		internal.DELETE("/inscriptions/:id/documents", dh.PurgeInscription)
+	}
 }
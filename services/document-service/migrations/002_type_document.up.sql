@@ -0,0 +1,2 @@
+-- Piece of the dossier a document stands for (cv, diplom, photo, ...)
+ALTER TABLE documents ADD COLUMN IF NOT EXISTS type_document VARCHAR(50) NOT NULL DEFAULT '';
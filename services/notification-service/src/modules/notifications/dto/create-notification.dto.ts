@@ -1,4 +1,28 @@
-import { IsEmail, IsNotEmpty, IsObject, IsOptional, IsString } from 'class-validator';
+import { Type } from 'class-transformer';
+import {
+  IsArray,
+  IsBase64,
+  IsEmail,
+  IsNotEmpty,
+  IsObject,
+  IsOptional,
+  IsString,
+  ValidateNested,
+} from 'class-validator';
+
+export class AttachmentDto {
+  @IsString()
+  @IsNotEmpty()
+  filename!: string;
+
+  @IsString()
+  @IsNotEmpty()
+  contentType!: string;
+
+  /** Base64-encoded file content. */
+  @IsBase64()
+  content!: string;
+}
 
 export class CreateNotificationDto {
   @IsString()
@@ -14,4 +38,10 @@ export class CreateNotificationDto {
 
   @IsObject()
   payload!: Record<string, unknown>;
+
+  @IsArray()
+  @IsOptional()
+  @ValidateNested({ each: true })
+  @Type(() => AttachmentDto)
+  attachments?: AttachmentDto[];
 }
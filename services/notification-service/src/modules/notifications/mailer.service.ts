@@ -1,5 +1,6 @@
 import { Injectable } from '@nestjs/common';
 import * as nodemailer from 'nodemailer';
+import { NotificationAttachment } from './notification.schema';
 
 @Injectable()
 export class MailerService {
@@ -18,6 +19,7 @@ export class MailerService {
     subject: string;
     html: string;
     correlationId: string;
+    attachments?: NotificationAttachment[];
   }): Promise<void> {
     const from = process.env.MAIL_FROM ?? 'no-reply@fst-cfc.local';
 
@@ -26,6 +28,12 @@ export class MailerService {
       to: params.to,
       subject: params.subject,
       html: params.html,
+      attachments: params.attachments?.map((a) => ({
+        filename: a.filename,
+        contentType: a.contentType,
+        content: a.content,
+        encoding: 'base64',
+      })),
       headers: {
         'x-correlation-id': params.correlationId,
       },
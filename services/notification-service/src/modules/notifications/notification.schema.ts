@@ -5,6 +5,12 @@ export type NotificationDocument = HydratedDocument<Notification>;
 
 export type NotificationStatus = 'PENDING' | 'SENT' | 'RETRYING' | 'DEAD';
 
+export type NotificationAttachment = {
+  filename: string;
+  contentType: string;
+  content: string; // base64
+};
+
 @Schema({ timestamps: true, collection: 'notifications' })
 export class Notification {
   @Prop({ required: true, index: true })
@@ -22,6 +28,9 @@ export class Notification {
   @Prop({ required: true, type: Object })
   payload!: Record<string, unknown>;
 
+  @Prop({ required: false, type: [Object], default: [] })
+  attachments?: NotificationAttachment[];
+
   @Prop({ required: true })
   channel!: 'email';
 
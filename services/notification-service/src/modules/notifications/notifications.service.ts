@@ -44,6 +44,7 @@ export class NotificationsService {
       language,
       recipient: dto.recipient,
       payload: dto.payload,
+      attachments: dto.attachments ?? [],
       channel: 'email',
       status: 'PENDING' satisfies NotificationStatus,
       attemptCount: 0,
@@ -100,6 +101,7 @@ export class NotificationsService {
         subject,
         html,
         correlationId: notification.correlationId,
+        attachments: notification.attachments,
       });
 
       await this.recordAttempt(_id, attemptNo, undefined, undefined, { ok: true });